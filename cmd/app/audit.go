@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"zadanie-6105/internal/audit"
+	"zadanie-6105/internal/config"
+	database "zadanie-6105/internal/db"
+	"zadanie-6105/internal/repositories"
+	"zadanie-6105/internal/server"
+	"zadanie-6105/internal/services"
+)
+
+func runCommand(name string, args []string) {
+	switch name {
+	case "verify":
+		runVerify(args)
+	case "digest":
+		runDigest(args)
+	case "import":
+		runImport(args)
+	default:
+		log.Fatalf("Unknown command %q, expected verify, digest or import", name)
+	}
+}
+
+// runVerify walks the audit hash chain and reports the first broken link.
+// With -digest it also checks an archived digest against the chain.
+func runVerify(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	digestFile := flags.String("digest", "", "exported digest to check against the chain")
+	flags.Parse(args)
+
+	resolver, auditService := openAudit()
+	defer resolver.Close()
+
+	ctx := context.Background()
+
+	verified, broken, err := auditService.VerifyChain(ctx)
+	if err != nil {
+		log.Fatalf("Failed to verify audit chain: %v", err)
+	}
+	if broken != nil {
+		fmt.Printf("Audit chain broken at event %d: %s (%d events verified before it)\n", broken.EventID, broken.Reason, verified)
+		os.Exit(1)
+	}
+	fmt.Printf("Audit chain intact: %d events verified\n", verified)
+
+	if *digestFile == "" {
+		return
+	}
+
+	data, err := os.ReadFile(*digestFile)
+	if err != nil {
+		log.Fatalf("Failed to read digest: %v", err)
+	}
+	var export audit.SignedDigest
+	if err := json.Unmarshal(data, &export); err != nil {
+		log.Fatalf("Failed to parse digest: %v", err)
+	}
+	if err := auditService.VerifyExport(ctx, &export); err != nil {
+		fmt.Printf("Digest %s does not verify: %v\n", *digestFile, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Digest %s verified\n", *digestFile)
+}
+
+// runDigest prints the signed digest of a day, yesterday by default,
+// creating any missing digests up to it first.
+func runDigest(args []string) {
+	flags := flag.NewFlagSet("digest", flag.ExitOnError)
+	dayFlag := flags.String("day", time.Now().UTC().AddDate(0, 0, -1).Format(audit.DayLayout), "UTC day to export, YYYY-MM-DD")
+	out := flags.String("out", "", "write the digest to this file instead of stdout")
+	flags.Parse(args)
+
+	day, err := time.Parse(audit.DayLayout, *dayFlag)
+	if err != nil {
+		log.Fatalf("Invalid day: %v", err)
+	}
+
+	resolver, auditService := openAudit()
+	defer resolver.Close()
+
+	ctx := context.Background()
+
+	_, err = repositories.NewJobLocker(resolver).RunExclusive(ctx, repositories.AuditDigestLockID, func(ctx context.Context) error {
+		_, err := auditService.CreateDueDigests(ctx, time.Now())
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to create audit digests: %v", err)
+	}
+
+	export, err := auditService.ExportDigest(ctx, day)
+	if err != nil {
+		log.Fatalf("Failed to export audit digest for %s: %v", *dayFlag, err)
+	}
+
+	data, err := json.Marshal(export)
+	if err != nil {
+		log.Fatalf("Failed to encode audit digest: %v", err)
+	}
+	data = append(data, '\n')
+
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatalf("Failed to write audit digest: %v", err)
+	}
+}
+
+func openAudit() (*database.Resolver, *services.AuditService) {
+	cfg, resolver := openDatabase()
+	auditService, err := server.NewAuditService(cfg, resolver)
+	if err != nil {
+		resolver.Close()
+		log.Fatalf("Failed to create audit service: %v", err)
+	}
+	return resolver, auditService
+}
+
+func openDatabase() (*config.Config, *database.Resolver) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	db, err := database.NewPostgresDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	return cfg, database.NewResolver(db)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"zadanie-6105/internal/audit"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+	"zadanie-6105/internal/server"
+	"zadanie-6105/internal/services"
+)
+
+// runImport creates tenders from a CSV or JSON Lines file on behalf of a
+// user and prints the result. It exits with status 1 when a row failed.
+func runImport(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	username := flags.String("user", "", "username of the employee importing the tenders")
+	format := flags.String("format", "", "csv or jsonl, by default taken from the file extension")
+	mode := flags.String("mode", string(models.TenderImportAllOrNothing), "all_or_nothing or best_effort")
+	dryRun := flags.Bool("dry-run", false, "only validate the rows")
+	flags.Usage = func() {
+		flags.Output().Write([]byte("Usage: app import -user USERNAME [flags] FILE\n"))
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *username == "" || flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	path := flags.Arg(0)
+
+	importFormat := models.TenderImportFormat(*format)
+	if importFormat == "" {
+		var ok bool
+		if importFormat, ok = models.TenderImportFormatOf(path); !ok {
+			log.Fatalf("Cannot tell the format of %s, use -format", path)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open import file: %v", err)
+	}
+	defer file.Close()
+
+	cfg, resolver := openDatabase()
+	defer resolver.Close()
+
+	auditService, err := server.NewAuditService(cfg, resolver)
+	if err != nil {
+		log.Fatalf("Failed to create audit service: %v", err)
+	}
+	tenderService := services.NewTenderService(
+		repositories.NewTenderRepository(resolver),
+		repositories.NewUnitOfWork(resolver),
+		auditService,
+		services.NewEventPublisher(repositories.NewOutboxRepository(resolver.Primary())),
+		cfg.TenderImportMaxRows,
+	)
+
+	ctx := audit.WithActor(context.Background(), audit.Actor{Username: *username})
+
+	result, err := tenderService.ImportTenders(ctx, *username, importFormat, file, models.TenderImportMode(*mode), *dryRun)
+	if err != nil {
+		log.Fatalf("Failed to import tenders: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		log.Fatalf("Failed to encode import result: %v", err)
+	}
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -10,25 +10,42 @@ import (
 	"time"
 
 	"zadanie-6105/internal/config"
+	database "zadanie-6105/internal/db"
 	"zadanie-6105/internal/server"
-
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 )
 
 func main() {
+	// Maintenance commands run instead of the server: app verify, app digest,
+	// app import
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	db, err := gorm.Open(postgres.Open(cfg.PostgresConn), &gorm.Config{})
+	db, err := database.NewPostgresDB(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	if err := database.Migrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	replicas, err := database.NewReplicaDBs(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to read replica: %v", err)
+	}
+
+	resolver := database.NewResolver(db, replicas...)
+	defer resolver.Close()
+
 	// Pass the database connection to the server setup
-	srv, err := server.NewServer(cfg, db)
+	srv, err := server.NewServer(cfg, resolver)
 	if err != nil {
 		log.Fatalf("Failed to create server: %v", err)
 	}
@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+	"zadanie-6105/internal/models"
+)
+
+// chainContent is the part of an event covered by its hash. Field order is
+// fixed by the struct, so the encoding is stable across releases.
+type chainContent struct {
+	PrevHash       string          `json:"prevHash"`
+	OccurredAt     string          `json:"occurredAt"`
+	ActorID        string          `json:"actorId"`
+	ActorUsername  string          `json:"actorUsername"`
+	ActorOrgID     string          `json:"actorOrganizationId"`
+	Action         string          `json:"action"`
+	EntityType     string          `json:"entityType"`
+	EntityID       string          `json:"entityId"`
+	OrganizationID string          `json:"organizationId"`
+	Before         json.RawMessage `json:"before"`
+	After          json.RawMessage `json:"after"`
+	RequestID      string          `json:"requestId"`
+	ClientIP       string          `json:"clientIp"`
+}
+
+// Hash returns the hex SHA-256 of the event content chained to prevHash.
+// The snapshots are canonicalized first because jsonb does not preserve the
+// formatting and key order of the JSON that was written.
+func Hash(prevHash string, event *models.AuditEvent) (string, error) {
+	before, err := canonicalJSON(event.Before)
+	if err != nil {
+		return "", err
+	}
+	after, err := canonicalJSON(event.After)
+	if err != nil {
+		return "", err
+	}
+
+	content := chainContent{
+		PrevHash:       prevHash,
+		OccurredAt:     event.OccurredAt.UTC().Format(time.RFC3339Nano),
+		ActorUsername:  event.ActorUsername,
+		ActorOrgID:     event.ActorOrgID,
+		Action:         string(event.Action),
+		EntityType:     string(event.EntityType),
+		EntityID:       event.EntityID,
+		OrganizationID: event.OrganizationID,
+		Before:         before,
+		After:          after,
+		RequestID:      event.RequestID,
+		ClientIP:       event.ClientIP,
+	}
+	if event.ActorID != nil {
+		content.ActorID = *event.ActorID
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalJSON re-encodes a snapshot with sorted keys and no insignificant
+// whitespace. Numbers are kept as written.
+func canonicalJSON(data []byte) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return json.RawMessage("null"), nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+	"zadanie-6105/internal/models"
+)
+
+func testEvent() *models.AuditEvent {
+	actorID := "2c1a4a6e-8f8e-4d7b-9a3f-0f3d3c1a2b4c"
+	return &models.AuditEvent{
+		OccurredAt:     time.Date(2026, 10, 1, 12, 30, 0, 123456000, time.UTC),
+		ActorID:        &actorID,
+		ActorUsername:  "user1",
+		Action:         models.AuditTenderUpdated,
+		EntityType:     models.AuditEntityTender,
+		EntityID:       "7b0e8c44-1b7e-4c55-8a3e-5d2f1c0b9a11",
+		OrganizationID: "0e6a5b2d-3c4f-4a1b-9e8d-7c6b5a4f3e2d",
+		Before:         models.AuditSnapshot(`{"name":"Tender","version":1}`),
+		After:          models.AuditSnapshot(`{"name":"Tender 2","version":2}`),
+		RequestID:      "req-1",
+		ClientIP:       "10.0.0.1",
+	}
+}
+
+func TestHash(t *testing.T) {
+	base := testEvent()
+	baseHash, err := Hash("", base)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if len(baseHash) != 64 {
+		t.Fatalf("Hash() = %q, want 64 hex digits", baseHash)
+	}
+
+	tests := []struct {
+		name     string
+		prevHash string
+		change   func(*models.AuditEvent)
+		wantSame bool
+	}{
+		{name: "same event", change: func(*models.AuditEvent) {}, wantSame: true},
+		{
+			name: "snapshot key order and whitespace",
+			change: func(e *models.AuditEvent) {
+				e.After = models.AuditSnapshot("{ \"version\": 2,\n \"name\": \"Tender 2\" }")
+			},
+			wantSame: true,
+		},
+		{
+			name:     "time in another zone",
+			change:   func(e *models.AuditEvent) { e.OccurredAt = e.OccurredAt.In(time.FixedZone("MSK", 3*60*60)) },
+			wantSame: true,
+		},
+		{name: "previous hash", prevHash: baseHash, change: func(*models.AuditEvent) {}},
+		{name: "snapshot value", change: func(e *models.AuditEvent) { e.After = models.AuditSnapshot(`{"name":"Tender 3","version":2}`) }},
+		{name: "number as written", change: func(e *models.AuditEvent) { e.After = models.AuditSnapshot(`{"name":"Tender 2","version":2.0}`) }},
+		{name: "no snapshot", change: func(e *models.AuditEvent) { e.Before = nil }},
+		{name: "actor", change: func(e *models.AuditEvent) { e.ActorID = nil }},
+		{name: "time", change: func(e *models.AuditEvent) { e.OccurredAt = e.OccurredAt.Add(time.Microsecond) }},
+		{name: "client IP", change: func(e *models.AuditEvent) { e.ClientIP = "10.0.0.2" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := testEvent()
+			tt.change(event)
+			hash, err := Hash(tt.prevHash, event)
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			if (hash == baseHash) != tt.wantSame {
+				t.Errorf("Hash() = %s, base %s, want same = %v", hash, baseHash, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestHashInvalidSnapshot(t *testing.T) {
+	event := testEvent()
+	event.Before = models.AuditSnapshot(`{"name":`)
+	if _, err := Hash("", event); err == nil {
+		t.Error("Hash() error = nil, want an error for invalid JSON")
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "seed", key: base64.StdEncoding.EncodeToString(seed)},
+		{name: "private key", key: base64.StdEncoding.EncodeToString(key)},
+		{name: "not base64", key: "not base64!", wantErr: true},
+		{name: "wrong length", key: base64.StdEncoding.EncodeToString(seed[:16]), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := NewSigner(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSigner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && signer.PublicKey() != base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)) {
+				t.Errorf("PublicKey() = %s, want the key of the seed", signer.PublicKey())
+			}
+		})
+	}
+}
+
+func TestSignerExportVerify(t *testing.T) {
+	signer, err := NewSigner(base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize)))
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+
+	digest := &models.AuditDigest{
+		Day:          time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		FirstEventID: 10,
+		LastEventID:  20,
+		EventCount:   11,
+		PrevHash:     "aa",
+		HeadHash:     "bb",
+	}
+	if digest.Signature, err = signer.Sign(NewDigestPayload(digest)); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	export, err := signer.Export(digest)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if err := signer.Verify(export.Digest, export.Signature); err != nil {
+		t.Errorf("Verify() of the export error = %v", err)
+	}
+
+	tampered := []byte(string(export.Digest[:len(export.Digest)-1]) + " }")
+	if err := signer.Verify(tampered, export.Signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() of a changed digest error = %v, want ErrInvalidSignature", err)
+	}
+	if err := signer.Verify(export.Digest, "not base64!"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() of a malformed signature error = %v, want ErrInvalidSignature", err)
+	}
+}
@@ -0,0 +1,47 @@
+// Package audit carries the actor and request details of the current
+// request down to the services that write audit events, and implements the
+// hash chain and signed daily digests that make the audit trail
+// tamper-evident.
+package audit
+
+import "context"
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	requestKey
+)
+
+// Actor is who performed a mutation: an employee, an organization or, for
+// background jobs, the system.
+type Actor struct {
+	Username       string
+	OrganizationID string
+}
+
+// SystemActor is recorded for changes made by background jobs.
+var SystemActor = Actor{Username: "system"}
+
+type RequestInfo struct {
+	RequestID string
+	ClientIP  string
+}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey).(Actor)
+	return actor
+}
+
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestKey, info)
+}
+
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestKey).(RequestInfo)
+	return info
+}
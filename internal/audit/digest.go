@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"zadanie-6105/internal/models"
+)
+
+const (
+	DayLayout        = "2006-01-02"
+	SigningAlgorithm = "Ed25519"
+)
+
+var ErrInvalidSignature = errors.New("invalid digest signature")
+
+// DigestPayload is the signed content of a daily digest.
+type DigestPayload struct {
+	Day          string `json:"day"`
+	FirstEventID int64  `json:"firstEventId"`
+	LastEventID  int64  `json:"lastEventId"`
+	EventCount   int64  `json:"eventCount"`
+	PrevHash     string `json:"prevHash"`
+	HeadHash     string `json:"headHash"`
+}
+
+func NewDigestPayload(digest *models.AuditDigest) DigestPayload {
+	return DigestPayload{
+		Day:          digest.Day.Format(DayLayout),
+		FirstEventID: digest.FirstEventID,
+		LastEventID:  digest.LastEventID,
+		EventCount:   digest.EventCount,
+		PrevHash:     digest.PrevHash,
+		HeadHash:     digest.HeadHash,
+	}
+}
+
+// SignedDigest is the export format handed to auditors. Signature is made
+// over the exact bytes of Digest, so the document must be archived as is.
+type SignedDigest struct {
+	Digest    json.RawMessage `json:"digest"`
+	Algorithm string          `json:"algorithm"`
+	PublicKey string          `json:"publicKey"`
+	Signature string          `json:"signature"`
+}
+
+// Signer signs daily digests with an Ed25519 key.
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewSigner accepts a base64-encoded Ed25519 seed (32 bytes) or private key
+// (64 bytes).
+func NewSigner(encodedKey string) (*Signer, error) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit signing key: %w", err)
+	}
+
+	switch len(key) {
+	case ed25519.SeedSize:
+		return &Signer{key: ed25519.NewKeyFromSeed(key)}, nil
+	case ed25519.PrivateKeySize:
+		return &Signer{key: ed25519.PrivateKey(key)}, nil
+	default:
+		return nil, fmt.Errorf("invalid audit signing key: expected %d or %d bytes, got %d", ed25519.SeedSize, ed25519.PrivateKeySize, len(key))
+	}
+}
+
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// Sign returns the base64 signature of the payload encoding.
+func (s *Signer) Sign(payload DigestPayload) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, data)), nil
+}
+
+// Export wraps a stored digest into the signed document for auditors.
+func (s *Signer) Export(digest *models.AuditDigest) (*SignedDigest, error) {
+	data, err := json.Marshal(NewDigestPayload(digest))
+	if err != nil {
+		return nil, err
+	}
+	return &SignedDigest{
+		Digest:    data,
+		Algorithm: SigningAlgorithm,
+		PublicKey: s.PublicKey(),
+		Signature: digest.Signature,
+	}, nil
+}
+
+// Verify checks that signature was made by this signer's key over payload.
+func (s *Signer) Verify(payload []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if !ed25519.Verify(s.key.Public().(ed25519.PublicKey), payload, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -18,6 +20,124 @@ type Config struct {
 	PostgresHost     string
 	PostgresPort     int
 	PostgresDatabase string
+
+	// TLS settings, passed to the driver as sslmode/sslrootcert/sslcert/sslkey
+	PostgresSSLMode     string
+	PostgresSSLRootCert string
+	PostgresSSLCert     string
+	PostgresSSLKey      string
+
+	// Connection pool settings of the underlying sql.DB
+	PostgresMaxOpenConns    int
+	PostgresMaxIdleConns    int
+	PostgresConnMaxLifetime time.Duration
+	PostgresConnMaxIdleTime time.Duration
+
+	// Server-side statement_timeout, zero disables it
+	PostgresStatementTimeout time.Duration
+
+	// Startup retry with exponential backoff while Postgres is not reachable
+	PostgresConnectAttempts   int
+	PostgresConnectBackoff    time.Duration
+	PostgresConnectMaxBackoff time.Duration
+
+	// Optional read replicas for list and search queries
+	PostgresReplicaConns []string
+	// How long reads stay on the primary after a client's mutation
+	ForcePrimaryWindow time.Duration
+
+	// Lifetime of stored Idempotency-Key responses and how often they are swept
+	IdempotencyKeyTTL        time.Duration
+	IdempotencySweepInterval time.Duration
+
+	// How long a reviewer may edit or delete a bid review, zero disables the limit
+	ReviewEditWindow time.Duration
+
+	// Bid lifecycle rules: at most one active bid per author and tender, and
+	// whether a bid may still be withdrawn once a decision is recorded
+	BidSingleActive          bool
+	BidWithdrawAfterDecision bool
+
+	// How often the tender scheduler checks deadlines
+	TenderSchedulerInterval time.Duration
+
+	// Attachment storage backend: "local" or "s3"
+	StorageBackend  string
+	StorageLocalDir string
+
+	// S3-compatible object storage (AWS S3, MinIO, Ceph RGW, ...)
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3UsePathStyle bool
+
+	// Take the client IP for audit events from X-Forwarded-For / X-Real-IP
+	TrustProxyHeaders bool
+
+	// Base64 Ed25519 key signing the daily audit digests; digests are not
+	// produced when it is empty
+	AuditSigningKey     string
+	AuditDigestInterval time.Duration
+
+	// Webhook delivery: polling interval, request timeout and retries with
+	// exponential backoff before a delivery is dead-lettered
+	WebhookDispatchInterval time.Duration
+	WebhookTimeout          time.Duration
+	WebhookMaxAttempts      int
+	WebhookRetryBackoff     time.Duration
+	WebhookMaxRetryBackoff  time.Duration
+	// Allow webhooks to private and local addresses, for development
+	WebhookAllowPrivateTargets bool
+
+	// SMTP relay for email notifications; email is not sent when SMTPHost
+	// is empty
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	SMTPTimeout  time.Duration
+
+	// Email queue: language for employees without one, polling interval and
+	// retries with exponential backoff
+	EmailDefaultLanguage string
+	EmailSendInterval    time.Duration
+	EmailMaxAttempts     int
+	EmailRetryBackoff    time.Duration
+	EmailMaxRetryBackoff time.Duration
+
+	// Live event streams: how long events are kept for resuming clients and
+	// how often idle connections, including review rooms, are pinged
+	StreamRetention         time.Duration
+	StreamHeartbeatInterval time.Duration
+	// How long a review room participant outlives the replica holding its
+	// connection
+	ReviewRoomPresenceTTL time.Duration
+	// Origins of other sites allowed to connect to review rooms, besides the
+	// origin of the API itself
+	ReviewRoomAllowedOrigins []string
+
+	// Most rows of a bulk tender import
+	TenderImportMaxRows int
+
+	// Upload limits for attachments
+	AttachmentMaxSize      int64
+	AttachmentAllowedTypes []string
+}
+
+var defaultAttachmentTypes = []string{
+	"application/pdf",
+	"application/zip",
+	"application/msword",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.ms-excel",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"image/png",
+	"image/jpeg",
+	"text/plain",
+	"text/csv",
 }
 
 func LoadConfig() (*Config, error) {
@@ -28,13 +148,30 @@ func LoadConfig() (*Config, error) {
 	}
 
 	cfg := &Config{
-		ServerAddress:    os.Getenv("SERVER_ADDRESS"),
-		PostgresConn:     os.Getenv("POSTGRES_CONN"),
-		PostgresJDBCURL:  os.Getenv("POSTGRES_JDBC_URL"),
-		PostgresUsername: os.Getenv("POSTGRES_USERNAME"),
-		PostgresPassword: os.Getenv("POSTGRES_PASSWORD"),
-		PostgresHost:     os.Getenv("POSTGRES_HOST"),
-		PostgresDatabase: os.Getenv("POSTGRES_DATABASE"),
+		ServerAddress:        os.Getenv("SERVER_ADDRESS"),
+		PostgresConn:         os.Getenv("POSTGRES_CONN"),
+		PostgresJDBCURL:      os.Getenv("POSTGRES_JDBC_URL"),
+		PostgresUsername:     os.Getenv("POSTGRES_USERNAME"),
+		PostgresPassword:     os.Getenv("POSTGRES_PASSWORD"),
+		PostgresHost:         os.Getenv("POSTGRES_HOST"),
+		PostgresDatabase:     os.Getenv("POSTGRES_DATABASE"),
+		PostgresSSLMode:      os.Getenv("POSTGRES_SSLMODE"),
+		PostgresSSLRootCert:  os.Getenv("POSTGRES_SSLROOTCERT"),
+		PostgresSSLCert:      os.Getenv("POSTGRES_SSLCERT"),
+		PostgresSSLKey:       os.Getenv("POSTGRES_SSLKEY"),
+		StorageBackend:       os.Getenv("STORAGE_BACKEND"),
+		StorageLocalDir:      os.Getenv("STORAGE_LOCAL_DIR"),
+		S3Endpoint:           os.Getenv("S3_ENDPOINT"),
+		S3Region:             os.Getenv("S3_REGION"),
+		S3Bucket:             os.Getenv("S3_BUCKET"),
+		S3AccessKey:          os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:          os.Getenv("S3_SECRET_KEY"),
+		AuditSigningKey:      os.Getenv("AUDIT_SIGNING_KEY"),
+		SMTPHost:             os.Getenv("SMTP_HOST"),
+		SMTPUsername:         os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:         os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:             os.Getenv("SMTP_FROM"),
+		EmailDefaultLanguage: os.Getenv("EMAIL_DEFAULT_LANGUAGE"),
 	}
 
 	portStr := os.Getenv("POSTGRES_PORT")
@@ -49,6 +186,161 @@ func LoadConfig() (*Config, error) {
 		cfg.PostgresPort = port
 	}
 
+	var err error
+	if cfg.PostgresMaxOpenConns, err = getEnvInt("POSTGRES_MAX_OPEN_CONNS", 25); err != nil {
+		return nil, err
+	}
+	if cfg.PostgresMaxIdleConns, err = getEnvInt("POSTGRES_MAX_IDLE_CONNS", 10); err != nil {
+		return nil, err
+	}
+	if cfg.PostgresConnMaxLifetime, err = getEnvDuration("POSTGRES_CONN_MAX_LIFETIME", 30*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.PostgresConnMaxIdleTime, err = getEnvDuration("POSTGRES_CONN_MAX_IDLE_TIME", 5*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.PostgresStatementTimeout, err = getEnvDuration("POSTGRES_STATEMENT_TIMEOUT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.PostgresConnectAttempts, err = getEnvInt("POSTGRES_CONNECT_ATTEMPTS", 10); err != nil {
+		return nil, err
+	}
+	if cfg.PostgresConnectBackoff, err = getEnvDuration("POSTGRES_CONNECT_BACKOFF", 500*time.Millisecond); err != nil {
+		return nil, err
+	}
+	if cfg.PostgresConnectMaxBackoff, err = getEnvDuration("POSTGRES_CONNECT_MAX_BACKOFF", 30*time.Second); err != nil {
+		return nil, err
+	}
+
+	if cfg.ForcePrimaryWindow, err = getEnvDuration("FORCE_PRIMARY_WINDOW", 5*time.Second); err != nil {
+		return nil, err
+	}
+
+	if cfg.IdempotencyKeyTTL, err = getEnvDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.IdempotencySweepInterval, err = getEnvDuration("IDEMPOTENCY_SWEEP_INTERVAL", time.Hour); err != nil {
+		return nil, err
+	}
+
+	if cfg.ReviewEditWindow, err = getEnvDuration("REVIEW_EDIT_WINDOW", 24*time.Hour); err != nil {
+		return nil, err
+	}
+
+	if cfg.BidSingleActive, err = getEnvBool("BID_SINGLE_ACTIVE", true); err != nil {
+		return nil, err
+	}
+	if cfg.BidWithdrawAfterDecision, err = getEnvBool("BID_WITHDRAW_AFTER_DECISION", false); err != nil {
+		return nil, err
+	}
+
+	if cfg.TenderSchedulerInterval, err = getEnvDuration("TENDER_SCHEDULER_INTERVAL", time.Minute); err != nil {
+		return nil, err
+	}
+
+	if cfg.TrustProxyHeaders, err = getEnvBool("TRUST_PROXY_HEADERS", false); err != nil {
+		return nil, err
+	}
+	if cfg.AuditDigestInterval, err = getEnvDuration("AUDIT_DIGEST_INTERVAL", time.Hour); err != nil {
+		return nil, err
+	}
+
+	if cfg.WebhookDispatchInterval, err = getEnvDuration("WEBHOOK_DISPATCH_INTERVAL", 5*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.WebhookTimeout, err = getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.WebhookMaxAttempts, err = getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8); err != nil {
+		return nil, err
+	}
+	if cfg.WebhookRetryBackoff, err = getEnvDuration("WEBHOOK_RETRY_BACKOFF", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.WebhookMaxRetryBackoff, err = getEnvDuration("WEBHOOK_MAX_RETRY_BACKOFF", 6*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.WebhookAllowPrivateTargets, err = getEnvBool("WEBHOOK_ALLOW_PRIVATE_TARGETS", false); err != nil {
+		return nil, err
+	}
+
+	if cfg.SMTPPort, err = getEnvInt("SMTP_PORT", 587); err != nil {
+		return nil, err
+	}
+	if cfg.SMTPTimeout, err = getEnvDuration("SMTP_TIMEOUT", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.EmailSendInterval, err = getEnvDuration("EMAIL_SEND_INTERVAL", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.EmailMaxAttempts, err = getEnvInt("EMAIL_MAX_ATTEMPTS", 5); err != nil {
+		return nil, err
+	}
+	if cfg.EmailRetryBackoff, err = getEnvDuration("EMAIL_RETRY_BACKOFF", time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.EmailMaxRetryBackoff, err = getEnvDuration("EMAIL_MAX_RETRY_BACKOFF", time.Hour); err != nil {
+		return nil, err
+	}
+
+	if cfg.StreamRetention, err = getEnvDuration("STREAM_RETENTION", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.StreamHeartbeatInterval, err = getEnvDuration("STREAM_HEARTBEAT_INTERVAL", 15*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.ReviewRoomPresenceTTL, err = getEnvDuration("REVIEW_ROOM_PRESENCE_TTL", 30*time.Second); err != nil {
+		return nil, err
+	}
+
+	if cfg.TenderImportMaxRows, err = getEnvInt("TENDER_IMPORT_MAX_ROWS", 1000); err != nil {
+		return nil, err
+	}
+
+	if cfg.S3UsePathStyle, err = getEnvBool("S3_USE_PATH_STYLE", false); err != nil {
+		return nil, err
+	}
+
+	attachmentMaxSize, err := getEnvInt("ATTACHMENT_MAX_SIZE", 20<<20)
+	if err != nil {
+		return nil, err
+	}
+	cfg.AttachmentMaxSize = int64(attachmentMaxSize)
+
+	for _, conn := range strings.Split(os.Getenv("POSTGRES_REPLICA_CONNS"), ",") {
+		if conn = strings.TrimSpace(conn); conn != "" {
+			cfg.PostgresReplicaConns = append(cfg.PostgresReplicaConns, conn)
+		}
+	}
+
+	for _, contentType := range strings.Split(os.Getenv("ATTACHMENT_ALLOWED_TYPES"), ",") {
+		if contentType = strings.TrimSpace(contentType); contentType != "" {
+			cfg.AttachmentAllowedTypes = append(cfg.AttachmentAllowedTypes, contentType)
+		}
+	}
+	if len(cfg.AttachmentAllowedTypes) == 0 {
+		cfg.AttachmentAllowedTypes = defaultAttachmentTypes
+	}
+
+	for _, origin := range strings.Split(os.Getenv("REVIEW_ROOM_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.ReviewRoomAllowedOrigins = append(cfg.ReviewRoomAllowedOrigins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+
+	if cfg.StorageBackend == "" {
+		cfg.StorageBackend = "local"
+	}
+	if cfg.StorageLocalDir == "" {
+		cfg.StorageLocalDir = "data/attachments"
+	}
+	if cfg.EmailDefaultLanguage == "" {
+		cfg.EmailDefaultLanguage = "ru"
+	}
+	if cfg.S3Region == "" {
+		cfg.S3Region = "us-east-1"
+	}
+
 	if cfg.ServerAddress == "" {
 		cfg.ServerAddress = ":8080"
 	}
@@ -57,10 +349,117 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("either POSTGRES_CONN or all of POSTGRES_HOST, POSTGRES_USERNAME, POSTGRES_PASSWORD, and POSTGRES_DATABASE must be set")
 	}
 
+	if cfg.PostgresConnectAttempts < 1 {
+		return nil, fmt.Errorf("POSTGRES_CONNECT_ATTEMPTS must be at least 1")
+	}
+
+	if cfg.IdempotencySweepInterval <= 0 {
+		return nil, fmt.Errorf("IDEMPOTENCY_SWEEP_INTERVAL must be positive")
+	}
+
+	if cfg.TenderSchedulerInterval <= 0 {
+		return nil, fmt.Errorf("TENDER_SCHEDULER_INTERVAL must be positive")
+	}
+
+	if cfg.AuditDigestInterval <= 0 {
+		return nil, fmt.Errorf("AUDIT_DIGEST_INTERVAL must be positive")
+	}
+
+	if cfg.WebhookDispatchInterval <= 0 || cfg.WebhookTimeout <= 0 || cfg.WebhookRetryBackoff <= 0 {
+		return nil, fmt.Errorf("WEBHOOK_DISPATCH_INTERVAL, WEBHOOK_TIMEOUT and WEBHOOK_RETRY_BACKOFF must be positive")
+	}
+	if cfg.WebhookMaxAttempts < 1 {
+		return nil, fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be at least 1")
+	}
+	if cfg.WebhookMaxRetryBackoff < cfg.WebhookRetryBackoff {
+		return nil, fmt.Errorf("WEBHOOK_MAX_RETRY_BACKOFF must not be less than WEBHOOK_RETRY_BACKOFF")
+	}
+
+	if cfg.SMTPHost != "" && cfg.SMTPFrom == "" {
+		return nil, fmt.Errorf("SMTP_FROM must be set when SMTP_HOST is set")
+	}
+	if cfg.EmailDefaultLanguage != "ru" && cfg.EmailDefaultLanguage != "en" {
+		return nil, fmt.Errorf("invalid EMAIL_DEFAULT_LANGUAGE: %q", cfg.EmailDefaultLanguage)
+	}
+	if cfg.SMTPTimeout <= 0 || cfg.EmailSendInterval <= 0 || cfg.EmailRetryBackoff <= 0 {
+		return nil, fmt.Errorf("SMTP_TIMEOUT, EMAIL_SEND_INTERVAL and EMAIL_RETRY_BACKOFF must be positive")
+	}
+	if cfg.EmailMaxAttempts < 1 {
+		return nil, fmt.Errorf("EMAIL_MAX_ATTEMPTS must be at least 1")
+	}
+	if cfg.EmailMaxRetryBackoff < cfg.EmailRetryBackoff {
+		return nil, fmt.Errorf("EMAIL_MAX_RETRY_BACKOFF must not be less than EMAIL_RETRY_BACKOFF")
+	}
+
+	if cfg.StreamRetention <= 0 || cfg.StreamHeartbeatInterval <= 0 {
+		return nil, fmt.Errorf("STREAM_RETENTION and STREAM_HEARTBEAT_INTERVAL must be positive")
+	}
+	// Participants are extended every third of the TTL
+	if cfg.ReviewRoomPresenceTTL < 3*time.Second {
+		return nil, fmt.Errorf("REVIEW_ROOM_PRESENCE_TTL must be at least 3s")
+	}
+
+	if cfg.TenderImportMaxRows < 1 {
+		return nil, fmt.Errorf("TENDER_IMPORT_MAX_ROWS must be at least 1")
+	}
+
+	switch cfg.StorageBackend {
+	case "local":
+	case "s3":
+		if cfg.S3Endpoint == "" || cfg.S3Bucket == "" || cfg.S3AccessKey == "" || cfg.S3SecretKey == "" {
+			return nil, fmt.Errorf("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY must be set for STORAGE_BACKEND=s3")
+		}
+	default:
+		return nil, fmt.Errorf("invalid STORAGE_BACKEND: %q", cfg.StorageBackend)
+	}
+
+	if cfg.AttachmentMaxSize <= 0 {
+		return nil, fmt.Errorf("ATTACHMENT_MAX_SIZE must be positive")
+	}
+
 	log.Printf("ServerAddress: %s", cfg.ServerAddress)
 	log.Printf("PostgresHost: %s", cfg.PostgresHost)
 	log.Printf("PostgresPort: %d", cfg.PostgresPort)
 	log.Printf("PostgresDatabase: %s", cfg.PostgresDatabase)
+	log.Printf("PostgresReplicas: %d", len(cfg.PostgresReplicaConns))
+	log.Printf("StorageBackend: %s", cfg.StorageBackend)
 
 	return cfg, nil
 }
+
+func getEnvInt(key string, defaultValue int) (int, error) {
+	valStr := os.Getenv(key)
+	if valStr == "" {
+		return defaultValue, nil
+	}
+	val, err := strconv.Atoi(valStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return val, nil
+}
+
+func getEnvBool(key string, defaultValue bool) (bool, error) {
+	valStr := os.Getenv(key)
+	if valStr == "" {
+		return defaultValue, nil
+	}
+	val, err := strconv.ParseBool(valStr)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return val, nil
+}
+
+// getEnvDuration accepts Go duration strings ("5s", "1m30s").
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	valStr := os.Getenv(key)
+	if valStr == "" {
+		return defaultValue, nil
+	}
+	val, err := time.ParseDuration(valStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return val, nil
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"fmt"
+
+	"zadanie-6105/internal/audit"
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Migrate creates the tables owned by the service itself and adds the
+// columns it needs to existing ones. The employee, organization, tenders and
+// bids tables are provisioned externally.
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(
+		&models.IdempotencyKey{},
+		&models.BidReview{},
+		&models.BidDecision{},
+		&models.EvaluationCriterion{},
+		&models.BidScore{},
+		&models.Attachment{},
+		&models.TenderQuestion{},
+		&models.TenderInvitation{},
+		&models.BidStatusChange{},
+		&models.AuditEvent{},
+		&models.AuditDigest{},
+		&models.OutboxEvent{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.EmailMessage{},
+		&models.StreamEvent{},
+		&models.ReviewParticipant{},
+	); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	for _, statement := range auditImmutabilitySQL {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	if err := chainAuditEvents(db); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	// A fork of the hash chain would reuse a previous hash
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_events_prev_hash ON audit_events (prev_hash)").Error; err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	// Columns added to the externally provisioned tables. AutoMigrate is not
+	// used on them so that existing column definitions are left untouched.
+	if err := addColumns(db, &models.Tender{}, "SubmissionDeadline", "DecisionDeadline", "PublishAt", "Budget", "Currency", "Visibility"); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	if err := addColumns(db, &models.Bid{}, "Amount", "Currency", "DeliveryDays", "ValidUntil", "LineItems"); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	if err := addColumns(db, &models.Employee{}, "Email", "Language"); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return nil
+}
+
+// auditImmutabilitySQL makes audit_events and audit_digests append-only for
+// every database user, including the service itself.
+var auditImmutabilitySQL = []string{
+	`CREATE OR REPLACE FUNCTION audit_events_immutable() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+	END;
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS audit_events_immutable ON audit_events`,
+	`CREATE TRIGGER audit_events_immutable
+		BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_events
+		FOR EACH STATEMENT EXECUTE FUNCTION audit_events_immutable()`,
+	`DROP TRIGGER IF EXISTS audit_digests_immutable ON audit_digests`,
+	`CREATE TRIGGER audit_digests_immutable
+		BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_digests
+		FOR EACH STATEMENT EXECUTE FUNCTION audit_events_immutable()`,
+}
+
+// chainAuditEvents hashes the events written before the hash chain was
+// introduced. They precede every hashed event, so the chain starts with
+// them. The immutability trigger is disabled for this one-off update only.
+func chainAuditEvents(db *gorm.DB) error {
+	var events []*models.AuditEvent
+	if err := db.Where("hash = ''").Order("id").Find(&events).Error; err != nil || len(events) == 0 {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE audit_events DISABLE TRIGGER audit_events_immutable").Error; err != nil {
+			return err
+		}
+
+		prevHash := ""
+		for _, event := range events {
+			hash, err := audit.Hash(prevHash, event)
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&models.AuditEvent{}).
+				Where("id = ?", event.ID).
+				Updates(map[string]interface{}{"prev_hash": prevHash, "hash": hash}).Error
+			if err != nil {
+				return err
+			}
+			prevHash = hash
+		}
+
+		return tx.Exec("ALTER TABLE audit_events ENABLE TRIGGER audit_events_immutable").Error
+	})
+}
+
+func addColumns(db *gorm.DB, model interface{}, fields ...string) error {
+	migrator := db.Migrator()
+	for _, field := range fields {
+		if migrator.HasColumn(model, field) {
+			continue
+		}
+		if err := migrator.AddColumn(model, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,41 +1,196 @@
 package database
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"log"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"zadanie-6105/internal/config"
 
-	_ "github.com/lib/pq"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
 )
 
-func NewPostgresDB(cfg *config.Config) (*sql.DB, error) {
-	var connStr string
+// NewPostgresDB opens the application database, retrying with exponential
+// backoff while Postgres is not yet accepting connections, and configures
+// the connection pool of the underlying sql.DB.
+func NewPostgresDB(cfg *config.Config) (*gorm.DB, error) {
+	return connect(BuildDSN(cfg), cfg)
+}
+
+// NewReplicaDBs opens every read replica listed in POSTGRES_REPLICA_CONNS
+// with the same pool, TLS and retry settings as the primary.
+func NewReplicaDBs(cfg *config.Config) ([]*gorm.DB, error) {
+	replicas := make([]*gorm.DB, 0, len(cfg.PostgresReplicaConns))
+	for i, conn := range cfg.PostgresReplicaConns {
+		db, err := connect(buildDSN(conn, cfg), cfg)
+		if err != nil {
+			return nil, fmt.Errorf("replica %d: %w", i+1, err)
+		}
+		replicas = append(replicas, db)
+	}
+	return replicas, nil
+}
+
+func connect(dsn string, cfg *config.Config) (*gorm.DB, error) {
+	var db *gorm.DB
+	var err error
+	backoff := cfg.PostgresConnectBackoff
+
+	for attempt := 1; attempt <= cfg.PostgresConnectAttempts; attempt++ {
+		db, err = open(dsn, cfg)
+		if err == nil {
+			break
+		}
+
+		if attempt == cfg.PostgresConnectAttempts {
+			return nil, fmt.Errorf("failed to connect to database after %d attempts: %w", attempt, err)
+		}
+
+		log.Printf("Database not ready (attempt %d/%d): %v. Retrying in %v", attempt, cfg.PostgresConnectAttempts, err, backoff)
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > cfg.PostgresConnectMaxBackoff {
+			backoff = cfg.PostgresConnectMaxBackoff
+		}
+	}
+
+	log.Println("Connected to PostgreSQL database!")
+	return db, nil
+}
+
+func open(dsn string, cfg *config.Config) (*gorm.DB, error) {
+	// The connection is checked below, with a timeout, instead of by gorm
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		// Do not leak the pool of a failed attempt
+		if db != nil {
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				sqlDB.Close()
+			}
+		}
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+
+	sqlDB.SetMaxOpenConns(cfg.PostgresMaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.PostgresMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.PostgresConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.PostgresConnMaxIdleTime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
 
-	if cfg.PostgresConn != "" {
-		connStr = cfg.PostgresConn
-	} else {
-		connStr = fmt.Sprintf(
-			"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+	return db, nil
+}
+
+// BuildDSN returns POSTGRES_CONN (or a DSN assembled from the individual
+// POSTGRES_* variables) extended with the TLS and statement timeout options.
+// Options already present in POSTGRES_CONN take precedence.
+func BuildDSN(cfg *config.Config) string {
+	return buildDSN(cfg.PostgresConn, cfg)
+}
+
+func buildDSN(conn string, cfg *config.Config) string {
+	params := dsnParams(cfg)
+
+	if conn == "" {
+		if _, ok := params["sslmode"]; !ok {
+			params["sslmode"] = "disable"
+		}
+		dsn := fmt.Sprintf(
+			"host=%s port=%s user=%s password=%s dbname=%s",
 			cfg.PostgresHost,
 			strconv.Itoa(cfg.PostgresPort),
 			cfg.PostgresUsername,
-			cfg.PostgresPassword,
+			quoteKeywordValue(cfg.PostgresPassword),
 			cfg.PostgresDatabase,
 		)
+		return appendKeywordParams(dsn, params)
 	}
 
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	if strings.HasPrefix(conn, "postgres://") || strings.HasPrefix(conn, "postgresql://") {
+		u, err := url.Parse(conn)
+		if err != nil {
+			// Let the driver report the malformed URL
+			return conn
+		}
+		query := u.Query()
+		for key, value := range params {
+			if query.Get(key) == "" {
+				query.Set(key, value)
+			}
+		}
+		u.RawQuery = query.Encode()
+		return u.String()
 	}
 
-	if err = db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	for key := range params {
+		if strings.Contains(conn, key+"=") {
+			delete(params, key)
+		}
 	}
+	return appendKeywordParams(conn, params)
+}
 
-	log.Println("Connected to PostgreSQL database!")
-	return db, nil
+func dsnParams(cfg *config.Config) map[string]string {
+	params := map[string]string{}
+	if cfg.PostgresSSLMode != "" {
+		params["sslmode"] = cfg.PostgresSSLMode
+	}
+	if cfg.PostgresSSLRootCert != "" {
+		params["sslrootcert"] = cfg.PostgresSSLRootCert
+	}
+	if cfg.PostgresSSLCert != "" {
+		params["sslcert"] = cfg.PostgresSSLCert
+	}
+	if cfg.PostgresSSLKey != "" {
+		params["sslkey"] = cfg.PostgresSSLKey
+	}
+	if cfg.PostgresStatementTimeout > 0 {
+		params["statement_timeout"] = strconv.FormatInt(cfg.PostgresStatementTimeout.Milliseconds(), 10)
+	}
+	return params
+}
+
+func appendKeywordParams(dsn string, params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(dsn)
+	for _, key := range keys {
+		b.WriteString(" ")
+		b.WriteString(key)
+		b.WriteString("=")
+		b.WriteString(quoteKeywordValue(params[key]))
+	}
+	return b.String()
+}
+
+func quoteKeywordValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
 }
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"zadanie-6105/internal/config"
+)
+
+func TestBuildDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want string
+	}{
+		{
+			name: "from variables",
+			cfg: config.Config{
+				PostgresHost:     "db",
+				PostgresPort:     5432,
+				PostgresUsername: "app",
+				PostgresPassword: "secret",
+				PostgresDatabase: "tenders",
+			},
+			want: "host=db port=5432 user=app password=secret dbname=tenders sslmode=disable",
+		},
+		{
+			name: "quoted password and options",
+			cfg: config.Config{
+				PostgresHost:             "db",
+				PostgresPort:             6432,
+				PostgresUsername:         "app",
+				PostgresPassword:         `it's a \secret`,
+				PostgresDatabase:         "tenders",
+				PostgresSSLMode:          "verify-full",
+				PostgresSSLRootCert:      "/certs/root ca.pem",
+				PostgresStatementTimeout: 30 * time.Second,
+			},
+			want: `host=db port=6432 user=app password='it\'s a \\secret' dbname=tenders sslmode=verify-full sslrootcert='/certs/root ca.pem' statement_timeout=30000`,
+		},
+		{
+			name: "empty password",
+			cfg: config.Config{
+				PostgresHost:     "db",
+				PostgresPort:     5432,
+				PostgresUsername: "app",
+				PostgresDatabase: "tenders",
+			},
+			want: "host=db port=5432 user=app password='' dbname=tenders sslmode=disable",
+		},
+		{
+			name: "URL gains missing options",
+			cfg: config.Config{
+				PostgresConn:             "postgres://app:secret@db:5432/tenders?sslmode=require",
+				PostgresSSLMode:          "disable",
+				PostgresStatementTimeout: time.Second,
+			},
+			want: "postgres://app:secret@db:5432/tenders?sslmode=require&statement_timeout=1000",
+		},
+		{
+			name: "URL without options",
+			cfg:  config.Config{PostgresConn: "postgresql://app@db/tenders"},
+			want: "postgresql://app@db/tenders",
+		},
+		{
+			name: "keyword DSN keeps its options",
+			cfg: config.Config{
+				PostgresConn:    "host=db dbname=tenders sslmode=require",
+				PostgresSSLMode: "disable",
+				PostgresSSLKey:  "/certs/client.key",
+			},
+			want: "host=db dbname=tenders sslmode=require sslkey=/certs/client.key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildDSN(&tt.cfg); got != tt.want {
+				t.Errorf("BuildDSN() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
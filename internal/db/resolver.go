@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+type forcePrimaryKey struct{}
+
+// Resolver routes read-only queries to replicas and everything else to the
+// primary. Without replicas every call resolves to the primary.
+type Resolver struct {
+	primary  *gorm.DB
+	replicas []*gorm.DB
+	next     atomic.Uint64
+}
+
+func NewResolver(primary *gorm.DB, replicas ...*gorm.DB) *Resolver {
+	return &Resolver{primary: primary, replicas: replicas}
+}
+
+// Primary returns the connection used for writes and read-after-write paths.
+func (r *Resolver) Primary() *gorm.DB {
+	return r.primary
+}
+
+// Reader returns a replica in round-robin order, or the primary when no
+// replicas are configured or the context was marked with WithForcePrimary.
+func (r *Resolver) Reader(ctx context.Context) *gorm.DB {
+	if len(r.replicas) == 0 || IsForcePrimary(ctx) {
+		return r.primary.WithContext(ctx)
+	}
+	n := r.next.Add(1)
+	return r.replicas[n%uint64(len(r.replicas))].WithContext(ctx)
+}
+
+// HasReplicas reports whether any read replica is configured.
+func (r *Resolver) HasReplicas() bool {
+	return len(r.replicas) > 0
+}
+
+// Close closes the primary and all replica connection pools.
+func (r *Resolver) Close() error {
+	var firstErr error
+	for _, db := range append([]*gorm.DB{r.primary}, r.replicas...) {
+		sqlDB, err := db.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WithForcePrimary marks the context so that Reader resolves to the primary,
+// giving the caller read-your-writes consistency.
+func WithForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryKey{}, true)
+}
+
+func IsForcePrimary(ctx context.Context) bool {
+	forced, _ := ctx.Value(forcePrimaryKey{}).(bool)
+	return forced
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// openLazy returns a pool that does not connect until it is used.
+func openLazy(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open %s: %v", dsn, err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestResolverReader(t *testing.T) {
+	primary := openLazy(t, "host=primary")
+	replicaA := openLazy(t, "host=replica-a")
+	replicaB := openLazy(t, "host=replica-b")
+	ctx := context.Background()
+
+	// Sessions share the connection pool they were created from
+	is := func(got, want *gorm.DB) bool { return got.ConnPool == want.ConnPool }
+
+	t.Run("without replicas", func(t *testing.T) {
+		r := NewResolver(primary)
+		if r.HasReplicas() {
+			t.Error("HasReplicas() = true")
+		}
+		if !is(r.Reader(ctx), primary) {
+			t.Error("Reader() did not resolve to the primary")
+		}
+	})
+
+	t.Run("round robin", func(t *testing.T) {
+		r := NewResolver(primary, replicaA, replicaB)
+		if !r.HasReplicas() {
+			t.Error("HasReplicas() = false")
+		}
+		counts := map[gorm.ConnPool]int{}
+		for i := 0; i < 10; i++ {
+			counts[r.Reader(ctx).ConnPool]++
+		}
+		if counts[replicaA.ConnPool] != 5 || counts[replicaB.ConnPool] != 5 || counts[primary.ConnPool] != 0 {
+			t.Errorf("reads per pool = %v, want 5 per replica", counts)
+		}
+		if !is(r.Primary(), primary) {
+			t.Error("Primary() did not return the primary")
+		}
+	})
+
+	t.Run("forced primary", func(t *testing.T) {
+		r := NewResolver(primary, replicaA)
+		forced := WithForcePrimary(ctx)
+		if !IsForcePrimary(forced) || IsForcePrimary(ctx) {
+			t.Fatal("IsForcePrimary() does not reflect WithForcePrimary")
+		}
+		if !is(r.Reader(forced), primary) {
+			t.Error("Reader() of a forced context did not resolve to the primary")
+		}
+	})
+}
+
+// TestResolverWithPostgres checks the routing against two running servers,
+// given as TEST_POSTGRES_PRIMARY and TEST_POSTGRES_REPLICA connection
+// strings. Any two local instances do; they need not replicate.
+func TestResolverWithPostgres(t *testing.T) {
+	primaryDSN, replicaDSN := os.Getenv("TEST_POSTGRES_PRIMARY"), os.Getenv("TEST_POSTGRES_REPLICA")
+	if primaryDSN == "" || replicaDSN == "" {
+		t.Skip("TEST_POSTGRES_PRIMARY and TEST_POSTGRES_REPLICA are not set")
+	}
+
+	r := NewResolver(openLazy(t, primaryDSN), openLazy(t, replicaDSN))
+	ctx := context.Background()
+
+	server := func(db *gorm.DB) string {
+		var id string
+		if err := db.Raw("SELECT inet_server_addr()::text || ':' || inet_server_port()::text || '/' || current_database()").Scan(&id).Error; err != nil {
+			t.Fatalf("query server: %v", err)
+		}
+		return id
+	}
+
+	primary, replica := server(r.Primary()), server(r.Reader(ctx))
+	if primary == replica {
+		t.Fatalf("primary and replica are the same server %s", primary)
+	}
+	if got := server(r.Reader(WithForcePrimary(ctx))); got != primary {
+		t.Errorf("forced read went to %s, want the primary %s", got, primary)
+	}
+}
@@ -0,0 +1,89 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Rows written before the CSV output is flushed
+const csvFlushRows = 100
+
+type csvWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+func NewCSVWriter(w io.Writer, columns []string) (Writer, error) {
+	c := &csvWriter{w: csv.NewWriter(w)}
+	if err := c.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *csvWriter) WriteRow(cells ...interface{}) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		value, err := csvValue(cell)
+		if err != nil {
+			return err
+		}
+		record[i] = value
+	}
+
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	if c.rows++; c.rows%csvFlushRows == 0 {
+		c.w.Flush()
+	}
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func csvValue(cell interface{}) (string, error) {
+	switch v := cell.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return csvText(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case decimal.Decimal:
+		return v.String(), nil
+	case decimal.NullDecimal:
+		if !v.Valid {
+			return "", nil
+		}
+		return v.Decimal.String(), nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339), nil
+	case *time.Time:
+		if v == nil {
+			return "", nil
+		}
+		return v.UTC().Format(time.RFC3339), nil
+	}
+	return "", fmt.Errorf("unsupported cell type %T", cell)
+}
+
+// csvText keeps spreadsheet applications from evaluating text entered by
+// other users, such as bid names, as a formula.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
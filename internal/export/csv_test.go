@@ -0,0 +1,88 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCSVWriter(t *testing.T) {
+	created := time.Date(2026, 10, 1, 15, 30, 0, 0, time.FixedZone("MSK", 3*60*60))
+
+	var buf bytes.Buffer
+	w, err := NewCSVWriter(&buf, []string{"name", "count", "budget", "created", "decided"})
+	if err != nil {
+		t.Fatalf("NewCSVWriter() error = %v", err)
+	}
+	rows := [][]interface{}{
+		{"Поставка, \"срочно\"\nвторая строка", 3, decimal.RequireFromString("1500.50"), created, &created},
+		{"=HYPERLINK(\"http://example.com\")", int64(-7), decimal.NullDecimal{}, created, (*time.Time)(nil)},
+		{"-1", 0, decimal.NewNullDecimal(decimal.NewFromInt(-2)), created, nil},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row...); err != nil {
+			t.Fatalf("WriteRow() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading the output: %v", err)
+	}
+	want := [][]string{
+		{"name", "count", "budget", "created", "decided"},
+		{"Поставка, \"срочно\"\nвторая строка", "3", "1500.5", "2026-10-01T12:30:00Z", "2026-10-01T12:30:00Z"},
+		{"'=HYPERLINK(\"http://example.com\")", "-7", "", "2026-10-01T12:30:00Z", ""},
+		{"'-1", "0", "-2", "2026-10-01T12:30:00Z", ""},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %q, want %q", records, want)
+	}
+}
+
+func TestCSVWriterManyRows(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewCSVWriter(&buf, []string{"n"})
+	if err != nil {
+		t.Fatalf("NewCSVWriter() error = %v", err)
+	}
+	// More rows than are written between flushes
+	const n = 3*csvFlushRows + 1
+	for i := 0; i < n; i++ {
+		if err := w.WriteRow(i); err != nil {
+			t.Fatalf("WriteRow() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading the output: %v", err)
+	}
+	if len(records) != n+1 {
+		t.Fatalf("read %d records, want %d", len(records), n+1)
+	}
+	if last := records[n][0]; last != strconv.Itoa(n-1) {
+		t.Errorf("last record = %s, want %d", last, n-1)
+	}
+}
+
+func TestCSVWriterUnsupportedCell(t *testing.T) {
+	w, err := NewCSVWriter(&bytes.Buffer{}, []string{"value"})
+	if err != nil {
+		t.Fatalf("NewCSVWriter() error = %v", err)
+	}
+	if err := w.WriteRow(1.5); err == nil {
+		t.Error("WriteRow(float64) error = nil, want an error")
+	}
+}
@@ -0,0 +1,58 @@
+// Package export writes tabular reports as CSV or XLSX spreadsheets one row
+// at a time, so that large reports are never held in memory.
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ParseFormat accepts "csv" and "xlsx"; an empty string means CSV.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	}
+	return "", fmt.Errorf("unsupported export format %q", s)
+}
+
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Writer writes the rows of a single table. Cells may be strings, integers,
+// decimal.Decimal, decimal.NullDecimal, time.Time, *time.Time or nil for an
+// empty cell. Close must be called to complete the file.
+type Writer interface {
+	WriteRow(cells ...interface{}) error
+	Close() error
+}
+
+// NewWriter returns a writer of the format that has written the header row
+// with the column names. sheet names the worksheet of an XLSX file and is
+// ignored for CSV.
+func NewWriter(format Format, w io.Writer, sheet string, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVWriter(w, columns)
+	case FormatXLSX:
+		return NewXLSXWriter(w, sheet, columns)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
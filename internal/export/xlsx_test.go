@@ -0,0 +1,187 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type xlsxTestSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string `xml:"r,attr"`
+			S      int    `xml:"s,attr"`
+			T      string `xml:"t,attr"`
+			V      string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// xlsxTestCell is a cell of the worksheet as "ref type style value".
+type xlsxTestCell struct {
+	Ref, Type string
+	Style     int
+	Value     string
+}
+
+// readXLSX opens the workbook, checks that every part is well-formed XML and
+// returns the parts by name.
+func readXLSX(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("opening the archive: %v", err)
+	}
+	parts := make(map[string][]byte)
+	for _, file := range archive.File {
+		f, err := file.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", file.Name, err)
+		}
+		content, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("reading %s: %v", file.Name, err)
+		}
+
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("parsing %s: %v", file.Name, err)
+			}
+		}
+		parts[file.Name] = content
+	}
+	return parts
+}
+
+func TestXLSXWriter(t *testing.T) {
+	created := time.Date(2026, 10, 1, 15, 30, 0, 0, time.FixedZone("MSK", 3*60*60))
+
+	var buf bytes.Buffer
+	w, err := NewXLSXWriter(&buf, "Предложения: тендер [1]", []string{"name", "count", "budget", "created", "decided"})
+	if err != nil {
+		t.Fatalf("NewXLSXWriter() error = %v", err)
+	}
+	rows := [][]interface{}{
+		{"Поставка <труб> & \"фитингов\"\x01", 3, decimal.RequireFromString("1500.50"), created, &created},
+		{"  ", int64(-7), decimal.NullDecimal{}, nil, (*time.Time)(nil)},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row...); err != nil {
+			t.Fatalf("WriteRow() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	parts := readXLSX(t, buf.Bytes())
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("the archive has no %s", name)
+		}
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(parts["xl/workbook.xml"], &workbook); err != nil {
+		t.Fatalf("unmarshaling the workbook: %v", err)
+	}
+	if len(workbook.Sheets) != 1 || workbook.Sheets[0].Name != "Предложения тендер 1" {
+		t.Errorf("sheets = %+v, want one named %q", workbook.Sheets, "Предложения тендер 1")
+	}
+
+	var sheet xlsxTestSheet
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatalf("unmarshaling the worksheet: %v", err)
+	}
+	var cells [][]xlsxTestCell
+	for i, row := range sheet.Rows {
+		if row.R != i+1 {
+			t.Errorf("row %d has number %d", i+1, row.R)
+		}
+		var rowCells []xlsxTestCell
+		for _, c := range row.Cells {
+			value := c.V
+			if c.T == "inlineStr" {
+				value = c.Inline
+			}
+			rowCells = append(rowCells, xlsxTestCell{c.R, c.T, c.S, value})
+		}
+		cells = append(cells, rowCells)
+	}
+
+	date := "46296.520833333336"
+	want := [][]xlsxTestCell{
+		{
+			{"A1", "inlineStr", xlsxStyleHeader, "name"},
+			{"B1", "inlineStr", xlsxStyleHeader, "count"},
+			{"C1", "inlineStr", xlsxStyleHeader, "budget"},
+			{"D1", "inlineStr", xlsxStyleHeader, "created"},
+			{"E1", "inlineStr", xlsxStyleHeader, "decided"},
+		},
+		{
+			{"A2", "inlineStr", 0, "Поставка <труб> & \"фитингов\"\uFFFD"},
+			{"B2", "", 0, "3"},
+			{"C2", "", 0, "1500.5"},
+			{"D2", "", xlsxStyleDate, date},
+			{"E2", "", xlsxStyleDate, date},
+		},
+		{
+			{"A3", "inlineStr", 0, "  "},
+			{"B3", "", 0, "-7"},
+		},
+	}
+	if !reflect.DeepEqual(cells, want) {
+		t.Errorf("cells = %+v, want %+v", cells, want)
+	}
+}
+
+func TestXLSXWriterUnsupportedCell(t *testing.T) {
+	w, err := NewXLSXWriter(&bytes.Buffer{}, "Sheet", []string{"value"})
+	if err != nil {
+		t.Fatalf("NewXLSXWriter() error = %v", err)
+	}
+	if err := w.WriteRow(1.5); err == nil {
+		t.Error("WriteRow(float64) error = nil, want an error")
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(i); got != want {
+			t.Errorf("xlsxColumn(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestXLSXSheetName(t *testing.T) {
+	tests := map[string]string{
+		"Tenders":               "Tenders",
+		"a/b\\c?d*e[f]g:h":      "abcdefgh",
+		"":                      "Sheet1",
+		"[]":                    "Sheet1",
+		strings.Repeat("я", 40): strings.Repeat("я", 31),
+	}
+	for name, want := range tests {
+		if got := xlsxSheetName(name); got != want {
+			t.Errorf("xlsxSheetName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"zadanie-6105/internal/middlewares"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/services"
+	"zadanie-6105/internal/storage"
+	"zadanie-6105/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Allowance for multipart headers on top of the attachment size limit
+const multipartOverhead = 1 << 20
+
+type AttachmentHandler struct {
+	attachmentService *services.AttachmentService
+}
+
+func NewAttachmentHandler(attachmentService *services.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{attachmentService: attachmentService}
+}
+
+func (h *AttachmentHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tenders/{id}/attachments", h.uploadHandler(models.AttachmentOwnerTender, "id")).Methods("POST")
+	router.HandleFunc("/tenders/{id}/attachments", h.listHandler(models.AttachmentOwnerTender, "id")).Methods("GET")
+	router.HandleFunc("/bids/{bidId}/attachments", h.uploadHandler(models.AttachmentOwnerBid, "bidId")).Methods("POST")
+	router.HandleFunc("/bids/{bidId}/attachments", h.listHandler(models.AttachmentOwnerBid, "bidId")).Methods("GET")
+	router.HandleFunc("/attachments/{id}", h.DownloadAttachment).Methods("GET")
+	router.HandleFunc("/attachments/{id}", h.DeleteAttachment).Methods("DELETE")
+}
+
+func (h *AttachmentHandler) uploadHandler(ownerType models.AttachmentOwnerType, idVar string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ownerID := mux.Vars(r)[idVar]
+
+		username, ok := middlewares.GetUsernameFromContext(r.Context())
+		if !ok {
+			utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+			return
+		}
+
+		if !h.checkOwner(w, r, ownerType, ownerID) {
+			return
+		}
+
+		authorized, err := h.attachmentService.IsUserAuthorizedToModifyAttachments(r.Context(), username, ownerType, ownerID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+			return
+		}
+		if !authorized {
+			utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, h.attachmentService.MaxSize()+multipartOverhead)
+
+		reader, err := r.MultipartReader()
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Expected multipart/form-data request")
+			return
+		}
+
+		// Stream the first "file" part instead of buffering the whole form
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				utils.RespondWithError(w, http.StatusBadRequest, "Missing file field")
+				return
+			}
+			if err != nil {
+				h.respondWithUploadError(w, err)
+				return
+			}
+			if part.FormName() != "file" {
+				part.Close()
+				continue
+			}
+
+			attachment, err := h.attachmentService.UploadAttachment(r.Context(), ownerType, ownerID, username, part.FileName(), part)
+			part.Close()
+			if err != nil {
+				h.respondWithUploadError(w, err)
+				return
+			}
+
+			utils.RespondWithJSON(w, http.StatusCreated, attachment)
+			return
+		}
+	}
+}
+
+func (h *AttachmentHandler) respondWithUploadError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, services.ErrAttachmentTooLarge) || errors.As(err, &maxBytesErr):
+		utils.RespondWithError(w, http.StatusRequestEntityTooLarge, "Attachment exceeds the size limit")
+	case errors.Is(err, services.ErrAttachmentTypeNotAllowed):
+		utils.RespondWithError(w, http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, services.ErrInvalidAttachment):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("Error uploading attachment: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to upload attachment")
+	}
+}
+
+func (h *AttachmentHandler) listHandler(ownerType models.AttachmentOwnerType, idVar string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ownerID := mux.Vars(r)[idVar]
+
+		username, ok := middlewares.GetUsernameFromContext(r.Context())
+		if !ok {
+			utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+			return
+		}
+
+		if !h.checkOwner(w, r, ownerType, ownerID) {
+			return
+		}
+
+		authorized, err := h.attachmentService.IsUserAuthorizedToViewAttachments(r.Context(), username, ownerType, ownerID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+			return
+		}
+		if !authorized {
+			utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+			return
+		}
+
+		attachments, err := h.attachmentService.GetAttachments(r.Context(), ownerType, ownerID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve attachments")
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, attachments)
+	}
+}
+
+func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := h.authorizedAttachment(w, r, h.attachmentService.IsUserAuthorizedToViewAttachments)
+	if !ok {
+		return
+	}
+
+	body, err := h.attachmentService.OpenAttachment(r.Context(), attachment)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Attachment contents not found")
+		} else {
+			log.Printf("Error opening attachment %s: %v", attachment.ID, err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve attachment")
+		}
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if sum, err := hex.DecodeString(attachment.SHA256); err == nil {
+		w.Header().Set("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum)+":")
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("Error streaming attachment %s: %v", attachment.ID, err)
+	}
+}
+
+func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := h.authorizedAttachment(w, r, h.attachmentService.IsUserAuthorizedToModifyAttachments)
+	if !ok {
+		return
+	}
+
+	if err := h.attachmentService.DeleteAttachment(r.Context(), attachment); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete attachment")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Attachment deleted successfully"})
+}
+
+// authorizedAttachment loads the attachment from the {id} route variable and
+// applies the access check of its owner, writing the error response itself.
+func (h *AttachmentHandler) authorizedAttachment(
+	w http.ResponseWriter,
+	r *http.Request,
+	check func(ctx context.Context, username string, ownerType models.AttachmentOwnerType, ownerID string) (bool, error),
+) (*models.Attachment, bool) {
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return nil, false
+	}
+
+	attachment, err := h.attachmentService.GetAttachment(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Attachment not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve attachment")
+		}
+		return nil, false
+	}
+
+	authorized, err := check(r.Context(), username, attachment.OwnerType, attachment.OwnerID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+		return nil, false
+	}
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+		return nil, false
+	}
+
+	return attachment, true
+}
+
+func (h *AttachmentHandler) checkOwner(w http.ResponseWriter, r *http.Request, ownerType models.AttachmentOwnerType, ownerID string) bool {
+	exists, err := h.attachmentService.OwnerExists(r.Context(), ownerType, ownerID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking attachment owner")
+		return false
+	}
+	if !exists {
+		utils.RespondWithError(w, http.StatusNotFound, string(ownerType)+" not found")
+		return false
+	}
+	return true
+}
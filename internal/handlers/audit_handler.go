@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/services"
+	"zadanie-6105/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+func (h *AuditHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/audit", h.GetEvents).Methods("GET")
+}
+
+// GetEvents returns the audit log of an organization, newest first. Only
+// employees responsible for the organization may read it.
+func (h *AuditHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing username")
+		return
+	}
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, offset, err := utils.GetPaginationParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	authorized, err := h.auditService.IsUserAuthorizedToViewAudit(username, filter.OrganizationID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		return
+	}
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	events, err := h.auditService.GetEvents(r.Context(), filter, limit, offset)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve audit events")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, events)
+}
+
+// parseAuditFilter reads organizationId (required), entityType, entityId,
+// actor, action and the RFC 3339 bounds from and to from the query string.
+func parseAuditFilter(r *http.Request) (models.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		OrganizationID: query.Get("organizationId"),
+		EntityType:     models.AuditEntityType(query.Get("entityType")),
+		EntityID:       query.Get("entityId"),
+		ActorUsername:  query.Get("actor"),
+		Action:         models.AuditAction(query.Get("action")),
+	}
+
+	if filter.OrganizationID == "" {
+		return filter, errors.New("Missing organizationId")
+	}
+	if err := utils.ValidateVar(filter.OrganizationID, "uuid"); err != nil {
+		return filter, errors.New("Invalid organizationId parameter")
+	}
+	if err := utils.ValidateVar(filter.EntityID, "omitempty,uuid"); err != nil {
+		return filter, errors.New("Invalid entityId parameter")
+	}
+
+	switch filter.EntityType {
+	case "", models.AuditEntityTender, models.AuditEntityBid, models.AuditEntityBidDecision, models.AuditEntityBidReview:
+	default:
+		return filter, errors.New("Invalid entityType parameter")
+	}
+
+	if value := query.Get("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("Invalid from parameter")
+		}
+		filter.From = &from
+	}
+	if value := query.Get("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("Invalid to parameter")
+		}
+		filter.To = &to
+	}
+
+	return filter, nil
+}
@@ -13,6 +13,8 @@ import (
 	"zadanie-6105/pkg/utils"
 
 	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 type BidHandler struct {
@@ -35,9 +37,15 @@ func (h *BidHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/bids/{bidId}/submit_decision", h.SubmitBidDecision).Methods("PUT")
 	router.HandleFunc("/bids/{bidId}/feedback", h.SubmitBidFeedback).Methods("PUT")
 	router.HandleFunc("/bids/{bidId}/rollback/{version}", h.RollbackBidVersion).Methods("PUT")
+	router.HandleFunc("/bids/{bidId}/withdraw", h.WithdrawBid).Methods("PUT")
+	router.HandleFunc("/bids/{bidId}/resubmit", h.ResubmitBid).Methods("PUT")
+	router.HandleFunc("/bids/{bidId}/history", h.GetBidStatusHistory).Methods("GET")
 	router.HandleFunc("/bids/{id}", h.DeleteBid).Methods("DELETE")
 	router.HandleFunc("/bids/{id}/reviews", h.AddBidReview).Methods("POST")
 	router.HandleFunc("/bids/{tenderId}/reviews", h.GetBidReviews).Methods("GET")
+	router.HandleFunc("/bids/reviews/{reviewId}", h.UpdateBidReview).Methods("PATCH")
+	router.HandleFunc("/bids/reviews/{reviewId}", h.DeleteBidReview).Methods("DELETE")
+	router.HandleFunc("/authors/{authorId}/profile", h.GetAuthorProfile).Methods("GET")
 }
 
 func (h *BidHandler) CreateBid(w http.ResponseWriter, r *http.Request) {
@@ -79,6 +87,26 @@ func (h *BidHandler) CreateBid(w http.ResponseWriter, r *http.Request) {
 
 	// Create the bid
 	if err := h.bidService.CreateBid(r.Context(), &bid); err != nil {
+		if errors.Is(err, services.ErrSubmissionDeadlinePassed) {
+			utils.RespondWithError(w, http.StatusConflict, "Tender is no longer accepting bids")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidBidTerms) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrTenderInvitationRequired) {
+			utils.RespondWithError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrInvalidBidStatus) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrActiveBidExists) {
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create bid")
 		return
 	}
@@ -135,7 +163,13 @@ func (h *BidHandler) GetBidsForTender(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bids, err := h.bidService.GetBidsForTender(r.Context(), tenderID, limit, offset)
+	filter, err := parseBidListFilter(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	bids, err := h.bidService.GetBidsForTender(r.Context(), tenderID, filter, limit, offset)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve bids")
 		return
@@ -144,6 +178,47 @@ func (h *BidHandler) GetBidsForTender(w http.ResponseWriter, r *http.Request) {
 	utils.RespondWithJSON(w, http.StatusOK, bids)
 }
 
+// parseBidListFilter reads sortBy (name, price, createdAt), order (asc, desc),
+// minAmount, maxAmount and currency from the query string.
+func parseBidListFilter(r *http.Request) (models.BidListFilter, error) {
+	query := r.URL.Query()
+	filter := models.BidListFilter{
+		SortBy:   models.BidSortField(query.Get("sortBy")),
+		Currency: query.Get("currency"),
+	}
+
+	switch filter.SortBy {
+	case "", models.BidSortByName, models.BidSortByPrice, models.BidSortByCreatedAt:
+	default:
+		return filter, errors.New("Invalid sortBy parameter")
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return filter, errors.New("Invalid order parameter")
+	}
+
+	if value := query.Get("minAmount"); value != "" {
+		amount, err := decimal.NewFromString(value)
+		if err != nil {
+			return filter, errors.New("Invalid minAmount parameter")
+		}
+		filter.MinAmount = decimal.NewNullDecimal(amount)
+	}
+	if value := query.Get("maxAmount"); value != "" {
+		amount, err := decimal.NewFromString(value)
+		if err != nil {
+			return filter, errors.New("Invalid maxAmount parameter")
+		}
+		filter.MaxAmount = decimal.NewNullDecimal(amount)
+	}
+
+	return filter, nil
+}
+
 func (h *BidHandler) GetBid(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
@@ -155,6 +230,7 @@ func (h *BidHandler) GetBid(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	utils.SetETag(w, bid.Version)
 	utils.RespondWithJSON(w, http.StatusOK, bid)
 }
 
@@ -219,6 +295,12 @@ func (h *BidHandler) UpdateBidStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, err := utils.ParseIfMatch(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusPreconditionFailed, "Invalid If-Match header")
+		return
+	}
+
 	authorized, err := h.bidService.IsUserAuthorizedToChangeStatus(r.Context(), username, bidID)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
@@ -230,9 +312,15 @@ func (h *BidHandler) UpdateBidStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.bidService.UpdateBidStatus(r.Context(), bidID, status)
+	err = h.bidService.UpdateBidStatus(r.Context(), bidID, status, username, expectedVersion)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, services.ErrPreconditionFailed) {
+			utils.RespondWithError(w, http.StatusPreconditionFailed, "Bid has been modified, reload it and retry")
+		} else if errors.Is(err, services.ErrInvalidBidStatus) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		} else if errors.Is(err, services.ErrActiveBidExists) || errors.Is(err, services.ErrBidDecisionRecorded) {
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+		} else if err == sql.ErrNoRows || errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.RespondWithError(w, http.StatusNotFound, "Bid not found")
 		} else {
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update bid status")
@@ -261,6 +349,12 @@ func (h *BidHandler) UpdateBid(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, err := utils.ParseIfMatch(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusPreconditionFailed, "Invalid If-Match header")
+		return
+	}
+
 	authorized, err := h.bidService.IsUserAuthorizedToEditBid(r.Context(), username, bidID)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
@@ -272,9 +366,15 @@ func (h *BidHandler) UpdateBid(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bid, err := h.bidService.UpdateBid(r.Context(), bidID, &updatedBid)
+	bid, err := h.bidService.UpdateBid(r.Context(), bidID, &updatedBid, expectedVersion)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, services.ErrPreconditionFailed) {
+			utils.RespondWithError(w, http.StatusPreconditionFailed, "Bid has been modified, reload it and retry")
+		} else if errors.Is(err, services.ErrSubmissionDeadlinePassed) {
+			utils.RespondWithError(w, http.StatusConflict, "Tender is no longer accepting bids")
+		} else if errors.Is(err, services.ErrInvalidBidTerms) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		} else if err == sql.ErrNoRows || errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.RespondWithError(w, http.StatusNotFound, "Bid not found")
 		} else {
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update bid")
@@ -282,6 +382,7 @@ func (h *BidHandler) UpdateBid(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	utils.SetETag(w, bid.Version)
 	utils.RespondWithJSON(w, http.StatusOK, bid)
 }
 
@@ -306,6 +407,12 @@ func (h *BidHandler) SubmitBidDecision(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, err := utils.ParseIfMatch(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusPreconditionFailed, "Invalid If-Match header")
+		return
+	}
+
 	authorized, err := h.bidService.IsUserAuthorizedToSubmitDecision(r.Context(), username, bidID)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
@@ -317,9 +424,11 @@ func (h *BidHandler) SubmitBidDecision(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.bidService.SubmitBidDecision(r.Context(), bidID, decision)
+	err = h.bidService.SubmitBidDecision(r.Context(), bidID, decision, username, expectedVersion)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, services.ErrPreconditionFailed) {
+			utils.RespondWithError(w, http.StatusPreconditionFailed, "Bid has been modified, reload it and retry")
+		} else if err == sql.ErrNoRows || errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.RespondWithError(w, http.StatusNotFound, "Bid not found")
 		} else {
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to submit bid decision")
@@ -422,6 +531,11 @@ func (h *BidHandler) AddBidReview(w http.ResponseWriter, r *http.Request) {
 
 	review.BidID = bidID
 
+	if err := utils.ValidateStruct(&review); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
 	authorized, err := h.bidService.IsUserAuthorizedToAddReview(r.Context(), username, bidID)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
@@ -433,7 +547,7 @@ func (h *BidHandler) AddBidReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.bidService.AddBidReview(r.Context(), &review); err != nil {
+	if err := h.bidService.AddBidReview(r.Context(), username, &review); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to save bid review")
 		return
 	}
@@ -458,6 +572,12 @@ func (h *BidHandler) RollbackBidVersion(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	expectedVersion, err := utils.ParseIfMatch(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusPreconditionFailed, "Invalid If-Match header")
+		return
+	}
+
 	authorized, err := h.bidService.IsUserAuthorizedToRollback(r.Context(), username, bidID)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
@@ -469,9 +589,11 @@ func (h *BidHandler) RollbackBidVersion(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = h.bidService.RollbackBidVersion(r.Context(), bidID, version)
+	err = h.bidService.RollbackBidVersion(r.Context(), bidID, version, expectedVersion)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, services.ErrPreconditionFailed) {
+			utils.RespondWithError(w, http.StatusPreconditionFailed, "Bid has been modified, reload it and retry")
+		} else if errors.Is(err, sql.ErrNoRows) || errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.RespondWithError(w, http.StatusNotFound, "Bid or version not found")
 		} else {
 			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
@@ -505,6 +627,14 @@ func (h *BidHandler) GetBidReviews(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	includeReputation := false
+	if value := r.URL.Query().Get("includeReputation"); value != "" {
+		if includeReputation, err = strconv.ParseBool(value); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid includeReputation parameter")
+			return
+		}
+	}
+
 	username, ok := middlewares.GetUsernameFromContext(r.Context())
 	if !ok {
 		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
@@ -532,5 +662,272 @@ func (h *BidHandler) GetBidReviews(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, reviews)
+	if !includeReputation {
+		utils.RespondWithJSON(w, http.StatusOK, reviews)
+		return
+	}
+
+	reputation, err := h.bidService.GetAuthorReputation(r.Context(), tenderID, authorUsername)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Author not found")
+		} else if errors.Is(err, services.ErrAuthorHasNoBids) {
+			utils.RespondWithError(w, http.StatusNotFound, "Author has no bids on this tender")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Error retrieving author reputation")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, &models.BidReviewsWithReputation{
+		Reviews:    reviews,
+		Reputation: reputation,
+	})
+}
+
+func (h *BidHandler) UpdateBidReview(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	reviewID := vars["reviewId"]
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var updates models.BidReview
+	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := utils.ValidateVar(updates.Rating, "omitempty,min=1,max=5"); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid rating")
+		return
+	}
+	if err := utils.ValidateVar(string(updates.Category), "omitempty,oneof=Quality Price Delivery Communication Other"); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid category")
+		return
+	}
+
+	authorized, err := h.bidService.IsUserAuthorizedToModifyReview(r.Context(), username, reviewID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Review not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+		}
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+		return
+	}
+
+	review, err := h.bidService.UpdateBidReview(r.Context(), reviewID, &updates)
+	if err != nil {
+		if errors.Is(err, services.ErrReviewEditWindowExpired) {
+			utils.RespondWithError(w, http.StatusForbidden, "Review can no longer be edited")
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Review not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update bid review")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, review)
+}
+
+func (h *BidHandler) DeleteBidReview(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	reviewID := vars["reviewId"]
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	authorized, err := h.bidService.IsUserAuthorizedToModifyReview(r.Context(), username, reviewID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Review not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+		}
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+		return
+	}
+
+	if err := h.bidService.DeleteBidReview(r.Context(), reviewID); err != nil {
+		if errors.Is(err, services.ErrReviewEditWindowExpired) {
+			utils.RespondWithError(w, http.StatusForbidden, "Review can no longer be deleted")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete bid review")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *BidHandler) GetAuthorProfile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	authorID := vars["authorId"]
+
+	if err := utils.ValidateVar(authorID, "uuid"); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid author ID")
+		return
+	}
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	authorized, err := h.bidService.IsUserAuthorizedToViewAuthorProfile(r.Context(), username, authorID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+		return
+	}
+
+	profile, err := h.bidService.GetAuthorProfile(r.Context(), authorID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Author not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Error retrieving author profile")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, profile)
+}
+
+func (h *BidHandler) WithdrawBid(w http.ResponseWriter, r *http.Request) {
+	bidID := mux.Vars(r)["bidId"]
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var request struct {
+		Reason string `json:"reason" validate:"max=1000"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := utils.ValidateStruct(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	h.changeBidStatus(w, r, username, func(expectedVersion int) (*models.Bid, error) {
+		return h.bidService.WithdrawBid(r.Context(), bidID, username, request.Reason, expectedVersion)
+	})
+}
+
+func (h *BidHandler) ResubmitBid(w http.ResponseWriter, r *http.Request) {
+	bidID := mux.Vars(r)["bidId"]
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	h.changeBidStatus(w, r, username, func(expectedVersion int) (*models.Bid, error) {
+		return h.bidService.ResubmitBid(r.Context(), bidID, username, expectedVersion)
+	})
+}
+
+// changeBidStatus checks that the user authored the bid and maps the
+// lifecycle errors of withdraw and resubmit to responses.
+func (h *BidHandler) changeBidStatus(w http.ResponseWriter, r *http.Request, username string, change func(expectedVersion int) (*models.Bid, error)) {
+	bidID := mux.Vars(r)["bidId"]
+
+	expectedVersion, err := utils.ParseIfMatch(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusPreconditionFailed, "Invalid If-Match header")
+		return
+	}
+
+	authorized, err := h.bidService.IsUserAuthorizedToEditBid(r.Context(), username, bidID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+		return
+	}
+
+	bid, err := change(expectedVersion)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPreconditionFailed):
+			utils.RespondWithError(w, http.StatusPreconditionFailed, "Bid has been modified, reload it and retry")
+		case errors.Is(err, services.ErrSubmissionDeadlinePassed):
+			utils.RespondWithError(w, http.StatusConflict, "Tender is no longer accepting bids")
+		case errors.Is(err, services.ErrInvalidBidTransition),
+			errors.Is(err, services.ErrActiveBidExists),
+			errors.Is(err, services.ErrBidDecisionRecorded):
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, services.ErrTenderInvitationRequired):
+			utils.RespondWithError(w, http.StatusForbidden, err.Error())
+		case err == sql.ErrNoRows || errors.Is(err, gorm.ErrRecordNotFound):
+			utils.RespondWithError(w, http.StatusNotFound, "Bid not found")
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update bid status")
+		}
+		return
+	}
+
+	utils.SetETag(w, bid.Version)
+	utils.RespondWithJSON(w, http.StatusOK, bid)
+}
+
+func (h *BidHandler) GetBidStatusHistory(w http.ResponseWriter, r *http.Request) {
+	bidID := mux.Vars(r)["bidId"]
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	authorized, err := h.bidService.IsUserAuthorizedToViewBidHistory(r.Context(), username, bidID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+		return
+	}
+
+	history, err := h.bidService.GetBidStatusHistory(r.Context(), bidID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve bid history")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, history)
 }
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"zadanie-6105/internal/middlewares"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/services"
+	"zadanie-6105/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+type EvaluationHandler struct {
+	evaluationService *services.EvaluationService
+}
+
+func NewEvaluationHandler(evaluationService *services.EvaluationService) *EvaluationHandler {
+	return &EvaluationHandler{evaluationService: evaluationService}
+}
+
+func (h *EvaluationHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tenders/{id}/criteria", h.SetCriteria).Methods("PUT")
+	router.HandleFunc("/tenders/{id}/criteria", h.GetCriteria).Methods("GET")
+	router.HandleFunc("/tenders/{id}/comparison", h.GetComparison).Methods("GET")
+	router.HandleFunc("/bids/{bidId}/scores", h.ScoreBid).Methods("PUT")
+}
+
+func (h *EvaluationHandler) SetCriteria(w http.ResponseWriter, r *http.Request) {
+	tenderID := mux.Vars(r)["id"]
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var request struct {
+		Criteria []*models.EvaluationCriterion `json:"criteria"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	for _, criterion := range request.Criteria {
+		if criterion == nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+			return
+		}
+		if err := utils.ValidateStruct(criterion); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+			return
+		}
+	}
+
+	authorized, err := h.evaluationService.IsUserAuthorizedToManageCriteria(username, tenderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	criteria, err := h.evaluationService.SetCriteria(r.Context(), tenderID, request.Criteria)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to save evaluation criteria")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, criteria)
+}
+
+func (h *EvaluationHandler) GetCriteria(w http.ResponseWriter, r *http.Request) {
+	tenderID := mux.Vars(r)["id"]
+	if err := utils.ValidateVar(tenderID, "required,uuid"); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid tenderId parameter")
+		return
+	}
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	authorized, err := h.evaluationService.IsUserAuthorizedToViewCriteria(r.Context(), username, tenderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Tender not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		}
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	criteria, err := h.evaluationService.GetCriteria(r.Context(), tenderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to fetch evaluation criteria")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, criteria)
+}
+
+func (h *EvaluationHandler) ScoreBid(w http.ResponseWriter, r *http.Request) {
+	bidID := mux.Vars(r)["bidId"]
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var request struct {
+		Scores []*models.BidScore `json:"scores"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	for _, score := range request.Scores {
+		if score == nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+			return
+		}
+		if err := utils.ValidateStruct(score); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+			return
+		}
+	}
+
+	authorized, err := h.evaluationService.IsUserAuthorizedToScoreBid(r.Context(), username, bidID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+		return
+	}
+
+	scores, err := h.evaluationService.ScoreBid(r.Context(), bidID, username, request.Scores)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidScore) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		} else if err == sql.ErrNoRows || errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Bid not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to save bid scores")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, scores)
+}
+
+func (h *EvaluationHandler) GetComparison(w http.ResponseWriter, r *http.Request) {
+	tenderID := mux.Vars(r)["id"]
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	authorized, err := h.evaluationService.IsUserAuthorizedToManageCriteria(username, tenderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	comparison, err := h.evaluationService.GetComparison(r.Context(), tenderID)
+	if err != nil {
+		if err == sql.ErrNoRows || errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Tender not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to build bid comparison")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, comparison)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"time"
+	"zadanie-6105/internal/export"
+	"zadanie-6105/internal/middlewares"
+	"zadanie-6105/internal/services"
+	"zadanie-6105/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// How long writing an export may take, instead of the server's write timeout
+const exportWriteTimeout = 10 * time.Minute
+
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// RegisterRoutes must be called before the routes of TenderHandler, whose
+// /tenders/{id} would match /tenders/export.
+func (h *ExportHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tenders/export", h.ExportTenders).Methods("GET")
+	router.HandleFunc("/bids/{tenderId}/export", h.ExportBids).Methods("GET")
+}
+
+func (h *ExportHandler) ExportTenders(w http.ResponseWriter, r *http.Request) {
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	organizationID := r.URL.Query().Get("organizationId")
+	if err := utils.ValidateVar(organizationID, "required,uuid"); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid organizationId parameter")
+		return
+	}
+
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid format parameter")
+		return
+	}
+
+	authorized, err := h.exportService.IsUserAuthorizedToExportTenders(username, organizationID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+		return
+	}
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+		return
+	}
+
+	startExport(w, format, "tenders-"+organizationID)
+	if err := h.exportService.ExportTenders(r.Context(), organizationID, format, w); err != nil {
+		log.Printf("Error exporting tenders of organization %s: %v", organizationID, err)
+	}
+}
+
+func (h *ExportHandler) ExportBids(w http.ResponseWriter, r *http.Request) {
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	tenderID := mux.Vars(r)["tenderId"]
+	if err := utils.ValidateVar(tenderID, "required,uuid"); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid tenderId parameter")
+		return
+	}
+
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid format parameter")
+		return
+	}
+
+	authorized, err := h.exportService.IsUserAuthorizedToExportBids(r.Context(), username, tenderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+		return
+	}
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+		return
+	}
+
+	startExport(w, format, "bids-"+tenderID)
+	if err := h.exportService.ExportBids(r.Context(), tenderID, format, w); err != nil {
+		log.Printf("Error exporting bids of tender %s: %v", tenderID, err)
+	}
+}
+
+// startExport sends the headers of a file download. Rows are written as they
+// are read, so an error past this point can only cut the file short.
+func startExport(w http.ResponseWriter, format export.Format, name string) {
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + format.Extension()}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+}
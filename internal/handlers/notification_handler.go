@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"zadanie-6105/internal/middlewares"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/services"
+	"zadanie-6105/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+func (h *NotificationHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/notifications", h.GetNotifications).Methods("GET")
+	router.HandleFunc("/notifications/read-all", h.MarkAllRead).Methods("PUT")
+	router.HandleFunc("/notifications/preferences", h.GetPreferences).Methods("GET")
+	router.HandleFunc("/notifications/preferences", h.UpdatePreferences).Methods("PUT")
+	router.HandleFunc("/notifications/{notificationId}/read", h.MarkRead).Methods("PUT")
+}
+
+func (h *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	limit, offset, err := utils.GetPaginationParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	unreadOnly := false
+	if value := r.URL.Query().Get("unreadOnly"); value != "" {
+		if unreadOnly, err = strconv.ParseBool(value); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid unreadOnly parameter")
+			return
+		}
+	}
+
+	inbox, err := h.notificationService.GetInbox(r.Context(), username, unreadOnly, limit, offset)
+	if err != nil {
+		respondWithNotificationError(w, err, "Failed to retrieve notifications")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, inbox)
+}
+
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	notificationId := mux.Vars(r)["notificationId"]
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	if err := h.notificationService.MarkRead(r.Context(), username, notificationId); err != nil {
+		respondWithNotificationError(w, err, "Failed to mark notification as read")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	marked, err := h.notificationService.MarkAllRead(r.Context(), username)
+	if err != nil {
+		respondWithNotificationError(w, err, "Failed to mark notifications as read")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]int64{"marked": marked})
+}
+
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	preferences, err := h.notificationService.GetPreferences(r.Context(), username)
+	if err != nil {
+		respondWithNotificationError(w, err, "Failed to retrieve notification preferences")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, preferences)
+}
+
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var request struct {
+		Preferences []*models.NotificationPreference `json:"preferences" validate:"required,dive"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := utils.ValidateStruct(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(r.Context(), username, request.Preferences)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidEventType) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		} else {
+			respondWithNotificationError(w, err, "Failed to update notification preferences")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, preferences)
+}
+
+// respondWithNotificationError maps a missing employee or notification to
+// 404 and anything else to 500 with message.
+func respondWithNotificationError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.RespondWithError(w, http.StatusNotFound, "Notification or user not found")
+		return
+	}
+	utils.RespondWithError(w, http.StatusInternalServerError, message)
+}
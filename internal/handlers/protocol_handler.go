@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+	"zadanie-6105/internal/middlewares"
+	"zadanie-6105/internal/services"
+	"zadanie-6105/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+type ProtocolHandler struct {
+	protocolService *services.ProtocolService
+}
+
+func NewProtocolHandler(protocolService *services.ProtocolService) *ProtocolHandler {
+	return &ProtocolHandler{protocolService: protocolService}
+}
+
+func (h *ProtocolHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tenders/{id}/protocol.pdf", h.GetAwardProtocol).Methods("GET")
+}
+
+func (h *ProtocolHandler) GetAwardProtocol(w http.ResponseWriter, r *http.Request) {
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	tenderID := mux.Vars(r)["id"]
+	if err := utils.ValidateVar(tenderID, "required,uuid"); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid tender ID")
+		return
+	}
+
+	authorized, err := h.protocolService.IsUserAuthorizedToViewProtocol(r.Context(), username, tenderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+		return
+	}
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+		return
+	}
+
+	protocol, err := h.protocolService.GetAwardProtocol(r.Context(), tenderID)
+	if err != nil {
+		if errors.Is(err, services.ErrTenderNotClosed) {
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+		} else if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithError(w, http.StatusNotFound, "Tender not found")
+		} else {
+			log.Printf("Error getting award protocol of tender %s: %v", tenderID, err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get award protocol")
+		}
+		return
+	}
+
+	// Rendered in full first, so that a failure can still be reported
+	var document bytes.Buffer
+	if err := h.protocolService.WriteAwardProtocol(protocol, time.Now(), &document); err != nil {
+		log.Printf("Error rendering award protocol of tender %s: %v", tenderID, err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to render award protocol")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "protocol-" + tenderID + ".pdf"}))
+	w.Header().Set("Content-Length", strconv.Itoa(document.Len()))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(document.Bytes())
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"zadanie-6105/internal/middlewares"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/services"
+	"zadanie-6105/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
+)
+
+// Largest message accepted from a participant, in bytes
+const reviewRoomMaxMessageSize = 4096
+
+// How long sending one message to a participant may take
+const reviewRoomWriteTimeout = 10 * time.Second
+
+type ReviewRoomHandler struct {
+	reviewRooms    *services.ReviewRooms
+	heartbeat      time.Duration
+	allowedOrigins map[string]bool
+}
+
+func NewReviewRoomHandler(reviewRooms *services.ReviewRooms, heartbeat time.Duration, allowedOrigins []string) *ReviewRoomHandler {
+	h := &ReviewRoomHandler{reviewRooms: reviewRooms, heartbeat: heartbeat, allowedOrigins: make(map[string]bool)}
+	for _, origin := range allowedOrigins {
+		h.allowedOrigins[strings.ToLower(origin)] = true
+	}
+	return h
+}
+
+func (h *ReviewRoomHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/bids/{tenderId}/review_room", h.JoinReviewRoom).Methods("GET")
+}
+
+// JoinReviewRoom upgrades the request to a WebSocket connection to the
+// tender's review room. The user is authenticated and authorized before the
+// upgrade, so failures are ordinary HTTP errors. Authorization is checked
+// again on every heartbeat, and the connection is closed once the user may
+// no longer see the tender's bid reviews.
+func (h *ReviewRoomHandler) JoinReviewRoom(w http.ResponseWriter, r *http.Request) {
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	tenderID := mux.Vars(r)["tenderId"]
+	if err := utils.ValidateVar(tenderID, "required,uuid"); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid tenderId parameter")
+		return
+	}
+
+	authorized, err := h.reviewRooms.IsUserAuthorizedToJoin(r.Context(), username, tenderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+		return
+	}
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+		return
+	}
+
+	websocket.Server{
+		Handshake: h.checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			h.serve(r.Context(), ws, username, tenderID)
+		},
+	}.ServeHTTP(w, r)
+}
+
+// checkOrigin accepts connections from the origin of the API itself and from
+// the configured origins. Browsers do not apply the same-origin policy to
+// WebSockets, so without the check any site could connect on behalf of its
+// visitors. A failed check is answered with 403 before the upgrade.
+func (h *ReviewRoomHandler) checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+	if origin == nil {
+		return errors.New("missing origin")
+	}
+	if strings.EqualFold(origin.Host, r.Host) || h.allowedOrigins[strings.ToLower(origin.Scheme+"://"+origin.Host)] {
+		config.Origin = origin
+		return nil
+	}
+	return fmt.Errorf("origin %s is not allowed", origin)
+}
+
+func (h *ReviewRoomHandler) serve(ctx context.Context, ws *websocket.Conn, username, tenderID string) {
+	defer ws.Close()
+	ws.MaxPayloadBytes = reviewRoomMaxMessageSize
+	// The hijacked connection keeps the server's read and write timeouts
+	ws.SetDeadline(time.Time{})
+
+	session, err := h.reviewRooms.Join(ctx, username, tenderID)
+	if err != nil {
+		log.Printf("Failed to join review room of tender %s: %v", tenderID, err)
+		return
+	}
+	defer h.reviewRooms.Leave(context.WithoutCancel(ctx), session)
+
+	done := make(chan struct{})
+	defer close(done)
+	incoming := make(chan *models.ReviewRoomMessage)
+	go receiveReviewRoomMessages(ws, incoming, done)
+
+	if !sendReviewRoomMessage(ws, &models.ReviewRoomMessage{
+		Type:        models.ReviewRoomJoined,
+		Participant: session.Participant(),
+	}) {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var message *models.ReviewRoomMessage
+
+		select {
+		case request, ok := <-incoming:
+			if !ok {
+				return
+			}
+			if message = h.handleMessage(ctx, session, request); message == nil {
+				continue
+			}
+
+		case update, ok := <-session.Messages():
+			if !ok {
+				return
+			}
+			message = update
+
+		case event, ok := <-session.Events():
+			if !ok {
+				return
+			}
+			message = &models.ReviewRoomMessage{Type: models.ReviewRoomEvent, Event: event}
+
+		case <-heartbeat.C:
+			authorized, err := h.reviewRooms.IsUserAuthorizedToJoin(ctx, username, tenderID)
+			if err != nil {
+				log.Printf("Failed to recheck review room authorization of %s for tender %s: %v", username, tenderID, err)
+			} else if !authorized {
+				return
+			}
+
+			// Keeps proxies from closing an idle connection; the client's
+			// pong is consumed by the next receive
+			ws.SetWriteDeadline(time.Now().Add(reviewRoomWriteTimeout))
+			ws.PayloadType = websocket.PingFrame
+			_, err = ws.Write(nil)
+			ws.PayloadType = websocket.TextFrame
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		if !sendReviewRoomMessage(ws, message) {
+			return
+		}
+	}
+}
+
+// handleMessage applies a participant's message and returns the reply, if
+// any.
+func (h *ReviewRoomHandler) handleMessage(ctx context.Context, session *services.ReviewSession, message *models.ReviewRoomMessage) *models.ReviewRoomMessage {
+	var err error
+
+	switch message.Type {
+	case models.ReviewRoomEditing:
+		if err := utils.ValidateVar(message.BidID, "required,uuid"); err != nil {
+			return reviewRoomError("Invalid bidId")
+		}
+		err = session.StartEditing(ctx, message.BidID)
+
+	case models.ReviewRoomEditingStopped:
+		err = session.StopEditing(ctx)
+
+	default:
+		return reviewRoomError("Invalid message")
+	}
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return reviewRoomError("Bid not found in this tender")
+	default:
+		log.Printf("Failed to update review room participant %s: %v", session.Participant().ID, err)
+		return reviewRoomError("Error updating editing state")
+	}
+}
+
+func reviewRoomError(message string) *models.ReviewRoomMessage {
+	return &models.ReviewRoomMessage{Type: models.ReviewRoomError, Message: message}
+}
+
+// receiveReviewRoomMessages reads the participant's messages until the
+// connection fails or done is closed. Messages that cannot be decoded are
+// passed on without a type, to be answered with an error.
+func receiveReviewRoomMessages(ws *websocket.Conn, incoming chan<- *models.ReviewRoomMessage, done <-chan struct{}) {
+	defer close(incoming)
+
+	for {
+		var message models.ReviewRoomMessage
+		err := websocket.JSON.Receive(ws, &message)
+
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, websocket.ErrFrameTooLarge) {
+			message = models.ReviewRoomMessage{}
+		} else if err != nil {
+			return
+		}
+
+		select {
+		case incoming <- &message:
+		case <-done:
+			return
+		}
+	}
+}
+
+func sendReviewRoomMessage(ws *websocket.Conn, message *models.ReviewRoomMessage) bool {
+	ws.SetWriteDeadline(time.Now().Add(reviewRoomWriteTimeout))
+	return websocket.JSON.Send(ws, message) == nil
+}
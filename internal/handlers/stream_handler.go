@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"zadanie-6105/internal/middlewares"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/services"
+	"zadanie-6105/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// Delay the browser waits before reconnecting, in milliseconds
+const streamRetryDelay = 3000
+
+// Stored events sent per query when a client resumes
+const streamReplayBatch = 100
+
+type StreamHandler struct {
+	eventStream *services.EventStream
+	heartbeat   time.Duration
+}
+
+func NewStreamHandler(eventStream *services.EventStream, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{eventStream: eventStream, heartbeat: heartbeat}
+}
+
+func (h *StreamHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/stream", h.Stream).Methods("GET")
+}
+
+// Stream serves the tender's events as Server-Sent Events. A client passing
+// Last-Event-ID (or lastEventId in the query) first receives the stored
+// events it missed. Authorization is checked again on every heartbeat, and
+// the stream is closed once the user may no longer see the tender's bids.
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	tenderID := r.URL.Query().Get("tenderId")
+	if err := utils.ValidateVar(tenderID, "required,uuid"); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid tenderId parameter")
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	var resumeAfter int64 = -1
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid Last-Event-ID")
+			return
+		}
+		resumeAfter = id
+	}
+
+	authorized, err := h.eventStream.IsUserAuthorizedToSubscribe(r.Context(), username, tenderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking user authorization")
+		return
+	}
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions to perform this action")
+		return
+	}
+
+	// Subscribe before replaying, so that nothing committed in between is
+	// lost; live events up to the last replayed one are skipped below
+	subscription := h.eventStream.Subscribe(tenderID)
+	defer h.eventStream.Unsubscribe(subscription)
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	// The server's write timeout would otherwise cut the stream
+	controller.SetWriteDeadline(time.Time{})
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetryDelay); err != nil {
+		return
+	}
+
+	for resumeAfter >= 0 {
+		events, err := h.eventStream.GetEventsAfter(r.Context(), tenderID, resumeAfter, streamReplayBatch)
+		if err != nil {
+			log.Printf("Failed to replay stream events of tender %s: %v", tenderID, err)
+			return
+		}
+		for _, event := range events {
+			if err := writeStreamEvent(w, event); err != nil {
+				return
+			}
+			resumeAfter = event.ID
+		}
+		if len(events) < streamReplayBatch {
+			break
+		}
+	}
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			if event.ID <= resumeAfter {
+				continue
+			}
+			if err := writeStreamEvent(w, event); err != nil {
+				return
+			}
+
+		case <-heartbeat.C:
+			authorized, err := h.eventStream.IsUserAuthorizedToSubscribe(r.Context(), username, tenderID)
+			if err != nil {
+				log.Printf("Failed to recheck stream authorization of %s for tender %s: %v", username, tenderID, err)
+			} else if !authorized {
+				return
+			}
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeStreamEvent(w io.Writer, event *models.StreamEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
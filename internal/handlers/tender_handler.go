@@ -3,9 +3,13 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
+	"zadanie-6105/internal/middlewares"
 	"zadanie-6105/internal/models"
 	"zadanie-6105/internal/services"
 	"zadanie-6105/pkg/utils"
@@ -15,6 +19,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// Largest import file, in bytes
+const tenderImportMaxSize = 10 << 20
+
 type TenderHandler struct {
 	tenderService *services.TenderService
 }
@@ -26,13 +33,17 @@ func NewTenderHandler(tenderService *services.TenderService) *TenderHandler {
 func (h *TenderHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/tenders", h.GetTenders).Methods("GET")
 	router.HandleFunc("/tenders/my", h.GetUserTenders).Methods("GET")
+	router.HandleFunc("/tenders/scheduled", h.GetScheduledTenders).Methods("GET")
 	router.HandleFunc("/tenders/{id}", h.GetTender).Methods("GET")
 	router.HandleFunc("/tenders/{id}/status", h.GetTenderStatus).Methods("GET")
 	router.HandleFunc("/tenders/{id}/status", h.UpdateTenderStatus).Methods("PUT")
 	router.HandleFunc("/tenders/{id}/rollback/{version}", h.RollbackTenderVersion).Methods("PUT")
 	router.HandleFunc("/tenders/new", h.CreateTender).Methods("POST")
+	router.HandleFunc("/tenders/import", h.ImportTenders).Methods("POST")
 	router.HandleFunc("/tenders/{id}/edit", h.EditTender).Methods("PATCH")
 	router.HandleFunc("/tenders/{id}", h.DeleteTender).Methods("DELETE")
+	router.HandleFunc("/tenders/{id}/schedule", h.ScheduleTenderPublication).Methods("PUT")
+	router.HandleFunc("/tenders/{id}/schedule", h.UnscheduleTenderPublication).Methods("DELETE")
 }
 
 func (h *TenderHandler) CreateTender(w http.ResponseWriter, r *http.Request) {
@@ -67,6 +78,10 @@ func (h *TenderHandler) CreateTender(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.tenderService.CreateTender(r.Context(), &tender); err != nil {
+		if errors.Is(err, services.ErrInvalidTenderDeadlines) || errors.Is(err, services.ErrInvalidTenderBudget) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -74,10 +89,125 @@ func (h *TenderHandler) CreateTender(w http.ResponseWriter, r *http.Request) {
 	utils.RespondWithJSON(w, http.StatusCreated, tender)
 }
 
+// ImportTenders creates tenders from the "file" field of a multipart upload.
+// The user is taken from the query, like for other uploads.
+func (h *TenderHandler) ImportTenders(w http.ResponseWriter, r *http.Request) {
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Пользователь не аутентифицирован")
+		return
+	}
+
+	query := r.URL.Query()
+	mode := models.TenderImportAllOrNothing
+	if value := query.Get("mode"); value != "" {
+		mode = models.TenderImportMode(value)
+	}
+	if !mode.IsValid() {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid mode parameter")
+		return
+	}
+
+	dryRun := false
+	if value := query.Get("dryRun"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid dryRun parameter")
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, tenderImportMaxSize)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Expected multipart/form-data request")
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			utils.RespondWithError(w, http.StatusBadRequest, "Missing file field")
+			return
+		}
+		if err != nil {
+			respondWithImportError(w, err)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		format, ok := tenderImportFormat(query.Get("format"), part.FileName())
+		if !ok {
+			part.Close()
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid format parameter, expected csv or jsonl")
+			return
+		}
+
+		result, err := h.tenderService.ImportTenders(r.Context(), username, format, part, mode, dryRun)
+		part.Close()
+		if err != nil {
+			respondWithImportError(w, err)
+			return
+		}
+
+		status := http.StatusOK
+		if result.Created > 0 {
+			status = http.StatusCreated
+		} else if !dryRun && result.Failed > 0 {
+			status = http.StatusUnprocessableEntity
+		}
+		utils.RespondWithJSON(w, status, result)
+		return
+	}
+}
+
+// tenderImportFormat takes the format from the parameter or, without one,
+// from the extension of the uploaded file.
+func tenderImportFormat(param, fileName string) (models.TenderImportFormat, bool) {
+	if param == "" {
+		return models.TenderImportFormatOf(fileName)
+	}
+	format := models.TenderImportFormat(param)
+	return format, format.IsValid()
+}
+
+func respondWithImportError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		utils.RespondWithError(w, http.StatusRequestEntityTooLarge, "Import file exceeds the size limit")
+	case errors.Is(err, services.ErrTenderImportTooLarge):
+		utils.RespondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, services.ErrInvalidTenderImport):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("Error importing tenders: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to import tenders")
+	}
+}
+
 func (h *TenderHandler) GetTender(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
+	username, _ := middlewares.GetUsernameFromContext(r.Context())
+	organizationID, _ := middlewares.GetOrganizationIDFromContext(r.Context())
+
+	// Invite-only tenders are reported as missing to users who were not invited
+	visible, err := h.tenderService.IsTenderVisible(r.Context(), id, username, organizationID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		return
+	}
+	if !visible {
+		utils.RespondWithError(w, http.StatusNotFound, "Tender not found")
+		return
+	}
+
 	tender, err := h.tenderService.GetTenderByID(r.Context(), id)
 	if err != nil {
 		log.Printf("Error getting tender: %v", err)
@@ -85,6 +215,7 @@ func (h *TenderHandler) GetTender(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	utils.SetETag(w, tender.Version)
 	utils.RespondWithJSON(w, http.StatusOK, tender)
 }
 
@@ -137,7 +268,10 @@ func (h *TenderHandler) GetTenders(w http.ResponseWriter, r *http.Request) {
 		serviceTypes = append(serviceTypes, models.TenderServiceType(s))
 	}
 
-	tenders, err := h.tenderService.GetTenders(r.Context(), serviceTypes, limit, offset)
+	username, _ := middlewares.GetUsernameFromContext(r.Context())
+	organizationID, _ := middlewares.GetOrganizationIDFromContext(r.Context())
+
+	tenders, err := h.tenderService.GetTenders(r.Context(), serviceTypes, username, organizationID, limit, offset)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve tenders")
 		return
@@ -167,6 +301,12 @@ func (h *TenderHandler) UpdateTenderStatus(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	expectedVersion, err := utils.ParseIfMatch(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusPreconditionFailed, "Invalid If-Match header")
+		return
+	}
+
 	authorized, err := h.tenderService.IsUserAuthorizedToUpdateStatus(username, tenderId)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
@@ -178,8 +318,10 @@ func (h *TenderHandler) UpdateTenderStatus(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := h.tenderService.UpdateTenderStatus(r.Context(), tenderId, models.TenderStatus(status)); err != nil {
-		if err == sql.ErrNoRows {
+	if err := h.tenderService.UpdateTenderStatus(r.Context(), tenderId, models.TenderStatus(status), expectedVersion); err != nil {
+		if errors.Is(err, services.ErrPreconditionFailed) {
+			utils.RespondWithError(w, http.StatusPreconditionFailed, "Tender has been modified, reload it and retry")
+		} else if err == sql.ErrNoRows || errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.RespondWithError(w, http.StatusNotFound, "Tender not found")
 		} else {
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update tender status")
@@ -193,6 +335,7 @@ func (h *TenderHandler) UpdateTenderStatus(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	utils.SetETag(w, tender.Version)
 	utils.RespondWithJSON(w, http.StatusOK, tender)
 }
 
@@ -212,6 +355,12 @@ func (h *TenderHandler) EditTender(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, err := utils.ParseIfMatch(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusPreconditionFailed, "Invalid If-Match header")
+		return
+	}
+
 	authorized, err := h.tenderService.IsUserAuthorizedToEditTender(username, tenderId)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
@@ -228,10 +377,18 @@ func (h *TenderHandler) EditTender(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
+	if err := utils.ValidateStructPartial(&updates, "Visibility"); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+		return
+	}
 
-	updatedTender, err := h.tenderService.UpdateTender(r.Context(), tenderId, &updates)
+	updatedTender, err := h.tenderService.UpdateTender(r.Context(), tenderId, &updates, expectedVersion)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, services.ErrPreconditionFailed) {
+			utils.RespondWithError(w, http.StatusPreconditionFailed, "Tender has been modified, reload it and retry")
+		} else if errors.Is(err, services.ErrInvalidTenderDeadlines) || errors.Is(err, services.ErrInvalidTenderBudget) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		} else if err == sql.ErrNoRows || errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.RespondWithError(w, http.StatusNotFound, "Tender not found")
 		} else {
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update tender")
@@ -239,6 +396,7 @@ func (h *TenderHandler) EditTender(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	utils.SetETag(w, updatedTender.Version)
 	utils.RespondWithJSON(w, http.StatusOK, updatedTender)
 }
 
@@ -259,6 +417,12 @@ func (h *TenderHandler) RollbackTenderVersion(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	expectedVersion, err := utils.ParseIfMatch(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusPreconditionFailed, "Invalid If-Match header")
+		return
+	}
+
 	authorized, err := h.tenderService.IsUserAuthorizedToEditTender(username, tenderId)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
@@ -270,9 +434,11 @@ func (h *TenderHandler) RollbackTenderVersion(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	updatedTender, err := h.tenderService.RollbackTenderVersion(r.Context(), tenderId, version)
+	updatedTender, err := h.tenderService.RollbackTenderVersion(r.Context(), tenderId, version, expectedVersion)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
+		if errors.Is(err, services.ErrPreconditionFailed) {
+			utils.RespondWithError(w, http.StatusPreconditionFailed, "Tender has been modified, reload it and retry")
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.RespondWithError(w, http.StatusNotFound, "Tender or version not found")
 		} else {
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to rollback tender")
@@ -280,6 +446,7 @@ func (h *TenderHandler) RollbackTenderVersion(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	utils.SetETag(w, updatedTender.Version)
 	utils.RespondWithJSON(w, http.StatusOK, updatedTender)
 }
 
@@ -326,3 +493,120 @@ func (h *TenderHandler) GetTenderStatus(w http.ResponseWriter, r *http.Request)
 
 	utils.RespondWithJSON(w, http.StatusOK, tender.Status)
 }
+
+func (h *TenderHandler) ScheduleTenderPublication(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tenderId := vars["id"]
+
+	publishAt, err := time.Parse(time.RFC3339, r.URL.Query().Get("publishAt"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid or missing publishAt, expected RFC 3339")
+		return
+	}
+
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing username")
+		return
+	}
+
+	authorized, err := h.tenderService.IsUserAuthorizedToEditTender(username, tenderId)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	tender, err := h.tenderService.ScheduleTenderPublication(r.Context(), tenderId, publishAt)
+	if err != nil {
+		if errors.Is(err, services.ErrPublishAtInPast) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		} else if errors.Is(err, services.ErrTenderNotSchedulable) {
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+		} else if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithError(w, http.StatusNotFound, "Tender not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to schedule tender publication")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, tender)
+}
+
+func (h *TenderHandler) UnscheduleTenderPublication(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tenderId := vars["id"]
+
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing username")
+		return
+	}
+
+	authorized, err := h.tenderService.IsUserAuthorizedToEditTender(username, tenderId)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	tender, err := h.tenderService.UnscheduleTenderPublication(r.Context(), tenderId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithError(w, http.StatusNotFound, "Tender not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to unschedule tender publication")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, tender)
+}
+
+func (h *TenderHandler) GetScheduledTenders(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := utils.GetPaginationParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	organizationID := r.URL.Query().Get("organizationId")
+	if organizationID == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing organizationId")
+		return
+	}
+
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing username")
+		return
+	}
+
+	authorized, err := h.tenderService.IsUserAuthorizedToCreateTender(username, organizationID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	tenders, err := h.tenderService.GetScheduledTenders(r.Context(), organizationID, limit, offset)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve tenders")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, tenders)
+}
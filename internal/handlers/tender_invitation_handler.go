@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"zadanie-6105/internal/middlewares"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/services"
+	"zadanie-6105/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+type TenderInvitationHandler struct {
+	invitationService *services.TenderInvitationService
+}
+
+func NewTenderInvitationHandler(invitationService *services.TenderInvitationService) *TenderInvitationHandler {
+	return &TenderInvitationHandler{invitationService: invitationService}
+}
+
+func (h *TenderInvitationHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tenders/{id}/invitations", h.InviteOrganization).Methods("POST")
+	router.HandleFunc("/tenders/{id}/invitations", h.GetInvitations).Methods("GET")
+	router.HandleFunc("/tenders/invitations/{invitationId}", h.RevokeInvitation).Methods("DELETE")
+	router.HandleFunc("/invitations/my", h.GetUserInvitations).Methods("GET")
+	router.HandleFunc("/invitations/{invitationId}/accept", h.respondHandler(models.InvitationStatusAccepted)).Methods("PUT")
+	router.HandleFunc("/invitations/{invitationId}/decline", h.respondHandler(models.InvitationStatusDeclined)).Methods("PUT")
+}
+
+func (h *TenderInvitationHandler) InviteOrganization(w http.ResponseWriter, r *http.Request) {
+	tenderId := mux.Vars(r)["id"]
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Пользователь не аутентифицирован")
+		return
+	}
+
+	var request struct {
+		OrganizationID string `json:"organizationId" validate:"required,uuid"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := utils.ValidateStruct(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	authorized, err := h.invitationService.IsUserAuthorizedToManageInvitations(username, tenderId)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		return
+	}
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	exists, err := h.invitationService.IsOrganizationExists(r.Context(), request.OrganizationID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Error checking organization existence")
+		return
+	}
+	if !exists {
+		utils.RespondWithError(w, http.StatusNotFound, "Organization not found")
+		return
+	}
+
+	invitation, err := h.invitationService.InviteOrganization(r.Context(), tenderId, request.OrganizationID, username)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInvitation) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Tender not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create invitation")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, invitation)
+}
+
+func (h *TenderInvitationHandler) GetInvitations(w http.ResponseWriter, r *http.Request) {
+	tenderId := mux.Vars(r)["id"]
+
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing username")
+		return
+	}
+
+	authorized, err := h.invitationService.IsUserAuthorizedToManageInvitations(username, tenderId)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		return
+	}
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	invitations, err := h.invitationService.GetInvitations(r.Context(), tenderId)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve invitations")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, invitations)
+}
+
+func (h *TenderInvitationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	invitationId := mux.Vars(r)["invitationId"]
+
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing username")
+		return
+	}
+
+	invitation, err := h.invitationService.GetInvitation(r.Context(), invitationId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Invitation not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve invitation")
+		}
+		return
+	}
+
+	authorized, err := h.invitationService.IsUserAuthorizedToManageInvitations(username, invitation.TenderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		return
+	}
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	if err := h.invitationService.RevokeInvitation(r.Context(), invitationId); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to revoke invitation")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Invitation revoked successfully"})
+}
+
+func (h *TenderInvitationHandler) GetUserInvitations(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := utils.GetPaginationParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing username")
+		return
+	}
+
+	invitations, err := h.invitationService.GetUserInvitations(r.Context(), username, limit, offset)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve invitations")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, invitations)
+}
+
+func (h *TenderInvitationHandler) respondHandler(status models.InvitationStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		invitationId := mux.Vars(r)["invitationId"]
+
+		username, ok := middlewares.GetUsernameFromContext(r.Context())
+		if !ok {
+			utils.RespondWithError(w, http.StatusUnauthorized, "Пользователь не аутентифицирован")
+			return
+		}
+
+		invitation, err := h.invitationService.GetInvitation(r.Context(), invitationId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.RespondWithError(w, http.StatusNotFound, "Invitation not found")
+			} else {
+				utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve invitation")
+			}
+			return
+		}
+
+		authorized, err := h.invitationService.IsUserAuthorizedToRespond(username, invitation)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+			return
+		}
+		if !authorized {
+			utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+			return
+		}
+
+		invitation, err = h.invitationService.RespondToInvitation(r.Context(), invitationId, username, status)
+		if err != nil {
+			if errors.Is(err, services.ErrInvitationAlreadyAnswered) {
+				utils.RespondWithError(w, http.StatusConflict, err.Error())
+			} else if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.RespondWithError(w, http.StatusNotFound, "Invitation not found")
+			} else {
+				utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update invitation")
+			}
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, invitation)
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"zadanie-6105/internal/middlewares"
+	"zadanie-6105/internal/services"
+	"zadanie-6105/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+type TenderQuestionHandler struct {
+	questionService *services.TenderQuestionService
+}
+
+func NewTenderQuestionHandler(questionService *services.TenderQuestionService) *TenderQuestionHandler {
+	return &TenderQuestionHandler{questionService: questionService}
+}
+
+func (h *TenderQuestionHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tenders/{id}/questions", h.AskQuestion).Methods("POST")
+	router.HandleFunc("/tenders/{id}/questions", h.GetQuestions).Methods("GET")
+	router.HandleFunc("/tenders/questions/{questionId}/answer", h.AnswerQuestion).Methods("PUT")
+}
+
+func (h *TenderQuestionHandler) AskQuestion(w http.ResponseWriter, r *http.Request) {
+	tenderId := mux.Vars(r)["id"]
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var request struct {
+		Question string `json:"question" validate:"required,max=2000"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := utils.ValidateStruct(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	question, err := h.questionService.AskQuestion(r.Context(), tenderId, username, request.Question)
+	if err != nil {
+		if errors.Is(err, services.ErrTenderNotOpenForQuestions) {
+			utils.RespondWithError(w, http.StatusConflict, "Тендер не принимает вопросы")
+		} else if err == sql.ErrNoRows || errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Tender not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to save question")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, question)
+}
+
+func (h *TenderQuestionHandler) GetQuestions(w http.ResponseWriter, r *http.Request) {
+	tenderId := mux.Vars(r)["id"]
+
+	limit, offset, err := utils.GetPaginationParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	authorized, err := h.questionService.IsUserAuthorizedToViewQuestions(r.Context(), username, tenderId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Tender not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		}
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	questions, err := h.questionService.GetQuestions(r.Context(), tenderId, username, limit, offset)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusUnauthorized, "Пользователь не существует или некорректен")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve questions")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, questions)
+}
+
+func (h *TenderQuestionHandler) AnswerQuestion(w http.ResponseWriter, r *http.Request) {
+	questionId := mux.Vars(r)["questionId"]
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var request struct {
+		Answer string `json:"answer" validate:"required,max=5000"`
+		Public bool   `json:"public"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := utils.ValidateStruct(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	authorized, err := h.questionService.IsUserAuthorizedToAnswer(r.Context(), username, questionId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Question not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		}
+		return
+	}
+
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	question, err := h.questionService.AnswerQuestion(r.Context(), questionId, username, request.Answer, request.Public)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Question not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to save answer")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, question)
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"zadanie-6105/internal/middlewares"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/services"
+	"zadanie-6105/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+func (h *WebhookHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/webhooks", h.CreateSubscription).Methods("POST")
+	router.HandleFunc("/webhooks", h.GetSubscriptions).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", h.DeleteSubscription).Methods("DELETE")
+	router.HandleFunc("/webhooks/{id}/deliveries", h.GetDeliveries).Methods("GET")
+	router.HandleFunc("/webhooks/deliveries/{deliveryId}/redeliver", h.Redeliver).Methods("POST")
+}
+
+func (h *WebhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Пользователь не аутентифицирован")
+		return
+	}
+
+	var request struct {
+		OrganizationID string                   `json:"organizationId" validate:"required,uuid"`
+		URL            string                   `json:"url" validate:"required,url"`
+		EventTypes     models.WebhookEventTypes `json:"eventTypes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := utils.ValidateStruct(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	authorized, err := h.webhookService.IsUserAuthorizedToManageWebhooks(username, request.OrganizationID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		return
+	}
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	subscription := &models.WebhookSubscription{
+		OrganizationID: request.OrganizationID,
+		URL:            request.URL,
+		EventTypes:     request.EventTypes,
+	}
+	if err := h.webhookService.CreateSubscription(r.Context(), username, subscription); err != nil {
+		if errors.Is(err, services.ErrInvalidWebhook) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create webhook subscription")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, subscription)
+}
+
+func (h *WebhookHandler) GetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing username")
+		return
+	}
+	organizationID := r.URL.Query().Get("organizationId")
+	if err := utils.ValidateVar(organizationID, "required,uuid"); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid organizationId parameter")
+		return
+	}
+
+	authorized, err := h.webhookService.IsUserAuthorizedToManageWebhooks(username, organizationID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		return
+	}
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return
+	}
+
+	subscriptions, err := h.webhookService.GetSubscriptions(r.Context(), organizationID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve webhook subscriptions")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, subscriptions)
+}
+
+func (h *WebhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	subscriptionId := mux.Vars(r)["id"]
+
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing username")
+		return
+	}
+
+	if !h.authorizeSubscription(w, r, username, subscriptionId) {
+		return
+	}
+
+	if err := h.webhookService.DeleteSubscription(r.Context(), subscriptionId); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete webhook subscription")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	subscriptionId := mux.Vars(r)["id"]
+
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing username")
+		return
+	}
+
+	status := models.WebhookDeliveryStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryDeadLetter:
+	default:
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid status parameter")
+		return
+	}
+
+	limit, offset, err := utils.GetPaginationParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	if !h.authorizeSubscription(w, r, username, subscriptionId) {
+		return
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(r.Context(), subscriptionId, status, limit, offset)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve webhook deliveries")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, deliveries)
+}
+
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	deliveryId := mux.Vars(r)["deliveryId"]
+
+	username, ok := middlewares.GetUsernameFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Пользователь не аутентифицирован")
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(r.Context(), deliveryId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Webhook delivery not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve webhook delivery")
+		}
+		return
+	}
+
+	if !h.authorizeSubscription(w, r, username, delivery.SubscriptionID) {
+		return
+	}
+
+	delivery, err = h.webhookService.Redeliver(r.Context(), deliveryId)
+	if err != nil {
+		if errors.Is(err, services.ErrWebhookDeliveryPending) {
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Webhook delivery not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to redeliver webhook")
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, delivery)
+}
+
+// authorizeSubscription writes the error response and returns false unless
+// the user is responsible for the organization owning the subscription.
+func (h *WebhookHandler) authorizeSubscription(w http.ResponseWriter, r *http.Request, username, subscriptionId string) bool {
+	subscription, err := h.webhookService.GetSubscription(r.Context(), subscriptionId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Webhook subscription not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve webhook subscription")
+		}
+		return false
+	}
+
+	authorized, err := h.webhookService.IsUserAuthorizedToManageWebhooks(username, subscription.OrganizationID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Ошибка проверки прав доступа")
+		return false
+	}
+	if !authorized {
+		utils.RespondWithError(w, http.StatusForbidden, "Недостаточно прав для выполнения действия")
+		return false
+	}
+	return true
+}
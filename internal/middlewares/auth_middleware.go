@@ -7,6 +7,8 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strings"
+	"zadanie-6105/internal/audit"
 	"zadanie-6105/pkg/utils"
 
 	"gorm.io/gorm"
@@ -25,7 +27,11 @@ func AuthMiddleware(db *gorm.DB) func(http.Handler) http.Handler {
 			var username string
 			var organizationID string
 
-			if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
+			// Multipart uploads carry a file rather than JSON, so like GET
+			// requests they identify the user with query parameters
+			isMultipart := strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data")
+
+			if (r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch) && !isMultipart {
 				// Read the body into bytes
 				bodyBytes, err := io.ReadAll(r.Body)
 				if err != nil {
@@ -128,6 +134,7 @@ func AuthMiddleware(db *gorm.DB) func(http.Handler) http.Handler {
 			if organizationID != "" {
 				ctx = context.WithValue(ctx, organizationContextKey, organizationID)
 			}
+			ctx = audit.WithActor(ctx, audit.Actor{Username: username, OrganizationID: organizationID})
 
 			// Proceed to the next handler with the updated context
 			next.ServeHTTP(w, r.WithContext(ctx))
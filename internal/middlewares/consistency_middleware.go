@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	database "zadanie-6105/internal/db"
+)
+
+const (
+	forcePrimaryHeader = "X-Force-Primary"
+	forcePrimaryCookie = "force_primary"
+)
+
+// ConsistencyMiddleware keeps a client's reads on the primary database for
+// the given window after it performs a mutation, so it always sees its own
+// writes even when replicas lag. Clients may also request the primary
+// explicitly with the X-Force-Primary: true header.
+func ConsistencyMiddleware(window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			forcePrimary := strings.EqualFold(r.Header.Get(forcePrimaryHeader), "true")
+
+			if cookie, err := r.Cookie(forcePrimaryCookie); err == nil && cookie.Value == "1" {
+				forcePrimary = true
+			}
+
+			if isMutation(r.Method) {
+				forcePrimary = true
+				if window > 0 {
+					http.SetCookie(w, &http.Cookie{
+						Name:     forcePrimaryCookie,
+						Value:    "1",
+						Path:     "/",
+						MaxAge:   cookieMaxAge(window),
+						HttpOnly: true,
+					})
+				}
+			}
+
+			if forcePrimary {
+				r = r.WithContext(database.WithForcePrimary(r.Context()))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// cookieMaxAge rounds the window up to whole seconds, as a MaxAge of 0
+// would turn the cookie into a session cookie.
+func cookieMaxAge(window time.Duration) int {
+	return int((window + time.Second - 1) / time.Second)
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
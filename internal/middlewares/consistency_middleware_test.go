@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	database "zadanie-6105/internal/db"
+)
+
+func TestConsistencyMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		header      string
+		cookie      bool
+		window      time.Duration
+		wantPrimary bool
+		wantCookie  bool
+		wantMaxAge  int
+	}{
+		{name: "read", method: http.MethodGet},
+		{name: "read with header", method: http.MethodGet, header: "true", wantPrimary: true},
+		{name: "read with other header value", method: http.MethodGet, header: "no"},
+		{name: "read after write", method: http.MethodGet, cookie: true, wantPrimary: true},
+		{name: "write", method: http.MethodPost, window: 5 * time.Second, wantPrimary: true, wantCookie: true, wantMaxAge: 5},
+		{name: "write with sub-second window", method: http.MethodPut, window: 300 * time.Millisecond, wantPrimary: true, wantCookie: true, wantMaxAge: 1},
+		{name: "write with fractional window", method: http.MethodPatch, window: 2500 * time.Millisecond, wantPrimary: true, wantCookie: true, wantMaxAge: 3},
+		{name: "write without window", method: http.MethodDelete, wantPrimary: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var forced bool
+			handler := ConsistencyMiddleware(tt.window)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				forced = database.IsForcePrimary(r.Context())
+			}))
+
+			r := httptest.NewRequest(tt.method, "/api/tenders", nil)
+			if tt.header != "" {
+				r.Header.Set(forcePrimaryHeader, tt.header)
+			}
+			if tt.cookie {
+				r.AddCookie(&http.Cookie{Name: forcePrimaryCookie, Value: "1"})
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if forced != tt.wantPrimary {
+				t.Errorf("forced primary = %v, want %v", forced, tt.wantPrimary)
+			}
+
+			var cookie *http.Cookie
+			for _, c := range w.Result().Cookies() {
+				if c.Name == forcePrimaryCookie {
+					cookie = c
+				}
+			}
+			if (cookie != nil) != tt.wantCookie {
+				t.Fatalf("cookie set = %v, want %v", cookie != nil, tt.wantCookie)
+			}
+			if cookie != nil && cookie.MaxAge != tt.wantMaxAge {
+				t.Errorf("cookie MaxAge = %d, want %d", cookie.MaxAge, tt.wantMaxAge)
+			}
+		})
+	}
+}
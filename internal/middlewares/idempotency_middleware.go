@@ -0,0 +1,200 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+	"zadanie-6105/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	// Largest request body hashed for an Idempotency-Key
+	maxIdempotentBodySize = 1 << 20
+	// How long a request may hold its key before a retry takes it over, in
+	// case the server processing it stopped without storing a response
+	idempotencyLease = time.Minute
+)
+
+// IdempotencyMiddleware makes POST requests to the given paths, the JSON
+// create endpoints, safe to retry when they carry an Idempotency-Key
+// header. The first response is stored per authenticated user and key; an
+// identical retry gets the stored response back, while reusing the key with
+// a different query or payload is rejected with 422. Must run after
+// AuthMiddleware.
+func IdempotencyMiddleware(repo repositories.IdempotencyRepository, ttl time.Duration, paths ...string) func(http.Handler) http.Handler {
+	idempotent := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		idempotent[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" || !idempotent[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				utils.RespondWithError(w, http.StatusBadRequest, "Idempotency-Key is too long")
+				return
+			}
+
+			owner := idempotencyOwner(r.Context())
+			if owner == "" {
+				utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+				return
+			}
+
+			bodyBytes, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					utils.RespondWithError(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+					return
+				}
+				utils.RespondWithError(w, http.StatusBadRequest, "Invalid request format")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+
+			hash := sha256.New()
+			hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+			hash.Write(bodyBytes)
+			requestHash := hex.EncodeToString(hash.Sum(nil))
+
+			record := &models.IdempotencyKey{
+				Owner:       owner,
+				Key:         key,
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				RequestHash: requestHash,
+				ExpiresAt:   time.Now().Add(ttl),
+			}
+
+			reserved, err := reserveIdempotencyKey(r.Context(), repo, record)
+			if err != nil {
+				log.Println("Failed to reserve idempotency key:", err)
+				utils.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error")
+				return
+			}
+
+			if !reserved {
+				stored, err := repo.GetKey(r.Context(), owner, key)
+				if err != nil {
+					log.Println("Failed to load idempotency key:", err)
+					utils.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error")
+					return
+				}
+
+				if stored.RequestHash != requestHash {
+					utils.RespondWithError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+					return
+				}
+
+				if stored.StatusCode == 0 {
+					utils.RespondWithError(w, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+					return
+				}
+
+				if stored.ContentType != "" {
+					w.Header().Set("Content-Type", stored.ContentType)
+				}
+				w.Header().Set(idempotencyReplayedHeader, "true")
+				w.WriteHeader(stored.StatusCode)
+				w.Write(stored.ResponseBody)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			// Server errors are not cached so the client can retry
+			ctx := context.WithoutCancel(r.Context())
+			if recorder.statusCode >= http.StatusInternalServerError {
+				if err := repo.DeleteKey(ctx, owner, key); err != nil {
+					log.Println("Failed to release idempotency key:", err)
+				}
+				return
+			}
+
+			if err := repo.CompleteKey(ctx, owner, key, recorder.statusCode, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+				log.Println("Failed to store idempotent response:", err)
+			}
+		})
+	}
+}
+
+// reserveIdempotencyKey claims the key, replacing a stored entry that has
+// expired but has not been swept yet, or that is still marked in progress
+// after the lease.
+func reserveIdempotencyKey(ctx context.Context, repo repositories.IdempotencyRepository, record *models.IdempotencyKey) (bool, error) {
+	reserved, err := repo.ReserveKey(ctx, record)
+	if err != nil || reserved {
+		return reserved, err
+	}
+
+	stored, err := repo.GetKey(ctx, record.Owner, record.Key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repo.ReserveKey(ctx, record)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	leaseStart := now.Add(-idempotencyLease)
+	if stored.ExpiresAt.Before(now) || (stored.StatusCode == 0 && stored.CreatedAt.Before(leaseStart)) {
+		// Only a stale entry is deleted, never one a concurrent retry just reserved
+		if err := repo.DeleteStaleKey(ctx, record.Owner, record.Key, now, leaseStart); err != nil {
+			return false, err
+		}
+		return repo.ReserveKey(ctx, record)
+	}
+
+	return false, nil
+}
+
+func idempotencyOwner(ctx context.Context) string {
+	if username, ok := GetUsernameFromContext(ctx); ok {
+		return "user:" + username
+	}
+	if organizationID, ok := GetOrganizationIDFromContext(ctx); ok {
+		return "organization:" + organizationID
+	}
+	return ""
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.statusCode = code
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,178 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// memoryIdempotencyRepository keeps idempotency keys in memory.
+type memoryIdempotencyRepository struct {
+	mu   sync.Mutex
+	keys map[string]models.IdempotencyKey
+}
+
+func newMemoryIdempotencyRepository() *memoryIdempotencyRepository {
+	return &memoryIdempotencyRepository{keys: make(map[string]models.IdempotencyKey)}
+}
+
+func (r *memoryIdempotencyRepository) ReserveKey(ctx context.Context, key *models.IdempotencyKey) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[key.Owner+"/"+key.Key]; ok {
+		return false, nil
+	}
+	stored := *key
+	stored.CreatedAt = time.Now()
+	r.keys[key.Owner+"/"+key.Key] = stored
+	return true, nil
+}
+
+func (r *memoryIdempotencyRepository) GetKey(ctx context.Context, owner, key string) (*models.IdempotencyKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.keys[owner+"/"+key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &stored, nil
+}
+
+func (r *memoryIdempotencyRepository) CompleteKey(ctx context.Context, owner, key string, statusCode int, contentType string, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.keys[owner+"/"+key]
+	stored.StatusCode, stored.ContentType, stored.ResponseBody = statusCode, contentType, body
+	r.keys[owner+"/"+key] = stored
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) DeleteKey(ctx context.Context, owner, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.keys, owner+"/"+key)
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) DeleteStaleKey(ctx context.Context, owner, key string, now, leaseStart time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.keys[owner+"/"+key]
+	if ok && (stored.ExpiresAt.Before(now) || (stored.StatusCode == 0 && stored.CreatedAt.Before(leaseStart))) {
+		delete(r.keys, owner+"/"+key)
+	}
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	const path = "/api/tenders/new"
+
+	tests := []struct {
+		name string
+		// Entry stored for the key before the request, if any
+		stored     *models.IdempotencyKey
+		target     string
+		body       string
+		wantStatus int
+		wantCalls  int
+		wantReplay bool
+	}{
+		{name: "first request", target: path, body: `{}`, wantStatus: http.StatusCreated, wantCalls: 1},
+		{name: "other path", target: "/api/tenders/import", body: `{}`, wantStatus: http.StatusCreated, wantCalls: 1},
+		{
+			name:       "replay",
+			stored:     &models.IdempotencyKey{StatusCode: http.StatusCreated, ResponseBody: []byte(`{"id":"1"}`)},
+			target:     path,
+			body:       `{}`,
+			wantStatus: http.StatusCreated,
+			wantReplay: true,
+		},
+		{
+			name:       "different query",
+			stored:     &models.IdempotencyKey{StatusCode: http.StatusCreated},
+			target:     path + "?dryRun=true",
+			body:       `{}`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "different body",
+			stored:     &models.IdempotencyKey{StatusCode: http.StatusCreated},
+			target:     path,
+			body:       `{"name":"other"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "in progress",
+			stored:     &models.IdempotencyKey{CreatedAt: time.Now()},
+			target:     path,
+			body:       `{}`,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "in progress past the lease",
+			stored:     &models.IdempotencyKey{CreatedAt: time.Now().Add(-2 * idempotencyLease)},
+			target:     path,
+			body:       `{}`,
+			wantStatus: http.StatusCreated,
+			wantCalls:  1,
+		},
+		{
+			name:       "body too large",
+			target:     path,
+			body:       `"` + strings.Repeat("a", maxIdempotentBodySize) + `"`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryIdempotencyRepository()
+			if tt.stored != nil {
+				stored := *tt.stored
+				stored.Owner, stored.Key = "user:alice", "key-1"
+				stored.ExpiresAt = time.Now().Add(time.Hour)
+				// The stored request posted an empty JSON object to path
+				hash := sha256.Sum256([]byte(http.MethodPost + " " + path + "\n{}"))
+				stored.RequestHash = hex.EncodeToString(hash[:])
+				repo.keys["user:alice/key-1"] = stored
+			}
+
+			var calls int
+			handler := IdempotencyMiddleware(repo, time.Hour, path)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(http.StatusCreated)
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set(idempotencyKeyHeader, "key-1")
+			r = r.WithContext(context.WithValue(r.Context(), userContextKey, "alice"))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if replayed := w.Header().Get(idempotencyReplayedHeader) == "true"; replayed != tt.wantReplay {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplay)
+			}
+		})
+	}
+}
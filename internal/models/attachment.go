@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+type AttachmentOwnerType string
+
+const (
+	AttachmentOwnerTender AttachmentOwnerType = "Tender"
+	AttachmentOwnerBid    AttachmentOwnerType = "Bid"
+)
+
+// Attachment is the metadata of a file attached to a tender or a bid. The
+// contents live in the configured storage under StorageKey.
+type Attachment struct {
+	ID          string              `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	OwnerType   AttachmentOwnerType `gorm:"type:varchar(20);not null;index:idx_attachments_owner" json:"ownerType"`
+	OwnerID     string              `gorm:"type:uuid;not null;index:idx_attachments_owner" json:"ownerId"`
+	FileName    string              `gorm:"type:varchar(255);not null" json:"fileName"`
+	ContentType string              `gorm:"type:varchar(255);not null" json:"contentType"`
+	Size        int64               `gorm:"not null" json:"size"`
+	// Hex-encoded SHA-256 of the contents
+	SHA256     string    `gorm:"column:sha256;type:char(64);not null" json:"sha256"`
+	StorageKey string    `gorm:"type:varchar(512);not null;unique" json:"-"`
+	UploadedBy string    `gorm:"type:uuid;not null" json:"uploadedBy"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
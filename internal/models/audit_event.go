@@ -0,0 +1,122 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+type AuditEntityType string
+
+const (
+	AuditEntityTender      AuditEntityType = "Tender"
+	AuditEntityBid         AuditEntityType = "Bid"
+	AuditEntityBidDecision AuditEntityType = "BidDecision"
+	AuditEntityBidReview   AuditEntityType = "BidReview"
+)
+
+type AuditAction string
+
+const (
+	AuditTenderCreated              AuditAction = "tender.created"
+	AuditTenderUpdated              AuditAction = "tender.updated"
+	AuditTenderStatusChanged        AuditAction = "tender.status_changed"
+	AuditTenderRolledBack           AuditAction = "tender.rolled_back"
+	AuditTenderDeleted              AuditAction = "tender.deleted"
+	AuditTenderPublicationScheduled AuditAction = "tender.publication_scheduled"
+	AuditTenderPublicationCanceled  AuditAction = "tender.publication_unscheduled"
+	AuditTenderClosedByDeadline     AuditAction = "tender.closed_by_deadline"
+	AuditTenderPublishedBySchedule  AuditAction = "tender.published_by_schedule"
+	AuditBidCreated                 AuditAction = "bid.created"
+	AuditBidUpdated                 AuditAction = "bid.updated"
+	AuditBidStatusChanged           AuditAction = "bid.status_changed"
+	AuditBidWithdrawn               AuditAction = "bid.withdrawn"
+	AuditBidResubmitted             AuditAction = "bid.resubmitted"
+	AuditBidRolledBack              AuditAction = "bid.rolled_back"
+	AuditBidDeleted                 AuditAction = "bid.deleted"
+	AuditBidFeedbackSubmitted       AuditAction = "bid.feedback_submitted"
+	AuditBidDecisionSubmitted       AuditAction = "bid.decision_submitted"
+	AuditBidReviewCreated           AuditAction = "review.created"
+	AuditBidReviewUpdated           AuditAction = "review.updated"
+	AuditBidReviewDeleted           AuditAction = "review.deleted"
+)
+
+// AuditEvent is an append-only record of a mutation. OrganizationID is the
+// organization owning the affected tender, so its responsible employees can
+// review everything that happened to their tenders and the bids on them.
+type AuditEvent struct {
+	ID             int64           `gorm:"primaryKey;autoIncrement" json:"id"`
+	OccurredAt     time.Time       `gorm:"not null;default:now();index" json:"occurredAt"`
+	ActorID        *string         `gorm:"type:uuid" json:"actorId,omitempty"`
+	ActorUsername  string          `gorm:"type:varchar(50)" json:"actorUsername,omitempty"`
+	ActorOrgID     string          `gorm:"column:actor_organization_id;type:varchar(36)" json:"actorOrganizationId,omitempty"`
+	Action         AuditAction     `gorm:"type:varchar(50);not null;index" json:"action"`
+	EntityType     AuditEntityType `gorm:"type:varchar(20);not null;index:idx_audit_events_entity" json:"entityType"`
+	EntityID       string          `gorm:"type:uuid;not null;index:idx_audit_events_entity" json:"entityId"`
+	OrganizationID string          `gorm:"type:uuid;not null;index" json:"organizationId"`
+	Before         AuditSnapshot   `gorm:"type:jsonb" json:"before"`
+	After          AuditSnapshot   `gorm:"type:jsonb" json:"after"`
+	RequestID      string          `gorm:"type:varchar(128)" json:"requestId,omitempty"`
+	ClientIP       string          `gorm:"type:varchar(45)" json:"clientIp,omitempty"`
+	// Hash covers the event content and PrevHash, the hash of the preceding
+	// event, so that altering or removing any event breaks the chain
+	PrevHash string `gorm:"type:varchar(64);not null;default:''" json:"prevHash"`
+	Hash     string `gorm:"type:varchar(64);not null;default:''" json:"hash"`
+}
+
+// AuditDigest pins the head of the audit hash chain at the end of a UTC day.
+// Digests are signed so that an archived copy proves the state of the chain
+// independently of the database.
+type AuditDigest struct {
+	Day          time.Time `gorm:"type:date;primaryKey" json:"day"`
+	FirstEventID int64     `gorm:"not null" json:"firstEventId"`
+	LastEventID  int64     `gorm:"not null" json:"lastEventId"`
+	EventCount   int64     `gorm:"not null" json:"eventCount"`
+	PrevHash     string    `gorm:"type:varchar(64);not null" json:"prevHash"`
+	HeadHash     string    `gorm:"type:varchar(64);not null" json:"headHash"`
+	Signature    string    `gorm:"type:text;not null" json:"signature"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// AuditSnapshot is the JSON state of an entity before or after a change;
+// nil for creations and deletions respectively.
+type AuditSnapshot []byte
+
+func (s AuditSnapshot) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return string(s), nil
+}
+
+func (s *AuditSnapshot) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+	case []byte:
+		*s = append(AuditSnapshot(nil), v...)
+	case string:
+		*s = AuditSnapshot(v)
+	default:
+		return fmt.Errorf("cannot scan %T into AuditSnapshot", value)
+	}
+	return nil
+}
+
+func (s AuditSnapshot) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return []byte("null"), nil
+	}
+	return s, nil
+}
+
+// AuditFilter narrows GET /api/audit to one organization's events.
+type AuditFilter struct {
+	OrganizationID string
+	EntityType     AuditEntityType
+	EntityID       string
+	ActorUsername  string
+	Action         AuditAction
+	From           *time.Time
+	To             *time.Time
+}
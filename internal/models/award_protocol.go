@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// AwardProtocol holds what the award protocol of a closed tender lists:
+// the latest version of the tender, its bids with their latest decision
+// and feedback, and every decision and review on them.
+type AwardProtocol struct {
+	Tender           *Tender
+	OrganizationName string
+	Bids             []*BidExportRow
+	Decisions        []*ProtocolDecision
+	Reviews          []*ProtocolReview
+}
+
+// ProtocolDecision is a decision on one of the tender's bids.
+type ProtocolDecision struct {
+	BidID     string
+	BidName   string
+	Decision  BidDecisionType
+	Approver  string
+	CreatedAt time.Time
+}
+
+// ProtocolReview is a review of one of the tender's bids.
+type ProtocolReview struct {
+	BidID     string
+	BidName   string
+	Reviewer  string
+	Rating    int
+	Category  BidReviewCategory
+	Review    string
+	CreatedAt time.Time
+}
@@ -1,7 +1,12 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type BidStatus string
@@ -10,8 +15,19 @@ const (
 	BidStatusCreated   BidStatus = "Created"
 	BidStatusPublished BidStatus = "Published"
 	BidStatusCanceled  BidStatus = "Canceled"
+	// Withdrawn by the author; the bid can be resubmitted later
+	BidStatusWithdrawn BidStatus = "Withdrawn"
 )
 
+// IsActive reports whether the bid still takes part in the tender.
+func (s BidStatus) IsActive() bool {
+	return s == BidStatusCreated || s == BidStatusPublished
+}
+
+func (s BidStatus) IsValid() bool {
+	return s.IsActive() || s == BidStatusCanceled || s == BidStatusWithdrawn
+}
+
 type AuthorType string
 
 const (
@@ -30,11 +46,149 @@ type Bid struct {
 	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"createdAt"`
 	Version     int        `json:"version"`
 	Feedback    string     `json:"feedback"`
+
+	// Commercial terms of the offer
+	Amount       decimal.NullDecimal `gorm:"type:numeric(18,2)" json:"amount"`
+	Currency     string              `gorm:"type:char(3)" json:"currency,omitempty" validate:"omitempty,iso4217"`
+	DeliveryDays int                 `gorm:"not null;default:0" json:"deliveryDays" validate:"min=0"`
+	ValidUntil   *time.Time          `json:"validUntil,omitempty"`
+	LineItems    BidLineItems        `gorm:"type:jsonb" json:"lineItems,omitempty" validate:"omitempty,dive"`
+
+	// OverBudget is set when listing a tender's bids whose amount exceeds
+	// the tender budget in the same currency
+	OverBudget bool `gorm:"-" json:"overBudget"`
 }
 
-type BidReview struct {
-	ID        string    `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	BidID     string    `gorm:"type:uuid;not null" json:"bidId"`
-	Review    string    `gorm:"type:text;not null" json:"review"`
+type BidLineItem struct {
+	Description string          `json:"description" validate:"required"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	UnitPrice   decimal.Decimal `json:"unitPrice"`
+}
+
+// BidLineItems is stored as a JSON array in the bids table.
+type BidLineItems []BidLineItem
+
+func (items BidLineItems) Value() (driver.Value, error) {
+	if items == nil {
+		return nil, nil
+	}
+	return json.Marshal(items)
+}
+
+func (items *BidLineItems) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*items = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, items)
+	case string:
+		return json.Unmarshal([]byte(v), items)
+	default:
+		return fmt.Errorf("cannot scan %T into BidLineItems", value)
+	}
+}
+
+type BidSortField string
+
+const (
+	BidSortByName      BidSortField = "name"
+	BidSortByPrice     BidSortField = "price"
+	BidSortByCreatedAt BidSortField = "createdAt"
+)
+
+// BidListFilter narrows and orders the bids of a tender.
+type BidListFilter struct {
+	SortBy     BidSortField
+	Descending bool
+	MinAmount  decimal.NullDecimal
+	MaxAmount  decimal.NullDecimal
+	Currency   string
+	Status     BidStatus
+}
+
+// BidStatusChange is one entry of a bid's status history, kept across
+// withdrawals and resubmissions.
+type BidStatusChange struct {
+	ID         string    `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	BidID      string    `gorm:"type:uuid;not null;index" json:"bidId"`
+	FromStatus BidStatus `gorm:"type:varchar(50);not null" json:"fromStatus"`
+	ToStatus   BidStatus `gorm:"type:varchar(50);not null" json:"toStatus"`
+	// Bid version after the change
+	Version   int       `gorm:"not null" json:"version"`
+	Reason    string    `gorm:"type:text" json:"reason,omitempty"`
+	ChangedBy string    `gorm:"type:uuid;not null" json:"changedBy"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
 }
+
+type BidDecisionType string
+
+const (
+	BidDecisionApproved BidDecisionType = "Approved"
+	BidDecisionRejected BidDecisionType = "Rejected"
+)
+
+// BidDecision records a responsible employee's decision on a bid.
+type BidDecision struct {
+	ID         string          `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	BidID      string          `gorm:"type:uuid;not null;index" json:"bidId"`
+	Decision   BidDecisionType `gorm:"type:varchar(50);not null" json:"decision"`
+	ApproverID string          `gorm:"type:uuid;not null" json:"approverId"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+type BidReviewCategory string
+
+const (
+	BidReviewCategoryQuality       BidReviewCategory = "Quality"
+	BidReviewCategoryPrice         BidReviewCategory = "Price"
+	BidReviewCategoryDelivery      BidReviewCategory = "Delivery"
+	BidReviewCategoryCommunication BidReviewCategory = "Communication"
+	BidReviewCategoryOther         BidReviewCategory = "Other"
+)
+
+type BidReview struct {
+	ID         string            `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	BidID      string            `gorm:"type:uuid;not null" json:"bidId"`
+	ReviewerID string            `gorm:"type:uuid;index" json:"reviewerId"`
+	Review     string            `gorm:"type:text;not null" json:"review" validate:"required"`
+	Rating     int               `gorm:"type:smallint;not null;default:0" json:"rating" validate:"required,min=1,max=5"`
+	Category   BidReviewCategory `gorm:"type:varchar(50)" json:"category,omitempty" validate:"omitempty,oneof=Quality Price Delivery Communication Other"`
+	CreatedAt  time.Time         `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt  time.Time         `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// AuthorReputation aggregates the reviews left on all bids of one author.
+type AuthorReputation struct {
+	AuthorID      string  `json:"authorId"`
+	ReviewCount   int64   `json:"reviewCount"`
+	AverageRating float64 `json:"averageRating"`
+}
+
+// BidReviewsWithReputation is the response of GET /bids/{tenderId}/reviews
+// when the author's reputation is requested with the reviews.
+type BidReviewsWithReputation struct {
+	Reviews    []*BidReview      `json:"reviews"`
+	Reputation *AuthorReputation `json:"reputation"`
+}
+
+// AuthorProfile summarizes an author's bidding history for responsible
+// employees deciding on one of their bids.
+type AuthorProfile struct {
+	AuthorID      string       `json:"authorId"`
+	AuthorType    AuthorType   `json:"authorType"`
+	BidsSubmitted int64        `json:"bidsSubmitted"`
+	BidsDecided   int64        `json:"bidsDecided"`
+	BidsApproved  int64        `json:"bidsApproved"`
+	ApprovalRate  float64      `json:"approvalRate"`
+	ReviewCount   int64        `json:"reviewCount"`
+	AverageRating float64      `json:"averageRating"`
+	RecentReviews []*BidReview `json:"recentReviews"`
+}
+
+// AuthorBidStats holds the bid counters an AuthorProfile is built from.
+type AuthorBidStats struct {
+	BidsSubmitted int64
+	BidsDecided   int64
+	BidsApproved  int64
+}
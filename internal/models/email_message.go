@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+type EmailMessageStatus string
+
+const (
+	EmailMessagePending EmailMessageStatus = "Pending"
+	EmailMessageSent    EmailMessageStatus = "Sent"
+	EmailMessageFailed  EmailMessageStatus = "Failed"
+)
+
+// EmailMessage is a rendered email queued for an employee. It is enqueued in
+// the transaction that publishes the event and sent by a background job,
+// retried with exponential backoff until the attempt limit. A claimed
+// message is leased to one sender until LockedUntil.
+type EmailMessage struct {
+	ID            string             `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	RecipientID   string             `gorm:"type:uuid;not null;index" json:"recipientId"`
+	EventID       string             `gorm:"type:uuid;not null" json:"eventId"`
+	To            string             `gorm:"column:to_address;type:varchar(255);not null" json:"to"`
+	Subject       string             `gorm:"type:text;not null" json:"subject"`
+	Body          string             `gorm:"type:text;not null" json:"body"`
+	Status        EmailMessageStatus `gorm:"type:varchar(20);not null;index:idx_email_messages_due" json:"status"`
+	Attempts      int                `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time          `gorm:"not null;index:idx_email_messages_due" json:"nextAttemptAt"`
+	LockedUntil   *time.Time         `json:"-"`
+	LastError     string             `gorm:"type:text" json:"lastError,omitempty"`
+	SentAt        *time.Time         `json:"sentAt,omitempty"`
+	CreatedAt     time.Time          `gorm:"autoCreateTime" json:"createdAt"`
+}
@@ -4,11 +4,15 @@ import (
 	"time"
 )
 
+// Employee notifications are emailed to Email, if set, in Language ("ru" or
+// "en", the configured default when empty).
 type Employee struct {
 	ID        string    `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
 	Username  string    `gorm:"type:varchar(50);unique;not null" json:"username"`
 	FirstName string    `gorm:"type:varchar(50)" json:"first_name"`
 	LastName  string    `gorm:"type:varchar(50)" json:"last_name"`
+	Email     string    `gorm:"type:varchar(255)" json:"email"`
+	Language  string    `gorm:"type:varchar(2)" json:"language"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+)
+
+type CriterionKind string
+
+const (
+	// Price, DeliveryTime and Reputation are scored automatically from the
+	// bid terms and the author's reviews; Quality and Custom are scored by
+	// responsible employees.
+	CriterionKindPrice        CriterionKind = "Price"
+	CriterionKindDeliveryTime CriterionKind = "DeliveryTime"
+	CriterionKindReputation   CriterionKind = "Reputation"
+	CriterionKindQuality      CriterionKind = "Quality"
+	CriterionKindCustom       CriterionKind = "Custom"
+)
+
+// MaxCriterionScore is the top of the 0..MaxCriterionScore scoring scale.
+const MaxCriterionScore = 10
+
+func (k CriterionKind) IsAutomatic() bool {
+	return k == CriterionKindPrice || k == CriterionKindDeliveryTime || k == CriterionKindReputation
+}
+
+type EvaluationCriterion struct {
+	ID        string        `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	TenderID  string        `gorm:"type:uuid;not null;index" json:"tenderId"`
+	Name      string        `gorm:"type:varchar(100);not null" json:"name" validate:"required,max=100"`
+	Kind      CriterionKind `gorm:"type:varchar(50);not null" json:"kind" validate:"required,oneof=Price DeliveryTime Reputation Quality Custom"`
+	Weight    float64       `gorm:"not null" json:"weight" validate:"gt=0"`
+	CreatedAt time.Time     `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+func (EvaluationCriterion) TableName() string {
+	return "evaluation_criteria"
+}
+
+// BidScore is one responsible employee's score of a bid against a manual criterion.
+type BidScore struct {
+	ID          string    `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	BidID       string    `gorm:"type:uuid;not null;uniqueIndex:idx_bid_scores_bid_criterion_scorer" json:"bidId"`
+	CriterionID string    `gorm:"type:uuid;not null;uniqueIndex:idx_bid_scores_bid_criterion_scorer" json:"criterionId" validate:"required,uuid"`
+	ScorerID    string    `gorm:"type:uuid;not null;uniqueIndex:idx_bid_scores_bid_criterion_scorer" json:"scorerId"`
+	Score       float64   `gorm:"not null" json:"score" validate:"min=0,max=10"`
+	Comment     string    `gorm:"type:text" json:"comment,omitempty"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// BidComparison is the ranked evaluation matrix of a tender's published bids.
+type BidComparison struct {
+	TenderID string                 `json:"tenderId"`
+	Criteria []*EvaluationCriterion `json:"criteria"`
+	Rows     []*BidComparisonRow    `json:"rows"`
+}
+
+type BidComparisonRow struct {
+	Rank int  `json:"rank"`
+	Bid  *Bid `json:"bid"`
+	// Scores per criterion ID on the 0..10 scale; nil when not scored yet
+	Scores map[string]*float64 `json:"scores"`
+	Total  float64             `json:"total"`
+	// Complete is false while any criterion is missing a score
+	Complete bool `json:"complete"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TenderExportRow is a line of an organization's tender export: the latest
+// version of a tender with the number of its bids and of the bids whose
+// latest decision approved them.
+type TenderExportRow struct {
+	ID                 string
+	Name               string
+	ServiceType        TenderServiceType
+	Status             TenderStatus
+	Visibility         TenderVisibility
+	Version            int
+	CreatorUsername    string
+	CreatedAt          time.Time
+	PublishAt          *time.Time
+	SubmissionDeadline *time.Time
+	DecisionDeadline   *time.Time
+	Budget             decimal.NullDecimal
+	Currency           string
+	BidCount           int64
+	ApprovedBidCount   int64
+}
+
+// BidExportRow is a line of a tender's bid export. The decision columns
+// describe the latest decision; approvals and rejections count all of them.
+type BidExportRow struct {
+	ID         string
+	Name       string
+	Status     BidStatus
+	Version    int
+	AuthorType AuthorType
+	AuthorID   string
+	// Username of the employee or name of the organization
+	AuthorName   string
+	Amount       decimal.NullDecimal
+	Currency     string
+	DeliveryDays int
+	ValidUntil   *time.Time
+	CreatedAt    time.Time
+	Approvals    int64
+	Rejections   int64
+	Decision     BidDecisionType
+	DecidedBy    string
+	DecidedAt    *time.Time
+	Feedback     string
+}
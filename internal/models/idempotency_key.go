@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyKey stores the first response to a create request so that a
+// retry carrying the same Idempotency-Key header can be answered from it.
+// A zero StatusCode marks a request that is still being processed.
+type IdempotencyKey struct {
+	Owner        string    `gorm:"type:varchar(100);primaryKey" json:"owner"`
+	Key          string    `gorm:"type:varchar(255);primaryKey" json:"key"`
+	Method       string    `gorm:"type:varchar(10);not null" json:"method"`
+	Path         string    `gorm:"type:text;not null" json:"path"`
+	RequestHash  string    `gorm:"type:char(64);not null" json:"requestHash"`
+	StatusCode   int       `gorm:"not null;default:0" json:"statusCode"`
+	ContentType  string    `gorm:"type:varchar(100)" json:"contentType"`
+	ResponseBody []byte    `gorm:"type:bytea" json:"-"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expiresAt"`
+}
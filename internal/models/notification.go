@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification is an entry in an employee's in-app inbox. Notifications are
+// created from domain events in the transaction that publishes them.
+type Notification struct {
+	ID          string          `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	RecipientID string          `gorm:"type:uuid;not null;index:idx_notifications_recipient" json:"recipientId"`
+	EventID     string          `gorm:"type:uuid;not null" json:"eventId"`
+	Type        DomainEventType `gorm:"type:varchar(50);not null" json:"type"`
+	TenderID    string          `gorm:"type:uuid;not null" json:"tenderId"`
+	BidID       *string         `gorm:"type:uuid" json:"bidId,omitempty"`
+	Message     string          `gorm:"type:text;not null" json:"message"`
+	ReadAt      *time.Time      `gorm:"index:idx_notifications_recipient" json:"readAt,omitempty"`
+	CreatedAt   time.Time       `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// NotificationPreference turns one event type on or off for an employee.
+// Event types without a stored preference are delivered.
+type NotificationPreference struct {
+	EmployeeID string          `gorm:"type:uuid;primaryKey" json:"-"`
+	EventType  DomainEventType `gorm:"type:varchar(50);primaryKey" json:"eventType" validate:"required"`
+	Enabled    bool            `gorm:"not null" json:"enabled"`
+}
+
+// NotificationInbox is a page of notifications with the total unread count.
+type NotificationInbox struct {
+	UnreadCount   int64           `json:"unreadCount"`
+	Notifications []*Notification `json:"notifications"`
+}
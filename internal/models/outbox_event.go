@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+)
+
+// DomainEventType names the business events published to webhook
+// subscribers.
+type DomainEventType string
+
+const (
+	EventTenderPublished DomainEventType = "tender.published"
+	EventTenderClosed    DomainEventType = "tender.closed"
+	EventBidCreated      DomainEventType = "bid.created"
+	EventBidDecided      DomainEventType = "bid.decided"
+	// Any change of tender or bid status, including the ones above
+	EventTenderStatusChanged DomainEventType = "tender.status_changed"
+	EventBidStatusChanged    DomainEventType = "bid.status_changed"
+	// Feedback left on a bid through SubmitBidFeedback
+	EventBidFeedbackSubmitted DomainEventType = "bid.feedback_submitted"
+	// Review added to a bid by the tender's organization through AddBidReview
+	EventBidReviewed DomainEventType = "bid.reviewed"
+)
+
+var DomainEventTypes = []DomainEventType{
+	EventTenderPublished,
+	EventTenderClosed,
+	EventBidCreated,
+	EventBidDecided,
+	EventBidFeedbackSubmitted,
+	EventTenderStatusChanged,
+	EventBidStatusChanged,
+	EventBidReviewed,
+}
+
+func (t DomainEventType) IsValid() bool {
+	for _, eventType := range DomainEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// OutboxEvent is a domain event written in the same transaction as the
+// change it describes. DispatchedAt is set once the event has been fanned
+// out into webhook deliveries. OrganizationID is the organization whose
+// subscriptions receive the event: the owner of the tender.
+type OutboxEvent struct {
+	ID             string          `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Type           DomainEventType `gorm:"type:varchar(50);not null" json:"type"`
+	OrganizationID string          `gorm:"type:uuid;not null" json:"organizationId"`
+	AggregateID    string          `gorm:"type:uuid;not null" json:"aggregateId"`
+	Data           AuditSnapshot   `gorm:"type:jsonb;not null" json:"data"`
+	CreatedAt      time.Time       `gorm:"autoCreateTime;index" json:"occurredAt"`
+	DispatchedAt   *time.Time      `gorm:"index" json:"-"`
+}
+
+// BidDecidedEvent is the data of a bid.decided event.
+type BidDecidedEvent struct {
+	Bid      *Bid         `json:"bid"`
+	Decision *BidDecision `json:"decision"`
+}
+
+// BidReviewedEvent is the data of a bid.reviewed event.
+type BidReviewedEvent struct {
+	Bid    *Bid       `json:"bid"`
+	Review *BidReview `json:"review"`
+}
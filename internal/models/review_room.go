@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// ReviewParticipant is one connection to a tender's review room. The replica
+// holding the connection keeps extending ExpiresAt; participants of a
+// replica that went away expire.
+type ReviewParticipant struct {
+	ID           string    `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	TenderID     string    `gorm:"type:uuid;not null;index" json:"-"`
+	Username     string    `gorm:"type:varchar(50);not null" json:"username"`
+	EditingBidID *string   `gorm:"type:uuid" json:"editingBidId"`
+	JoinedAt     time.Time `gorm:"autoCreateTime" json:"joinedAt"`
+	ExpiresAt    time.Time `gorm:"not null;index" json:"-"`
+}
+
+// ReviewRoomMessageType names the messages exchanged in a review room.
+type ReviewRoomMessageType string
+
+const (
+	// Sent by participants when they start and stop editing a bid
+	ReviewRoomEditing        ReviewRoomMessageType = "editing"
+	ReviewRoomEditingStopped ReviewRoomMessageType = "editing_stopped"
+	// Sent by the server: the participant's own entry once it joined, the
+	// room's participants whenever they change, a warning while others edit
+	// the same bid, the tender's events and errors in a participant's
+	// message
+	ReviewRoomJoined          ReviewRoomMessageType = "joined"
+	ReviewRoomPresence        ReviewRoomMessageType = "presence"
+	ReviewRoomEditingConflict ReviewRoomMessageType = "editing_conflict"
+	ReviewRoomEvent           ReviewRoomMessageType = "event"
+	ReviewRoomError           ReviewRoomMessageType = "error"
+)
+
+// ReviewRoomMessage is a JSON text frame of a review room connection.
+type ReviewRoomMessage struct {
+	Type         ReviewRoomMessageType `json:"type"`
+	BidID        string                `json:"bidId,omitempty"`
+	Participant  *ReviewParticipant    `json:"participant,omitempty"`
+	Participants []*ReviewParticipant  `json:"participants,omitempty"`
+	// Other participants editing BidID
+	Usernames []string     `json:"usernames,omitempty"`
+	Event     *StreamEvent `json:"event,omitempty"`
+	Message   string       `json:"message,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// StreamEvent is a domain event kept for the live stream of its tender: bid
+// created, bid status changed, bid decided, bid reviewed or tender status
+// changed. IDs
+// grow monotonically, so a reconnecting client resumes after the last ID it
+// received.
+type StreamEvent struct {
+	ID        int64           `gorm:"primaryKey;autoIncrement;index:idx_stream_events_tender,priority:2" json:"id"`
+	TenderID  string          `gorm:"type:uuid;not null;index:idx_stream_events_tender,priority:1" json:"tenderId"`
+	Type      DomainEventType `gorm:"type:varchar(50);not null" json:"type"`
+	Data      AuditSnapshot   `gorm:"type:jsonb;not null" json:"data"`
+	CreatedAt time.Time       `gorm:"autoCreateTime;index" json:"occurredAt"`
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type TenderStatus string
@@ -12,6 +14,16 @@ const (
 	TenderStatusClosed    TenderStatus = "Closed"
 )
 
+// TenderVisibility controls who can see and bid on a tender. InviteOnly
+// tenders are visible to the owning organization and to the organizations
+// invited to them, and accept bids only after the invitation is accepted.
+type TenderVisibility string
+
+const (
+	TenderVisibilityPublic     TenderVisibility = "Public"
+	TenderVisibilityInviteOnly TenderVisibility = "InviteOnly"
+)
+
 type TenderServiceType string
 
 const (
@@ -29,5 +41,14 @@ type Tender struct {
 	OrganizationID  string            `gorm:"type:uuid;not null" json:"organizationId" validate:"required,uuid4"`
 	CreatorUsername string            `gorm:"type:varchar(50);not null" json:"creatorUsername" validate:"required"`
 	Status          TenderStatus      `gorm:"type:varchar(50);default:'Created'" json:"status"`
+	Visibility      TenderVisibility  `gorm:"type:varchar(20);not null;default:'Public'" json:"visibility" validate:"omitempty,oneof=Public InviteOnly"`
 	CreatedAt       time.Time         `gorm:"autoCreateTime" json:"createdAt"`
+	// Bids are accepted until SubmissionDeadline; the tender is closed automatically afterwards
+	SubmissionDeadline *time.Time `gorm:"index" json:"submissionDeadline,omitempty"`
+	DecisionDeadline   *time.Time `json:"decisionDeadline,omitempty"`
+	// A Created tender is published automatically at PublishAt
+	PublishAt *time.Time `gorm:"index" json:"publishAt,omitempty"`
+	// Optional budget; bids above it in the same currency are flagged
+	Budget   decimal.NullDecimal `gorm:"type:numeric(18,2)" json:"budget"`
+	Currency string              `gorm:"type:char(3)" json:"currency,omitempty" validate:"omitempty,iso4217"`
 }
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// TenderImportFormat is the format of a bulk tender import: CSV with a
+// header row naming the columns, or JSON Lines with one tender per line.
+// Both use the field names and formats of POST /tenders/new.
+type TenderImportFormat string
+
+const (
+	TenderImportCSV   TenderImportFormat = "csv"
+	TenderImportJSONL TenderImportFormat = "jsonl"
+)
+
+func (f TenderImportFormat) IsValid() bool {
+	return f == TenderImportCSV || f == TenderImportJSONL
+}
+
+// TenderImportFormatOf tells the format of an import file by its extension.
+func TenderImportFormatOf(fileName string) (TenderImportFormat, bool) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return TenderImportCSV, true
+	case ".jsonl", ".ndjson":
+		return TenderImportJSONL, true
+	}
+	return "", false
+}
+
+// TenderImportMode decides what happens to the valid rows of an import
+// with invalid ones: AllOrNothing creates no tender at all, BestEffort
+// creates every valid tender.
+type TenderImportMode string
+
+const (
+	TenderImportAllOrNothing TenderImportMode = "all_or_nothing"
+	TenderImportBestEffort   TenderImportMode = "best_effort"
+)
+
+func (m TenderImportMode) IsValid() bool {
+	return m == TenderImportAllOrNothing || m == TenderImportBestEffort
+}
+
+// TenderImportRow reports on one row of the file, identified by its line.
+type TenderImportRow struct {
+	Line     int    `json:"line"`
+	TenderID string `json:"tenderId,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// TenderImportResult reports on an import. Valid counts the rows that passed
+// validation; in a dry run nothing is created.
+type TenderImportResult struct {
+	DryRun  bool               `json:"dryRun"`
+	Mode    TenderImportMode   `json:"mode"`
+	Total   int                `json:"total"`
+	Valid   int                `json:"valid"`
+	Created int                `json:"created"`
+	Failed  int                `json:"failed"`
+	Rows    []*TenderImportRow `json:"rows"`
+}
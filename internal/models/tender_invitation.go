@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+type InvitationStatus string
+
+const (
+	InvitationStatusPending  InvitationStatus = "Pending"
+	InvitationStatusAccepted InvitationStatus = "Accepted"
+	InvitationStatusDeclined InvitationStatus = "Declined"
+)
+
+// TenderInvitation grants a supplier organization access to an invite-only
+// tender. Pending and accepted invitations make the tender visible to the
+// organization's employees; bids require an accepted one.
+type TenderInvitation struct {
+	ID             string           `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	TenderID       string           `gorm:"type:uuid;not null;uniqueIndex:idx_tender_invitations_tender_org" json:"tenderId"`
+	OrganizationID string           `gorm:"type:uuid;not null;uniqueIndex:idx_tender_invitations_tender_org;index" json:"organizationId" validate:"required,uuid"`
+	Status         InvitationStatus `gorm:"type:varchar(20);not null;default:'Pending'" json:"status"`
+	InvitedBy      string           `gorm:"type:uuid;not null" json:"invitedBy"`
+	// Employee of the invited organization who accepted or declined
+	RespondedBy *string    `gorm:"type:uuid" json:"respondedBy,omitempty"`
+	RespondedAt *time.Time `json:"respondedAt,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+}
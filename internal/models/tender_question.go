@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// TenderQuestion is a clarification question about a published tender and
+// the organization's answer to it.
+type TenderQuestion struct {
+	ID       string `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	TenderID string `gorm:"type:uuid;not null;index" json:"tenderId"`
+	// Employee who asked; omitted when the question is shown to other bidders
+	AuthorID string `gorm:"type:uuid;not null;index" json:"authorId,omitempty"`
+	Question string `gorm:"type:text;not null" json:"question" validate:"required,max=2000"`
+	Answer   string `gorm:"type:text" json:"answer,omitempty"`
+	// Responsible employee who answered, shown only to the organization
+	AnsweredBy *string    `gorm:"type:uuid" json:"answeredBy,omitempty"`
+	AnsweredAt *time.Time `json:"answeredAt,omitempty"`
+	// Public answers are shown to every bidder without the asker's identity
+	IsPublic  bool      `gorm:"not null;default:false" json:"isPublic"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
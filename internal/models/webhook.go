@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// WebhookSubscription delivers an organization's domain events to URL.
+// An empty EventTypes list subscribes to every event type. Secret signs the
+// deliveries and is only returned when the subscription is created.
+type WebhookSubscription struct {
+	ID             string            `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	OrganizationID string            `gorm:"type:uuid;not null;index" json:"organizationId"`
+	URL            string            `gorm:"type:text;not null" json:"url"`
+	EventTypes     WebhookEventTypes `gorm:"type:jsonb" json:"eventTypes"`
+	Secret         string            `gorm:"type:varchar(100);not null" json:"secret,omitempty"`
+	CreatedBy      string            `gorm:"type:uuid;not null" json:"createdBy"`
+	CreatedAt      time.Time         `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// WebhookEventTypes is stored as a JSON array in webhook_subscriptions.
+type WebhookEventTypes []DomainEventType
+
+func (types WebhookEventTypes) Value() (driver.Value, error) {
+	if types == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(types)
+	return string(data), err
+}
+
+func (types *WebhookEventTypes) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*types = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, types)
+	case string:
+		return json.Unmarshal([]byte(v), types)
+	default:
+		return fmt.Errorf("cannot scan %T into WebhookEventTypes", value)
+	}
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending    WebhookDeliveryStatus = "Pending"
+	WebhookDeliveryDelivered  WebhookDeliveryStatus = "Delivered"
+	WebhookDeliveryDeadLetter WebhookDeliveryStatus = "DeadLetter"
+)
+
+// WebhookDelivery is one event sent to one subscription. Failed attempts
+// are retried with exponential backoff until the attempt limit, after which
+// the delivery is dead-lettered until it is redelivered manually. A claimed
+// delivery is leased to one dispatcher until LockedUntil.
+type WebhookDelivery struct {
+	ID             string                `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	SubscriptionID string                `gorm:"type:uuid;not null;index" json:"subscriptionId"`
+	EventID        string                `gorm:"type:uuid;not null" json:"eventId"`
+	EventType      DomainEventType       `gorm:"type:varchar(50);not null" json:"eventType"`
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(20);not null;index:idx_webhook_deliveries_due" json:"status"`
+	Attempts       int                   `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time             `gorm:"not null;index:idx_webhook_deliveries_due" json:"nextAttemptAt"`
+	LockedUntil    *time.Time            `json:"-"`
+	LastStatusCode int                   `json:"lastStatusCode,omitempty"`
+	LastError      string                `gorm:"type:text" json:"lastError,omitempty"`
+	DeliveredAt    *time.Time            `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time             `gorm:"autoCreateTime" json:"createdAt"`
+}
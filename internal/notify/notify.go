@@ -0,0 +1,21 @@
+// Package notify delivers notifications to employees outside of the
+// application. Channel abstracts the transport; SMTPChannel sends email and
+// the smtptest subpackage runs an in-process SMTP server for tests.
+package notify
+
+import (
+	"context"
+)
+
+// Message is a rendered notification addressed to one recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Channel sends a message. Errors are treated as transient by the caller,
+// which retries the message later.
+type Channel interface {
+	Send(ctx context.Context, message *Message) error
+}
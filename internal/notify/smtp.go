@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPOptions configure SMTPChannel.
+type SMTPOptions struct {
+	Host string
+	Port int
+	// Credentials for AUTH PLAIN, skipped when Username is empty
+	Username string
+	Password string
+	// Sender address, optionally with a display name
+	From string
+	// Limit for a whole SMTP session, zero disables it
+	Timeout time.Duration
+}
+
+// SMTPChannel sends plain text UTF-8 email through an SMTP relay. STARTTLS
+// is used whenever the server offers it.
+type SMTPChannel struct {
+	options SMTPOptions
+	from    *mail.Address
+}
+
+func NewSMTPChannel(options SMTPOptions) (*SMTPChannel, error) {
+	from, err := mail.ParseAddress(options.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP sender address: %w", err)
+	}
+	return &SMTPChannel{options: options, from: from}, nil
+}
+
+func (c *SMTPChannel) Send(ctx context.Context, message *Message) error {
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	if c.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.options.Timeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(c.options.Host, strconv.Itoa(c.options.Port)))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.options.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.options.Host}); err != nil {
+			return err
+		}
+	}
+	if c.options.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.options.Username, c.options.Password, c.options.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(c.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(c.compose(to, message)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// compose builds the RFC 5322 message. Header values are encoded, so names
+// and subjects may contain any text.
+func (c *SMTPChannel) compose(to *mail.Address, message *Message) []byte {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	header("From", c.from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	qp.Write([]byte(message.Body))
+	qp.Close()
+	return buf.Bytes()
+}
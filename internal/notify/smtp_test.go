@@ -0,0 +1,177 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"zadanie-6105/internal/notify/smtptest"
+)
+
+// templateData has the fields of the bid templates.
+type templateData struct {
+	RecipientName string
+	BidName       string
+	TenderName    string
+	Feedback      string
+}
+
+func newTestChannel(t *testing.T, server *smtptest.Server) *SMTPChannel {
+	t.Helper()
+	channel, err := NewSMTPChannel(SMTPOptions{
+		Host:     server.Host(),
+		Port:     server.Port(),
+		Username: "mailer",
+		Password: "secret",
+		From:     "Сервис тендеров <noreply@tenders.example.com>",
+		Timeout:  5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewSMTPChannel() error = %v", err)
+	}
+	return channel
+}
+
+func TestSMTPChannelSendsTemplates(t *testing.T) {
+	data := &templateData{
+		RecipientName: "Иван Петров",
+		BidName:       "Поставка = 100 шт.",
+		TenderName:    "Ремонт офиса",
+		Feedback:      "Срок поставки слишком большой.\nПросим сократить до 10 дней.",
+	}
+
+	tests := []struct {
+		language string
+		template Template
+		subject  string
+		body     []string
+	}{
+		{
+			language: "ru",
+			template: TemplateBidApproved,
+			subject:  "Ваше предложение «Поставка = 100 шт.» одобрено",
+			body:     []string{"Здравствуйте, Иван Петров!", "по тендеру «Ремонт офиса» одобрено."},
+		},
+		{
+			language: "ru",
+			template: TemplateBidRejected,
+			subject:  "Ваше предложение «Поставка = 100 шт.» отклонено",
+			body:     []string{"Здравствуйте, Иван Петров!", "по тендеру «Ремонт офиса» отклонено."},
+		},
+		{
+			language: "ru",
+			template: TemplateBidFeedback,
+			subject:  "Отзыв о вашем предложении «Поставка = 100 шт.»",
+			body:     []string{"оставлен отзыв:", data.Feedback},
+		},
+		{
+			language: "en",
+			template: TemplateBidApproved,
+			subject:  `Your bid "Поставка = 100 шт." was approved`,
+			body:     []string{"Hello, Иван Петров!", `on tender "Ремонт офиса" was approved.`},
+		},
+		{
+			language: "en",
+			template: TemplateBidRejected,
+			subject:  `Your bid "Поставка = 100 шт." was rejected`,
+			body:     []string{"Hello, Иван Петров!", `on tender "Ремонт офиса" was rejected.`},
+		},
+		{
+			language: "en",
+			template: TemplateBidFeedback,
+			subject:  `Feedback on your bid "Поставка = 100 шт."`,
+			body:     []string{"Feedback was left on your bid", data.Feedback, "Tender service"},
+		},
+	}
+
+	server := smtptest.NewServer()
+	defer server.Close()
+	channel := newTestChannel(t, server)
+
+	for i, tt := range tests {
+		t.Run(tt.language+"/"+string(tt.template), func(t *testing.T) {
+			message, err := Render(tt.language, tt.template, data)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			message.To = "Иван Петров <ivan@example.com>"
+			if err := channel.Send(context.Background(), message); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+
+			messages := server.Messages()
+			if len(messages) != i+1 {
+				t.Fatalf("server received %d messages, want %d", len(messages), i+1)
+			}
+			received := messages[i]
+			if received.From != "noreply@tenders.example.com" || len(received.To) != 1 || received.To[0] != "ivan@example.com" {
+				t.Errorf("envelope = %s -> %v, want noreply@tenders.example.com -> [ivan@example.com]", received.From, received.To)
+			}
+
+			subject, body, err := received.Parse()
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if subject != tt.subject {
+				t.Errorf("subject = %q, want %q", subject, tt.subject)
+			}
+			if body != message.Body {
+				t.Errorf("body = %q, want the rendered %q", body, message.Body)
+			}
+			for _, want := range tt.body {
+				if !strings.Contains(body, want) {
+					t.Errorf("body %q does not contain %q", body, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderWithoutRecipientName(t *testing.T) {
+	for language, greeting := range map[string]string{"ru": "Здравствуйте!", "en": "Hello!"} {
+		message, err := Render(language, TemplateBidApproved, &templateData{BidName: "Bid", TenderName: "Tender"})
+		if err != nil {
+			t.Fatalf("Render(%s) error = %v", language, err)
+		}
+		if !strings.HasPrefix(message.Body, greeting+"\n") {
+			t.Errorf("Render(%s) body = %q, want it to start with %q", language, message.Body, greeting)
+		}
+	}
+}
+
+func TestRenderUnknownLanguage(t *testing.T) {
+	if IsSupportedLanguage("de") {
+		t.Fatal("IsSupportedLanguage(de) = true, want false")
+	}
+	if _, err := Render("de", TemplateBidApproved, &templateData{}); err == nil {
+		t.Error("Render(de) error = nil, want an error")
+	}
+}
+
+func TestSMTPChannelTransientFailure(t *testing.T) {
+	server := smtptest.NewServer()
+	defer server.Close()
+	channel := newTestChannel(t, server)
+
+	message := &Message{To: "ivan@example.com", Subject: "Subject", Body: "Body\n"}
+	server.FailNext(1)
+	if err := channel.Send(context.Background(), message); err == nil || !strings.Contains(err.Error(), "451") {
+		t.Fatalf("Send() error = %v, want the 451 of the server", err)
+	}
+	if err := channel.Send(context.Background(), message); err != nil {
+		t.Fatalf("Send() after the failure error = %v", err)
+	}
+	if got := len(server.Messages()); got != 1 {
+		t.Errorf("server received %d messages, want 1", got)
+	}
+}
+
+func TestSMTPChannelInvalidRecipient(t *testing.T) {
+	server := smtptest.NewServer()
+	defer server.Close()
+
+	err := newTestChannel(t, server).Send(context.Background(), &Message{To: "not an address"})
+	if err == nil || !strings.Contains(err.Error(), "invalid recipient address") {
+		t.Errorf("Send() error = %v, want an invalid recipient error", err)
+	}
+}
@@ -0,0 +1,213 @@
+// Package smtptest provides an in-process SMTP server for tests, in the
+// spirit of net/http/httptest. It accepts any sender, recipient and
+// credentials and keeps the received messages in memory.
+package smtptest
+
+import (
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Message is an email received by Server.
+type Message struct {
+	From string
+	To   []string
+	// Data is the message as transmitted, headers included
+	Data []byte
+}
+
+// Parse returns the decoded subject and body of a message composed by
+// notify.SMTPChannel.
+func (m *Message) Parse() (subject, body string, err error) {
+	msg, err := mail.ReadMessage(strings.NewReader(string(m.Data)))
+	if err != nil {
+		return "", "", err
+	}
+	if subject, err = new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); err != nil {
+		return "", "", err
+	}
+
+	reader := msg.Body
+	if strings.EqualFold(msg.Header.Get("Content-Transfer-Encoding"), "quoted-printable") {
+		reader = quotedprintable.NewReader(reader)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", "", err
+	}
+	return subject, strings.ReplaceAll(string(data), "\r\n", "\n"), nil
+}
+
+// Server is a minimal SMTP server listening on a loopback port.
+type Server struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	conns    map[net.Conn]bool
+	messages []*Message
+	failures int
+}
+
+// NewServer starts a server. It must be stopped with Close.
+func NewServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("smtptest: failed to listen: " + err.Error())
+	}
+
+	s := &Server{listener: listener, conns: make(map[net.Conn]bool)}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Host and Port are the address to pass to notify.SMTPOptions.
+func (s *Server) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Messages returns the messages accepted so far.
+func (s *Server) Messages() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Message(nil), s.messages...)
+}
+
+// FailNext makes the server reject the next n messages with a transient
+// error, to exercise retries.
+func (s *Server) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+}
+
+// Close stops the server, closing open sessions.
+func (s *Server) Close() {
+	s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(textproto.NewConn(conn))
+
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+func (s *Server) handle(conn *textproto.Conn) {
+	reply := func(code int, text string) error {
+		return conn.PrintfLine("%d %s", code, text)
+	}
+
+	if reply(220, "smtptest ESMTP ready") != nil {
+		return
+	}
+
+	var message *Message
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			err = conn.PrintfLine("250-smtptest\r\n250-8BITMIME\r\n250 AUTH PLAIN")
+		case "HELO", "NOOP":
+			err = reply(250, "OK")
+		case "AUTH":
+			err = reply(235, "Authentication successful")
+		case "MAIL":
+			message = &Message{From: trimPath(arg, "FROM:")}
+			err = reply(250, "OK")
+		case "RCPT":
+			if message == nil {
+				err = reply(503, "MAIL first")
+				break
+			}
+			message.To = append(message.To, trimPath(arg, "TO:"))
+			err = reply(250, "OK")
+		case "DATA":
+			if message == nil || len(message.To) == 0 {
+				err = reply(503, "RCPT first")
+				break
+			}
+			if err = reply(354, "End data with <CR><LF>.<CR><LF>"); err != nil {
+				break
+			}
+			if message.Data, err = io.ReadAll(conn.DotReader()); err != nil {
+				break
+			}
+			err = s.accept(message, reply)
+			message = nil
+		case "RSET":
+			message = nil
+			err = reply(250, "OK")
+		case "QUIT":
+			reply(221, "Bye")
+			return
+		default:
+			err = reply(502, "Command not implemented")
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) accept(message *Message, reply func(int, string) error) error {
+	s.mu.Lock()
+	if s.failures > 0 {
+		s.failures--
+		s.mu.Unlock()
+		return reply(451, "Temporary failure, try again later")
+	}
+	s.messages = append(s.messages, message)
+	id := len(s.messages)
+	s.mu.Unlock()
+	return reply(250, "Queued as "+strconv.Itoa(id))
+}
+
+// trimPath extracts the address from "FROM:<address> [params]".
+func trimPath(arg, prefix string) string {
+	if len(arg) >= len(prefix) && strings.EqualFold(arg[:len(prefix)], prefix) {
+		arg = arg[len(prefix):]
+	}
+	arg, _, _ = strings.Cut(strings.TrimSpace(arg), " ")
+	return strings.Trim(arg, "<>")
+}
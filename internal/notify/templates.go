@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Template names a message template. Every template is available in every
+// language and defines a "subject" and a "body".
+type Template string
+
+const (
+	TemplateBidApproved Template = "bid_approved"
+	TemplateBidRejected Template = "bid_rejected"
+	TemplateBidFeedback Template = "bid_feedback"
+)
+
+// Languages the templates are translated to.
+var Languages = []string{"ru", "en"}
+
+var templateNames = []Template{
+	TemplateBidApproved,
+	TemplateBidRejected,
+	TemplateBidFeedback,
+}
+
+//go:embed templates
+var templateFiles embed.FS
+
+// templates are parsed file by file, since every file defines the same
+// "subject" and "body" names.
+var templates = parseTemplates()
+
+func parseTemplates() map[string]map[Template]*template.Template {
+	sets := make(map[string]map[Template]*template.Template, len(Languages))
+	for _, language := range Languages {
+		sets[language] = make(map[Template]*template.Template, len(templateNames))
+		for _, name := range templateNames {
+			path := fmt.Sprintf("templates/%s/%s.tmpl", language, name)
+			sets[language][name] = template.Must(template.ParseFS(templateFiles, path))
+		}
+	}
+	return sets
+}
+
+// IsSupportedLanguage reports whether templates exist for language.
+func IsSupportedLanguage(language string) bool {
+	_, ok := templates[language]
+	return ok
+}
+
+// Render executes the template in language with data and returns the
+// message without a recipient.
+func Render(language string, name Template, data interface{}) (*Message, error) {
+	tmpl, ok := templates[language][name]
+	if !ok {
+		return nil, fmt.Errorf("no %q notification template for language %q", name, language)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimSpace(body.String()) + "\n",
+	}, nil
+}
@@ -0,0 +1,316 @@
+// Package pdf lays out simple text documents, such as reports and
+// protocols, as A4 PDF files. It embeds the Go fonts so that Latin and
+// Cyrillic text renders the same everywhere without any installed fonts.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Page geometry in points
+const (
+	pageWidth    = 595.28
+	pageHeight   = 841.89
+	margin       = 50.0
+	contentWidth = pageWidth - 2*margin
+	footerY      = 28.0
+)
+
+// Text sizes in points; lines are spaced at lineSpacing times the size
+const (
+	titleSize   = 15.0
+	headingSize = 11.5
+	textSize    = 9.5
+	tableSize   = 8.0
+	footerSize  = 7.5
+	lineSpacing = 1.35
+	cellPadding = 3.0
+	// Width of the label column of fields
+	labelWidth = 150.0
+)
+
+const (
+	regular = iota
+	bold
+)
+
+// Column is a column of a table, its width a share of the page width.
+type Column struct {
+	Title string
+	Width float64
+}
+
+// Document collects the content of a PDF file, starting a new page
+// whenever the next block does not fit on the current one.
+type Document struct {
+	// Footer, if set, returns the text printed at the bottom of each page.
+	Footer func(page, pages int) string
+
+	title   string
+	created time.Time
+	fonts   [2]*documentFont
+	pages   []*bytes.Buffer
+	page    *bytes.Buffer
+	// Top of the free space on the current page
+	y float64
+}
+
+func New(title string, created time.Time) (*Document, error) {
+	faces, err := loadFaces()
+	if err != nil {
+		return nil, err
+	}
+	d := &Document{title: title, created: created}
+	for i, face := range faces {
+		d.fonts[i] = newDocumentFont(face)
+	}
+	d.newPage()
+	return d, nil
+}
+
+func (d *Document) newPage() {
+	d.page = new(bytes.Buffer)
+	d.pages = append(d.pages, d.page)
+	d.y = pageHeight - margin
+}
+
+// ensure starts a new page unless height points fit on the current one.
+func (d *Document) ensure(height float64) {
+	if d.y-height < margin && d.y < pageHeight-margin {
+		d.newPage()
+	}
+}
+
+// Space leaves vertical space, unless at the top of a page.
+func (d *Document) Space(height float64) {
+	if d.y < pageHeight-margin {
+		d.y -= height
+	}
+}
+
+// Title writes the centered title of the document.
+func (d *Document) Title(text string) {
+	lines := d.wrap(text, bold, titleSize, contentWidth)
+	d.ensure(float64(len(lines)) * titleSize * lineSpacing)
+	for _, line := range lines {
+		x := margin + (contentWidth-d.fonts[bold].width(line, titleSize))/2
+		d.line(bold, titleSize, x, line)
+	}
+	d.Space(titleSize * 0.6)
+}
+
+// Heading starts a section, kept on the page with the lines after it.
+func (d *Document) Heading(text string) {
+	d.Space(headingSize * 0.8)
+	lines := d.wrap(text, bold, headingSize, contentWidth)
+	d.ensure(float64(len(lines))*headingSize*lineSpacing + 3*textSize*lineSpacing)
+	for _, line := range lines {
+		d.line(bold, headingSize, margin, line)
+	}
+	d.Space(headingSize * 0.3)
+}
+
+// Paragraph writes text wrapped to the page width; line breaks are kept.
+func (d *Document) Paragraph(text string) {
+	for _, line := range d.wrap(text, regular, textSize, contentWidth) {
+		d.ensure(textSize * lineSpacing)
+		d.line(regular, textSize, margin, line)
+	}
+	d.Space(textSize * 0.4)
+}
+
+// Field writes a bold label with its value wrapped beside it.
+func (d *Document) Field(label, value string) {
+	labels := d.wrap(label, bold, textSize, labelWidth-cellPadding)
+	values := d.wrap(value, regular, textSize, contentWidth-labelWidth)
+	for i := 0; i < len(labels) || i < len(values); i++ {
+		d.ensure(textSize * lineSpacing)
+		top := d.y
+		if i < len(labels) {
+			d.line(bold, textSize, margin, labels[i])
+		}
+		if i < len(values) {
+			d.y = top
+			d.line(regular, textSize, margin+labelWidth, values[i])
+		}
+	}
+}
+
+// Table writes rows of cells under a header row, which is repeated on
+// every page the table continues on. Cells taller than a page are cut.
+func (d *Document) Table(columns []Column, rows [][]string) {
+	widths := make([]float64, len(columns))
+	titles := make([]string, len(columns))
+	for i, column := range columns {
+		widths[i] = column.Width * contentWidth
+		titles[i] = column.Title
+	}
+
+	lineHeight := tableSize * lineSpacing
+	maxLines := int((pageHeight - 2*margin - 2*cellPadding) / lineHeight / 2)
+
+	layout := func(cells []string, font int) ([][]string, float64) {
+		wrapped := make([][]string, len(columns))
+		lines := 1
+		for i := range columns {
+			var cell string
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			wrapped[i] = d.wrap(cell, font, tableSize, widths[i]-2*cellPadding)
+			if len(wrapped[i]) > maxLines {
+				wrapped[i] = wrapped[i][:maxLines]
+				wrapped[i][maxLines-1] += "…"
+			}
+			if len(wrapped[i]) > lines {
+				lines = len(wrapped[i])
+			}
+		}
+		return wrapped, float64(lines)*lineHeight + 2*cellPadding
+	}
+
+	header, headerHeight := layout(titles, bold)
+	drawHeader := func() {
+		d.row(widths, header, headerHeight, bold, true)
+	}
+
+	d.ensure(headerHeight + lineHeight + 2*cellPadding)
+	drawHeader()
+	for _, cells := range rows {
+		wrapped, height := layout(cells, regular)
+		if d.y-height < margin {
+			d.newPage()
+			drawHeader()
+		}
+		d.row(widths, wrapped, height, regular, false)
+	}
+	d.Space(textSize * 0.6)
+}
+
+func (d *Document) row(widths []float64, cells [][]string, height float64, font int, shaded bool) {
+	top := d.y
+	x := margin
+	for i, width := range widths {
+		if shaded {
+			fmt.Fprintf(d.page, "0.9 g %.2f %.2f %.2f %.2f re f 0 g\n", x, top-height, width, height)
+		}
+		fmt.Fprintf(d.page, "0.5 w %.2f %.2f %.2f %.2f re S\n", x, top-height, width, height)
+
+		d.y = top - cellPadding
+		for _, line := range cells[i] {
+			d.line(font, tableSize, x+cellPadding, line)
+		}
+		x += width
+	}
+	d.y = top - height
+}
+
+// line writes a line of text at the top of the free space and moves down.
+func (d *Document) line(font int, size, x float64, text string) {
+	baseline := d.y - size
+	d.text(font, size, x, baseline, text)
+	d.y -= size * lineSpacing
+}
+
+func (d *Document) text(font int, size, x, baseline float64, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(d.page, "BT /F%d %.2f Tf %.2f %.2f Td %s Tj ET\n", font+1, size, x, baseline, d.fonts[font].encode(text))
+}
+
+// wrap breaks text into lines no wider than width, breaking at spaces and,
+// for words that are too long, between characters.
+func (d *Document) wrap(text string, font int, size, width float64) []string {
+	f := d.fonts[font]
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		paragraph = strings.Map(func(r rune) rune {
+			if r == '\t' {
+				return ' '
+			}
+			if unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, paragraph)
+
+		var line string
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if f.width(candidate, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+
+			// Split words that do not fit on a line of their own
+			line = ""
+			for _, r := range word {
+				if line != "" && f.width(line+string(r), size) > width {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// WriteTo writes the PDF file.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if d.Footer != nil {
+		for i, page := range d.pages {
+			d.page = page
+			footer := d.Footer(i+1, len(d.pages))
+			x := margin + contentWidth - d.fonts[regular].width(footer, footerSize)
+			d.text(regular, footerSize, math.Max(x, margin), footerY, footer)
+		}
+	}
+
+	var out objectWriter
+	out.buf.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+
+	catalogObj, pagesObj, infoObj := out.reserve(), out.reserve(), out.reserve()
+
+	var fontRefs strings.Builder
+	for i, font := range d.fonts {
+		n, err := out.writeFont(font)
+		if err != nil {
+			return 0, err
+		}
+		fmt.Fprintf(&fontRefs, "/F%d %d 0 R ", i+1, n)
+	}
+
+	kids := make([]string, len(d.pages))
+	for i, page := range d.pages {
+		pageObj, contentObj := out.reserve(), out.reserve()
+		out.object(pageObj, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << %s>> >> /Contents %d 0 R >>",
+			pagesObj, pageWidth, pageHeight, fontRefs.String(), contentObj))
+		if err := out.stream(contentObj, "", page.Bytes()); err != nil {
+			return 0, err
+		}
+		kids[i] = fmt.Sprintf("%d 0 R", pageObj)
+	}
+
+	out.object(catalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+	out.object(pagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	out.object(infoObj, fmt.Sprintf("<< /Title %s /Producer (zadanie-6105) /CreationDate %s >>", textString(d.title), dateString(d.created)))
+	out.trailer(catalogObj, infoObj)
+
+	n, err := w.Write(out.buf.Bytes())
+	return int64(n), err
+}
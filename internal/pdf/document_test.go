@@ -0,0 +1,268 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// pdfObject is an object of a parsed file: its dictionary or other body and
+// the inflated data of a stream object.
+type pdfObject struct {
+	body   string
+	stream []byte
+}
+
+var (
+	startxrefPattern = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	lengthPattern    = regexp.MustCompile(`/Length (\d+) `)
+	refPattern       = regexp.MustCompile(`/(F\d|ToUnicode) (\d+) 0 R`)
+	textPattern      = regexp.MustCompile(`/F(\d) [\d.]+ Tf [\d.]+ [\d.]+ Td <([0-9A-F]*)> Tj`)
+	bfcharPattern    = regexp.MustCompile(`<([0-9A-F]{4})> <([0-9A-F]+)>`)
+)
+
+// parsePDF checks the structure of the file: the header, the trailer and
+// that every cross-reference entry points at its object. It returns the
+// objects by number and the number of the catalog.
+func parsePDF(t *testing.T, data []byte) (map[int]*pdfObject, int) {
+	t.Helper()
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.7\n")) {
+		t.Fatalf("file starts with %q, want the PDF header", data[:min(len(data), 9)])
+	}
+	match := startxrefPattern.FindSubmatch(data)
+	if match == nil {
+		t.Fatal("file does not end with startxref and the end-of-file marker")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if xref >= len(data) || !bytes.HasPrefix(data[xref:], []byte("xref\n0 ")) {
+		t.Fatalf("startxref %d does not point at the cross-reference table", xref)
+	}
+
+	lines := strings.Split(string(data[xref:]), "\n")
+	size, err := strconv.Atoi(strings.TrimPrefix(lines[1], "0 "))
+	if err != nil {
+		t.Fatalf("invalid cross-reference subsection %q", lines[1])
+	}
+	if lines[2] != "0000000000 65535 f " {
+		t.Errorf("first cross-reference entry = %q, want the free entry", lines[2])
+	}
+	if lines[size+2] != "trailer" {
+		t.Fatalf("line after %d cross-reference entries = %q, want trailer", size, lines[size+2])
+	}
+	var root, info int
+	trailer := fmt.Sprintf("<< /Size %d /Root %%d 0 R /Info %%d 0 R >>", size)
+	if _, err := fmt.Sscanf(lines[size+3], trailer, &root, &info); err != nil {
+		t.Fatalf("trailer %q: %v", lines[size+3], err)
+	}
+
+	objects := make(map[int]*pdfObject)
+	for n := 1; n < size; n++ {
+		entry := lines[n+2]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("cross-reference entry %d = %q", n, entry)
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		objects[n] = readObject(t, data, n, offset)
+	}
+	if !strings.HasPrefix(objects[root].body, "<< /Type /Catalog ") {
+		t.Errorf("root object = %q, want the catalog", objects[root].body)
+	}
+	if !strings.HasPrefix(objects[info].body, "<< /Title ") {
+		t.Errorf("info object = %q, want the document information", objects[info].body)
+	}
+	return objects, root
+}
+
+func readObject(t *testing.T, data []byte, n, offset int) *pdfObject {
+	t.Helper()
+
+	prefix := fmt.Sprintf("%d 0 obj\n", n)
+	if offset >= len(data) || !bytes.HasPrefix(data[offset:], []byte(prefix)) {
+		t.Fatalf("offset %d of object %d does not point at %q", offset, n, prefix)
+	}
+	rest := data[offset+len(prefix):]
+	end := bytes.IndexByte(rest, '\n')
+	object := &pdfObject{body: string(rest[:end])}
+	rest = rest[end+1:]
+
+	if !bytes.HasPrefix(rest, []byte("stream\n")) {
+		if !bytes.HasPrefix(rest, []byte("endobj\n")) {
+			t.Fatalf("object %d is not followed by endobj", n)
+		}
+		return object
+	}
+
+	match := lengthPattern.FindStringSubmatch(object.body)
+	if match == nil {
+		t.Fatalf("stream object %d has no length", n)
+	}
+	length, _ := strconv.Atoi(match[1])
+	rest = rest[len("stream\n"):]
+	if length > len(rest) || !bytes.HasPrefix(rest[length:], []byte("\nendstream\nendobj\n")) {
+		t.Fatalf("stream object %d is not %d bytes long", n, length)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(rest[:length]))
+	if err != nil {
+		t.Fatalf("inflating object %d: %v", n, err)
+	}
+	if object.stream, err = io.ReadAll(zr); err != nil {
+		t.Fatalf("inflating object %d: %v", n, err)
+	}
+	return object
+}
+
+// parseToUnicode returns the characters of the glyphs a ToUnicode CMap maps.
+func parseToUnicode(t *testing.T, cmap []byte) map[string]rune {
+	t.Helper()
+
+	chars := make(map[string]rune)
+	for _, match := range bfcharPattern.FindAllSubmatch(cmap, -1) {
+		var units []uint16
+		for i := 0; i+4 <= len(match[2]); i += 4 {
+			unit, err := strconv.ParseUint(string(match[2][i:i+4]), 16, 16)
+			if err != nil {
+				t.Fatalf("invalid character %s in the CMap", match[2])
+			}
+			units = append(units, uint16(unit))
+		}
+		runes := utf16.Decode(units)
+		if len(runes) != 1 {
+			t.Fatalf("glyph %s maps to %q, want one character", match[1], string(runes))
+		}
+		chars[string(match[1])] = runes[0]
+	}
+	return chars
+}
+
+// pageTexts returns the text of every page, decoded through the ToUnicode
+// CMaps of its fonts, and the characters mapped by all CMaps.
+func pageTexts(t *testing.T, objects map[int]*pdfObject, root int) ([]string, map[rune]bool) {
+	t.Helper()
+
+	var pagesObj int
+	fmt.Sscanf(objects[root].body, "<< /Type /Catalog /Pages %d 0 R >>", &pagesObj)
+	pages := objects[pagesObj]
+	if pages == nil || !strings.HasPrefix(pages.body, "<< /Type /Pages /Kids [") {
+		t.Fatalf("catalog does not point at the page tree")
+	}
+
+	var kids []int
+	for _, ref := range regexp.MustCompile(`(\d+) 0 R`).FindAllStringSubmatch(pages.body, -1) {
+		n, _ := strconv.Atoi(ref[1])
+		kids = append(kids, n)
+	}
+	if !strings.HasSuffix(pages.body, fmt.Sprintf("/Count %d >>", len(kids))) {
+		t.Errorf("page tree %q does not count its %d kids", pages.body, len(kids))
+	}
+
+	mapped := make(map[rune]bool)
+	var texts []string
+	for _, kid := range kids {
+		page := objects[kid]
+		if page == nil || !strings.HasPrefix(page.body, "<< /Type /Page ") {
+			t.Fatalf("kid %d is not a page", kid)
+		}
+
+		cmaps := make(map[string]map[string]rune)
+		for _, ref := range refPattern.FindAllStringSubmatch(page.body, -1) {
+			n, _ := strconv.Atoi(ref[2])
+			unicodeRef := refPattern.FindAllStringSubmatch(objects[n].body, -1)
+			if len(unicodeRef) != 1 || unicodeRef[0][1] != "ToUnicode" {
+				t.Fatalf("font %s has no ToUnicode CMap", ref[1])
+			}
+			unicodeObj, _ := strconv.Atoi(unicodeRef[0][2])
+			cmaps[ref[1][1:]] = parseToUnicode(t, objects[unicodeObj].stream)
+			for _, r := range cmaps[ref[1][1:]] {
+				mapped[r] = true
+			}
+		}
+
+		var contentObj int
+		fmt.Sscanf(page.body[strings.Index(page.body, "/Contents "):], "/Contents %d 0 R", &contentObj)
+		var text strings.Builder
+		for _, show := range textPattern.FindAllStringSubmatch(string(objects[contentObj].stream), -1) {
+			cmap := cmaps[show[1]]
+			for i := 0; i+4 <= len(show[2]); i += 4 {
+				r, ok := cmap[show[2][i:i+4]]
+				if !ok {
+					t.Fatalf("glyph %s of font F%s is not in its CMap", show[2][i:i+4], show[1])
+				}
+				text.WriteRune(r)
+			}
+			text.WriteByte('\n')
+		}
+		texts = append(texts, text.String())
+	}
+	return texts, mapped
+}
+
+func TestDocument(t *testing.T) {
+	title := "Протокол подведения итогов тендера «Ремонт офиса»"
+	doc, err := New(title, time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	doc.Footer = func(page, pages int) string {
+		return fmt.Sprintf("Страница %d из %d", page, pages)
+	}
+
+	doc.Title(title)
+	doc.Heading("Сведения о тендере")
+	doc.Field("Организация", "ООО «Ромашка»")
+	doc.Paragraph("Описание в две строки.\nВторая строка, ёжик — 100 ₽.")
+
+	rows := make([][]string, 60)
+	for i := range rows {
+		rows[i] = []string{strconv.Itoa(i + 1), "Предложение №" + strconv.Itoa(i+1), "Одобрено"}
+	}
+	doc.Table([]Column{{"№", 0.1}, {"Предложение", 0.6}, {"Решение", 0.3}}, rows)
+
+	var buf bytes.Buffer
+	n, err := doc.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo() = %d, wrote %d bytes", n, buf.Len())
+	}
+
+	objects, root := parsePDF(t, buf.Bytes())
+	texts, mapped := pageTexts(t, objects, root)
+	if len(texts) < 2 {
+		t.Fatalf("document has %d pages, want the table to continue on another page", len(texts))
+	}
+
+	all := strings.Join(texts, "")
+	for _, want := range []string{"ООО «Ромашка»", "Вторая строка, ёжик — 100", "Предложение №1\n", "Предложение №60\n"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("document text does not contain %q", want)
+		}
+	}
+	for i, text := range texts {
+		if !strings.Contains(text, fmt.Sprintf("Страница %d из %d", i+1, len(texts))) {
+			t.Errorf("page %d has no footer", i+1)
+		}
+		// The table header is repeated on every page it continues on
+		if i > 0 && !strings.Contains(text, "Предложение\nРешение\n") {
+			t.Errorf("page %d does not repeat the table header", i+1)
+		}
+	}
+
+	// The Go fonts have no ruble sign, which falls back to a question mark
+	if !strings.Contains(all, "100 ?") {
+		t.Errorf("document text does not show the missing glyph as a question mark")
+	}
+	for _, r := range title + "ООО Ромашка ёжик — №0123456789" {
+		if r != ' ' && !mapped[r] {
+			t.Errorf("no glyph is mapped to %q", r)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package pdf
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// fontFace is a parsed TrueType font with the metrics of its PDF font
+// descriptor in thousandths of an em. It is shared by all documents.
+type fontFace struct {
+	name      string
+	data      []byte
+	font      *sfnt.Font
+	ppem      fixed.Int26_6
+	unitsEm   float64
+	ascent    float64
+	descent   float64
+	capHeight float64
+	bbox      [4]float64
+}
+
+var (
+	facesOnce sync.Once
+	faces     [2]*fontFace
+	facesErr  error
+)
+
+// loadFaces parses the Go fonts, which cover Latin, Cyrillic and Greek.
+func loadFaces() ([2]*fontFace, error) {
+	facesOnce.Do(func() {
+		if faces[0], facesErr = parseFace("GoRegular", goregular.TTF); facesErr != nil {
+			return
+		}
+		faces[1], facesErr = parseFace("GoBold", gobold.TTF)
+	})
+	return faces, facesErr
+}
+
+func parseFace(name string, data []byte) (*fontFace, error) {
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse font %s: %w", name, err)
+	}
+
+	// At a size of one unit per em, metrics come out in font units
+	face := &fontFace{name: name, data: data, font: f, ppem: fixed.Int26_6(f.UnitsPerEm()), unitsEm: float64(f.UnitsPerEm())}
+
+	var buf sfnt.Buffer
+	metrics, err := f.Metrics(&buf, face.ppem, font.HintingNone)
+	if err != nil {
+		return nil, fmt.Errorf("font %s metrics: %w", name, err)
+	}
+	bounds, err := f.Bounds(&buf, face.ppem, font.HintingNone)
+	if err != nil {
+		return nil, fmt.Errorf("font %s bounds: %w", name, err)
+	}
+
+	face.ascent = face.scale(metrics.Ascent)
+	face.descent = -face.scale(metrics.Descent)
+	face.capHeight = face.scale(metrics.CapHeight)
+	// The Y axis of sfnt points down, that of PDF up
+	face.bbox = [4]float64{face.scale(bounds.Min.X), -face.scale(bounds.Max.Y), face.scale(bounds.Max.X), -face.scale(bounds.Min.Y)}
+	return face, nil
+}
+
+func (f *fontFace) scale(v fixed.Int26_6) float64 {
+	return float64(v) * 1000 / f.unitsEm
+}
+
+type glyph struct {
+	index sfnt.GlyphIndex
+	// Character the glyph shows
+	char rune
+	// Advance in thousandths of an em
+	width float64
+}
+
+// documentFont is a font as used by one document: it remembers the glyphs
+// shown, whose widths and characters the PDF has to list.
+type documentFont struct {
+	face   *fontFace
+	buf    sfnt.Buffer
+	glyphs map[rune]glyph
+	used   map[sfnt.GlyphIndex]rune
+}
+
+func newDocumentFont(face *fontFace) *documentFont {
+	return &documentFont{face: face, glyphs: make(map[rune]glyph), used: make(map[sfnt.GlyphIndex]rune)}
+}
+
+// glyph looks up the glyph of a character, falling back to a question mark
+// for characters the font lacks.
+func (f *documentFont) glyph(r rune) glyph {
+	if g, ok := f.glyphs[r]; ok {
+		return g
+	}
+
+	index, err := f.face.font.GlyphIndex(&f.buf, r)
+	if (err != nil || index == 0) && r != '?' {
+		g := f.glyph('?')
+		f.glyphs[r] = g
+		return g
+	}
+
+	g := glyph{index: index, char: r}
+	if advance, err := f.face.font.GlyphAdvance(&f.buf, index, f.face.ppem, font.HintingNone); err == nil {
+		g.width = f.face.scale(advance)
+	}
+	f.glyphs[r] = g
+	return g
+}
+
+// width returns the width of the text at the size in points.
+func (f *documentFont) width(text string, size float64) float64 {
+	var width float64
+	for _, r := range text {
+		width += f.glyph(r).width
+	}
+	return width * size / 1000
+}
+
+// encode returns the text as the hexadecimal string of its glyph indexes,
+// which is how Identity-H encoded fonts are shown.
+func (f *documentFont) encode(text string) string {
+	encoded := make([]byte, 0, 2+4*len(text))
+	encoded = append(encoded, '<')
+	for _, r := range text {
+		g := f.glyph(r)
+		f.used[g.index] = g.char
+		encoded = append(encoded, fmt.Sprintf("%04X", uint16(g.index))...)
+	}
+	return string(append(encoded, '>'))
+}
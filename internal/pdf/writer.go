@@ -0,0 +1,140 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// objectWriter writes the numbered objects of a PDF file and the
+// cross-reference table locating them.
+type objectWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+// reserve allocates the number of an object written later.
+func (w *objectWriter) reserve() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets)
+}
+
+func (w *objectWriter) object(n int, body string) {
+	w.offsets[n-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", n, body)
+}
+
+// stream writes a compressed stream object; dict holds the entries besides
+// its length and filter.
+func (w *objectWriter) stream(n int, dict string, data []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	w.offsets[n-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s /Length %d /Filter /FlateDecode >>\nstream\n", n, dict, compressed.Len())
+	w.buf.Write(compressed.Bytes())
+	w.buf.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+func (w *objectWriter) trailer(root, info int) {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, root, info, xref)
+}
+
+// writeFont writes a font as a Type 0 font over a CIDFontType2 font with
+// the whole TrueType file embedded, and returns the number of the font
+// object. Only the widths and characters of the glyphs shown are listed.
+func (w *objectWriter) writeFont(f *documentFont) (int, error) {
+	fontObj, cidObj, descriptorObj, fileObj, unicodeObj := w.reserve(), w.reserve(), w.reserve(), w.reserve(), w.reserve()
+	face := f.face
+
+	indexes := make([]sfnt.GlyphIndex, 0, len(f.used))
+	for index := range f.used {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+	widths := make(map[sfnt.GlyphIndex]float64, len(f.glyphs))
+	for _, g := range f.glyphs {
+		widths[g.index] = g.width
+	}
+	var widthList strings.Builder
+	for _, index := range indexes {
+		fmt.Fprintf(&widthList, "%d [%.0f] ", index, widths[index])
+	}
+
+	w.object(fontObj, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		face.name, cidObj, unicodeObj))
+	w.object(cidObj, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /DW 1000 /W [%s] >>",
+		face.name, descriptorObj, widthList.String()))
+	w.object(descriptorObj, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%.0f %.0f %.0f %.0f] /ItalicAngle 0 /Ascent %.0f /Descent %.0f /CapHeight %.0f /StemV 80 /FontFile2 %d 0 R >>",
+		face.name, face.bbox[0], face.bbox[1], face.bbox[2], face.bbox[3], face.ascent, face.descent, face.capHeight, fileObj))
+	if err := w.stream(fileObj, fmt.Sprintf("/Length1 %d", len(face.data)), face.data); err != nil {
+		return 0, err
+	}
+	if err := w.stream(unicodeObj, "", toUnicodeCMap(indexes, f.used)); err != nil {
+		return 0, err
+	}
+	return fontObj, nil
+}
+
+// toUnicodeCMap maps the glyphs shown back to their characters, so that
+// text can be searched and copied.
+func toUnicodeCMap(indexes []sfnt.GlyphIndex, chars map[sfnt.GlyphIndex]rune) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	// A bfchar section holds at most 100 entries
+	for start := 0; start < len(indexes); start += 100 {
+		end := start + 100
+		if end > len(indexes) {
+			end = len(indexes)
+		}
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, index := range indexes[start:end] {
+			fmt.Fprintf(&b, "<%04X> <%s>\n", uint16(index), utf16Hex(string(chars[index])))
+		}
+		b.WriteString("endbfchar\n")
+	}
+
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+func utf16Hex(s string) string {
+	var b strings.Builder
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	return b.String()
+}
+
+// textString encodes text outside of content streams, such as the document
+// title, as UTF-16 with a byte order mark.
+func textString(s string) string {
+	return "<FEFF" + utf16Hex(s) + ">"
+}
+
+func dateString(t time.Time) string {
+	return "(D:" + t.UTC().Format("20060102150405") + "Z)"
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+
+	database "zadanie-6105/internal/db"
+
+	"gorm.io/gorm"
+)
+
+// Advisory lock keys of the background jobs, one per job kind, and of the
+// audit hash chain head
+const (
+	TenderSchedulerLockID int64 = 6105001
+	AuditChainLockID      int64 = 6105002
+	AuditDigestLockID     int64 = 6105003
+)
+
+// JobLocker elects a single replica to run a background job.
+type JobLocker interface {
+	// RunExclusive runs fn in a transaction holding the Postgres advisory
+	// lock lockID and reports whether the lock was acquired. Repository calls
+	// made with the ctx passed to fn join that transaction.
+	RunExclusive(ctx context.Context, lockID int64, fn func(ctx context.Context) error) (bool, error)
+}
+
+type jobLocker struct {
+	db *gorm.DB
+}
+
+func NewJobLocker(resolver *database.Resolver) JobLocker {
+	return &jobLocker{db: resolver.Primary()}
+}
+
+func (l *jobLocker) RunExclusive(ctx context.Context, lockID int64, fn func(ctx context.Context) error) (bool, error) {
+	acquired := false
+
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The transaction-level lock is released on commit or rollback
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", lockID).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if !acquired {
+			return nil
+		}
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+
+	return acquired, err
+}
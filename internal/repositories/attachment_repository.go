@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository interface {
+	CreateAttachment(ctx context.Context, attachment *models.Attachment) error
+	GetAttachmentByID(ctx context.Context, id string) (*models.Attachment, error)
+	GetAttachments(ctx context.Context, ownerType models.AttachmentOwnerType, ownerID string) ([]*models.Attachment, error)
+	DeleteAttachment(ctx context.Context, id string) error
+}
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+func (r *attachmentRepository) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	return conn(ctx, r.db).Create(attachment).Error
+}
+
+func (r *attachmentRepository) GetAttachmentByID(ctx context.Context, id string) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := conn(ctx, r.db).First(&attachment, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *attachmentRepository) GetAttachments(ctx context.Context, ownerType models.AttachmentOwnerType, ownerID string) ([]*models.Attachment, error) {
+	var attachments []*models.Attachment
+	err := conn(ctx, r.db).
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Order("created_at").
+		Find(&attachments).Error
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (r *attachmentRepository) DeleteAttachment(ctx context.Context, id string) error {
+	return conn(ctx, r.db).Delete(&models.Attachment{}, "id = ?", id).Error
+}
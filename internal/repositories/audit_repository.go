@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepository only appends and reads; updates and deletes are also
+// rejected by a trigger on audit_events.
+type AuditRepository interface {
+	// LockChainHead serializes appends to the hash chain until the end of the
+	// transaction and returns the hash of the last event along with the
+	// database clock, which orders events consistently across replicas.
+	LockChainHead(ctx context.Context) (string, time.Time, error)
+	CreateEvent(ctx context.Context, event *models.AuditEvent) error
+	GetEvents(ctx context.Context, filter models.AuditFilter, limit, offset int) ([]*models.AuditEvent, error)
+	GetEventByID(ctx context.Context, id int64) (*models.AuditEvent, error)
+	// GetEventsAfter returns events with IDs above afterID in chain order.
+	GetEventsAfter(ctx context.Context, afterID int64, limit int) ([]*models.AuditEvent, error)
+	// GetDigestRange returns the events a digest ending at end covers after
+	// afterID: first and last ID and count. IDs are zero when there are none.
+	GetDigestRange(ctx context.Context, afterID int64, end time.Time) (first, last, count int64, err error)
+	CreateDigest(ctx context.Context, digest *models.AuditDigest) error
+	GetDigest(ctx context.Context, day time.Time) (*models.AuditDigest, error)
+	GetLastDigest(ctx context.Context) (*models.AuditDigest, error)
+	GetDigests(ctx context.Context) ([]*models.AuditDigest, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) LockChainHead(ctx context.Context) (string, time.Time, error) {
+	db := conn(ctx, r.db)
+
+	if err := db.Exec("SELECT pg_advisory_xact_lock(?)", AuditChainLockID).Error; err != nil {
+		return "", time.Time{}, err
+	}
+
+	var head struct {
+		Hash string
+		Now  time.Time
+	}
+	err := db.Raw(`SELECT
+			COALESCE((SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1), '') AS hash,
+			clock_timestamp() AS now`).
+		Scan(&head).Error
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return head.Hash, head.Now, nil
+}
+
+func (r *auditRepository) CreateEvent(ctx context.Context, event *models.AuditEvent) error {
+	return conn(ctx, r.db).Create(event).Error
+}
+
+func (r *auditRepository) GetEvents(ctx context.Context, filter models.AuditFilter, limit, offset int) ([]*models.AuditEvent, error) {
+	var events []*models.AuditEvent
+
+	query := conn(ctx, r.db).Where("organization_id = ?", filter.OrganizationID)
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.ActorUsername != "" {
+		query = query.Where("actor_username = ?", filter.ActorUsername)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		query = query.Where("occurred_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("occurred_at < ?", *filter.To)
+	}
+
+	err := query.
+		Order("id desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *auditRepository) GetEventByID(ctx context.Context, id int64) (*models.AuditEvent, error) {
+	var event models.AuditEvent
+	if err := conn(ctx, r.db).First(&event, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *auditRepository) GetEventsAfter(ctx context.Context, afterID int64, limit int) ([]*models.AuditEvent, error) {
+	var events []*models.AuditEvent
+	err := conn(ctx, r.db).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *auditRepository) GetDigestRange(ctx context.Context, afterID int64, end time.Time) (first, last, count int64, err error) {
+	db := conn(ctx, r.db)
+
+	err = db.Model(&models.AuditEvent{}).
+		Select("COALESCE(MAX(id), 0)").
+		Where("id > ? AND occurred_at < ?", afterID, end).
+		Scan(&last).Error
+	if err != nil || last == 0 {
+		return 0, 0, 0, err
+	}
+
+	var span struct {
+		First int64
+		Count int64
+	}
+	err = db.Model(&models.AuditEvent{}).
+		Select("MIN(id) AS first, COUNT(*) AS count").
+		Where("id > ? AND id <= ?", afterID, last).
+		Scan(&span).Error
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return span.First, last, span.Count, nil
+}
+
+func (r *auditRepository) CreateDigest(ctx context.Context, digest *models.AuditDigest) error {
+	return conn(ctx, r.db).Create(digest).Error
+}
+
+func (r *auditRepository) GetDigest(ctx context.Context, day time.Time) (*models.AuditDigest, error) {
+	var digest models.AuditDigest
+	if err := conn(ctx, r.db).First(&digest, "day = ?", day.Format("2006-01-02")).Error; err != nil {
+		return nil, err
+	}
+	return &digest, nil
+}
+
+// GetLastDigest returns gorm.ErrRecordNotFound before the first digest.
+func (r *auditRepository) GetLastDigest(ctx context.Context) (*models.AuditDigest, error) {
+	var digest models.AuditDigest
+	if err := conn(ctx, r.db).Order("day DESC").First(&digest).Error; err != nil {
+		return nil, err
+	}
+	return &digest, nil
+}
+
+func (r *auditRepository) GetDigests(ctx context.Context) ([]*models.AuditDigest, error) {
+	var digests []*models.AuditDigest
+	if err := conn(ctx, r.db).Order("day").Find(&digests).Error; err != nil {
+		return nil, err
+	}
+	return digests, nil
+}
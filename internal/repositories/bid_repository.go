@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"time"
+	database "zadanie-6105/internal/db"
 	"zadanie-6105/internal/models"
 
 	"gorm.io/gorm"
@@ -13,7 +15,7 @@ type BidRepository interface {
 	IsUserResponsibleForTender(ctx context.Context, username, tenderID string) (bool, error)
 	GetBidByID(ctx context.Context, id string) (*models.Bid, error)
 	GetBidsByUser(ctx context.Context, username string, limit, offset int) ([]*models.Bid, error)
-	GetBidsForTender(ctx context.Context, tenderID string, limit, offset int) ([]*models.Bid, error)
+	GetBidsForTender(ctx context.Context, tenderID string, filter models.BidListFilter, limit, offset int) ([]*models.Bid, error)
 	GetAllBids(ctx context.Context) ([]*models.Bid, error)
 	GetBidStatus(ctx context.Context, bidID string) (string, error)
 	IsUserAuthorizedForBid(ctx context.Context, username, bidID string) (bool, error)
@@ -24,25 +26,49 @@ type BidRepository interface {
 	GetBidByVersion(ctx context.Context, bidID string, version int) (*models.Bid, error)
 	GetBidReviews(ctx context.Context, tenderID string, authorUsername string, limit, offset int) ([]*models.BidReview, error)
 	CreateBidReview(ctx context.Context, review *models.BidReview) error
+	GetBidReviewByID(ctx context.Context, id string) (*models.BidReview, error)
+	UpdateBidReview(ctx context.Context, review *models.BidReview) error
+	DeleteBidReview(ctx context.Context, id string) error
+	GetAuthorReputation(ctx context.Context, authorID string) (*models.AuthorReputation, error)
+	IsUserResponsibleForBidTender(ctx context.Context, username, bidID string) (bool, error)
+	CreateBidDecision(ctx context.Context, decision *models.BidDecision) error
+	GetAuthorBidStats(ctx context.Context, authorID string) (*models.AuthorBidStats, error)
+	GetRecentReviewsForAuthor(ctx context.Context, authorID string, limit int) ([]*models.BidReview, error)
+	IsUserResponsibleForAuthorTender(ctx context.Context, username, authorID string) (bool, error)
+	GetLatestTender(ctx context.Context, tenderID string) (*models.Tender, error)
 	IsUserAuthorizedToDeleteBid(ctx context.Context, username, bidID string) (bool, error)
 	IsUserAuthorizedToViewBids(ctx context.Context, tenderID string, username string) (bool, error)
+	CountActiveBids(ctx context.Context, tenderID, authorID, excludeBidID string) (int64, error)
+	HasAuthorBidOnTender(ctx context.Context, tenderID, authorID string) (bool, error)
+	HasBidDecision(ctx context.Context, bidID string) (bool, error)
+	CreateBidStatusChange(ctx context.Context, change *models.BidStatusChange) error
+	GetBidStatusHistory(ctx context.Context, bidID string) ([]*models.BidStatusChange, error)
+	// ExportTenderBids calls fn for the tender's bids in creation order,
+	// reading them from the database one at a time rather than loading them
+	// all like GetAllBids.
+	ExportTenderBids(ctx context.Context, tenderID string, fn func(*models.BidExportRow) error) error
+	// GetTenderDecisions and GetTenderReviews return all decisions and
+	// reviews on the tender's bids, oldest first.
+	GetTenderDecisions(ctx context.Context, tenderID string) ([]*models.ProtocolDecision, error)
+	GetTenderReviews(ctx context.Context, tenderID string) ([]*models.ProtocolReview, error)
 }
 
 type bidRepository struct {
-	db *gorm.DB
+	db       *gorm.DB
+	resolver *database.Resolver
 }
 
-func NewBidRepository(db *gorm.DB) BidRepository {
-	return &bidRepository{db: db}
+func NewBidRepository(resolver *database.Resolver) BidRepository {
+	return &bidRepository{db: resolver.Primary(), resolver: resolver}
 }
 
 func (r *bidRepository) CreateBid(ctx context.Context, bid *models.Bid) error {
-	return r.db.WithContext(ctx).Create(bid).Error
+	return conn(ctx, r.db).Create(bid).Error
 }
 
 func (r *bidRepository) IsTenderExists(ctx context.Context, tenderID string) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(&models.Tender{}).
+	err := conn(ctx, r.db).Model(&models.Tender{}).
 		Where("id = ?", tenderID).
 		Count(&count).Error
 	if err != nil {
@@ -53,9 +79,9 @@ func (r *bidRepository) IsTenderExists(ctx context.Context, tenderID string) (bo
 
 func (r *bidRepository) IsUserResponsibleForTender(ctx context.Context, username, tenderID string) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).Table("tenders").
-		Joins("JOIN organization_responsible or ON tenders.organization_id = or.organization_id").
-		Joins("JOIN employee e ON or.user_id = e.id").
+	err := conn(ctx, r.db).Table("tenders").
+		Joins("JOIN organization_responsible org_resp ON tenders.organization_id = org_resp.organization_id").
+		Joins("JOIN employee e ON org_resp.user_id = e.id").
 		Where("tenders.id = ? AND e.username = ?", tenderID, username).
 		Count(&count).Error
 
@@ -67,7 +93,7 @@ func (r *bidRepository) IsUserResponsibleForTender(ctx context.Context, username
 
 func (r *bidRepository) GetBidByID(ctx context.Context, id string) (*models.Bid, error) {
 	var bid models.Bid
-	err := r.db.WithContext(ctx).First(&bid, "id = ?", id).Error
+	err := conn(ctx, r.db).First(&bid, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +103,7 @@ func (r *bidRepository) GetBidByID(ctx context.Context, id string) (*models.Bid,
 func (r *bidRepository) GetBidsByUser(ctx context.Context, username string, limit, offset int) ([]*models.Bid, error) {
 	var bids []*models.Bid
 
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Table("bids").
 		Joins("JOIN employee ON bids.author_id = employee.id").
 		Where("employee.username = ?", username).
@@ -93,14 +119,41 @@ func (r *bidRepository) GetBidsByUser(ctx context.Context, username string, limi
 	return bids, nil
 }
 
-func (r *bidRepository) GetBidsForTender(ctx context.Context, tenderID string, limit, offset int) ([]*models.Bid, error) {
+func (r *bidRepository) GetBidsForTender(ctx context.Context, tenderID string, filter models.BidListFilter, limit, offset int) ([]*models.Bid, error) {
 	var bids []*models.Bid
 
-	err := r.db.WithContext(ctx).
-		Where("tender_id = ?", tenderID).
+	query := conn(ctx, r.resolver.Reader(ctx)).
+		Where("tender_id = ?", tenderID)
+
+	if filter.MinAmount.Valid {
+		query = query.Where("amount >= ?", filter.MinAmount.Decimal)
+	}
+	if filter.MaxAmount.Valid {
+		query = query.Where("amount <= ?", filter.MaxAmount.Decimal)
+	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	direction := "ASC"
+	if filter.Descending {
+		direction = "DESC"
+	}
+	switch filter.SortBy {
+	case models.BidSortByPrice:
+		query = query.Order("amount " + direction + " NULLS LAST").Order("name")
+	case models.BidSortByCreatedAt:
+		query = query.Order("created_at " + direction)
+	default:
+		query = query.Order("name " + direction)
+	}
+
+	err := query.
 		Limit(limit).
 		Offset(offset).
-		Order("name").
 		Find(&bids).Error
 
 	if err != nil {
@@ -112,7 +165,7 @@ func (r *bidRepository) GetBidsForTender(ctx context.Context, tenderID string, l
 
 func (r *bidRepository) GetAllBids(ctx context.Context) ([]*models.Bid, error) {
 	var bids []*models.Bid
-	err := r.db.WithContext(ctx).Find(&bids).Error
+	err := conn(ctx, r.db).Find(&bids).Error
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +175,7 @@ func (r *bidRepository) GetAllBids(ctx context.Context) ([]*models.Bid, error) {
 func (r *bidRepository) GetBidStatus(ctx context.Context, bidID string) (string, error) {
 	var status string
 
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Table("bids").
 		Select("status").
 		Where("id = ?", bidID).
@@ -138,7 +191,7 @@ func (r *bidRepository) GetBidStatus(ctx context.Context, bidID string) (string,
 func (r *bidRepository) IsUserAuthorizedForBid(ctx context.Context, username, bidID string) (bool, error) {
 	var count int64
 
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Table("bids").
 		Joins("JOIN employee e ON bids.author_id = e.id").
 		Where("bids.id = ? AND e.username = ?", bidID, username).
@@ -152,7 +205,7 @@ func (r *bidRepository) IsUserAuthorizedForBid(ctx context.Context, username, bi
 }
 
 func (r *bidRepository) UpdateBidStatus(ctx context.Context, bidID string, status string) error {
-	return r.db.WithContext(ctx).
+	return conn(ctx, r.db).
 		Model(&models.Bid{}).
 		Where("id = ?", bidID).
 		Update("status", status).Error
@@ -161,7 +214,7 @@ func (r *bidRepository) UpdateBidStatus(ctx context.Context, bidID string, statu
 func (r *bidRepository) IsUserResponsibleForBid(ctx context.Context, username, bidID string) (bool, error) {
 	var count int64
 
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Table("bids").
 		Joins("JOIN employee e ON bids.author_id = e.id").
 		Where("bids.id = ? AND e.username = ?", bidID, username).
@@ -175,24 +228,31 @@ func (r *bidRepository) IsUserResponsibleForBid(ctx context.Context, username, b
 }
 
 func (r *bidRepository) UpdateBid(ctx context.Context, bid *models.Bid) error {
-	return r.db.WithContext(ctx).
+	return conn(ctx, r.db).
 		Model(&models.Bid{}).
 		Where("id = ?", bid.ID).
 		Updates(map[string]interface{}{
-			"name":        bid.Name,
-			"description": bid.Description,
-			"version":     bid.Version,
+			"name":          bid.Name,
+			"description":   bid.Description,
+			"version":       bid.Version,
+			"status":        bid.Status,
+			"feedback":      bid.Feedback,
+			"amount":        bid.Amount,
+			"currency":      bid.Currency,
+			"delivery_days": bid.DeliveryDays,
+			"valid_until":   bid.ValidUntil,
+			"line_items":    bid.LineItems,
 		}).Error
 }
 
 func (r *bidRepository) DeleteBid(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&models.Bid{}, "id = ?", id).Error
+	return conn(ctx, r.db).Delete(&models.Bid{}, "id = ?", id).Error
 }
 
 func (r *bidRepository) GetBidByVersion(ctx context.Context, bidID string, version int) (*models.Bid, error) {
 	var bid models.Bid
 
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Where("id = ? AND version = ?", bidID, version).
 		First(&bid).Error
 	if err != nil {
@@ -205,7 +265,7 @@ func (r *bidRepository) GetBidByVersion(ctx context.Context, bidID string, versi
 func (r *bidRepository) GetBidReviews(ctx context.Context, tenderID string, authorUsername string, limit, offset int) ([]*models.BidReview, error) {
 	var reviews []*models.BidReview
 
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.resolver.Reader(ctx)).
 		Table("bid_reviews").
 		Joins("JOIN bids ON bid_reviews.bid_id = bids.id").
 		Joins("JOIN employee e ON bids.author_id = e.id").
@@ -222,7 +282,129 @@ func (r *bidRepository) GetBidReviews(ctx context.Context, tenderID string, auth
 }
 
 func (r *bidRepository) CreateBidReview(ctx context.Context, review *models.BidReview) error {
-	return r.db.WithContext(ctx).Create(review).Error
+	return conn(ctx, r.db).Create(review).Error
+}
+
+func (r *bidRepository) GetBidReviewByID(ctx context.Context, id string) (*models.BidReview, error) {
+	var review models.BidReview
+	err := conn(ctx, r.db).First(&review, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *bidRepository) UpdateBidReview(ctx context.Context, review *models.BidReview) error {
+	return conn(ctx, r.db).
+		Model(&models.BidReview{}).
+		Where("id = ?", review.ID).
+		Updates(map[string]interface{}{
+			"review":     review.Review,
+			"rating":     review.Rating,
+			"category":   review.Category,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+func (r *bidRepository) DeleteBidReview(ctx context.Context, id string) error {
+	return conn(ctx, r.db).Delete(&models.BidReview{}, "id = ?", id).Error
+}
+
+func (r *bidRepository) GetAuthorReputation(ctx context.Context, authorID string) (*models.AuthorReputation, error) {
+	reputation := models.AuthorReputation{AuthorID: authorID}
+
+	err := conn(ctx, r.resolver.Reader(ctx)).
+		Table("bid_reviews").
+		Select("COUNT(*) AS review_count, COALESCE(AVG(bid_reviews.rating), 0) AS average_rating").
+		Joins("JOIN bids ON bid_reviews.bid_id = bids.id").
+		Where("bids.author_id = ? AND bid_reviews.rating > 0", authorID).
+		Scan(&reputation).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reputation, nil
+}
+
+func (r *bidRepository) IsUserResponsibleForBidTender(ctx context.Context, username, bidID string) (bool, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Table("bids").
+		Joins("JOIN tenders ON bids.tender_id = tenders.id").
+		Joins("JOIN organization_responsible org_resp ON tenders.organization_id = org_resp.organization_id").
+		Joins("JOIN employee e ON org_resp.user_id = e.id").
+		Where("bids.id = ? AND e.username = ?", bidID, username).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *bidRepository) CreateBidDecision(ctx context.Context, decision *models.BidDecision) error {
+	return conn(ctx, r.db).Create(decision).Error
+}
+
+// GetAuthorBidStats counts the author's bids and, using the latest decision
+// per bid, how many of them were decided and approved.
+func (r *bidRepository) GetAuthorBidStats(ctx context.Context, authorID string) (*models.AuthorBidStats, error) {
+	var stats models.AuthorBidStats
+
+	err := conn(ctx, r.resolver.Reader(ctx)).Raw(`
+		SELECT
+			COUNT(*) AS bids_submitted,
+			COUNT(d.decision) AS bids_decided,
+			COUNT(*) FILTER (WHERE d.decision = ?) AS bids_approved
+		FROM bids
+		LEFT JOIN LATERAL (
+			SELECT decision FROM bid_decisions
+			WHERE bid_decisions.bid_id = bids.id
+			ORDER BY created_at DESC
+			LIMIT 1
+		) d ON true
+		WHERE bids.author_id = ?`, models.BidDecisionApproved, authorID).
+		Scan(&stats).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+func (r *bidRepository) GetRecentReviewsForAuthor(ctx context.Context, authorID string, limit int) ([]*models.BidReview, error) {
+	var reviews []*models.BidReview
+
+	err := conn(ctx, r.resolver.Reader(ctx)).
+		Table("bid_reviews").
+		Select("bid_reviews.*").
+		Joins("JOIN bids ON bid_reviews.bid_id = bids.id").
+		Where("bids.author_id = ?", authorID).
+		Order("bid_reviews.created_at DESC").
+		Limit(limit).
+		Find(&reviews).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+func (r *bidRepository) IsUserResponsibleForAuthorTender(ctx context.Context, username, authorID string) (bool, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Table("bids").
+		Joins("JOIN tenders ON bids.tender_id = tenders.id").
+		Joins("JOIN organization_responsible org_resp ON tenders.organization_id = org_resp.organization_id").
+		Joins("JOIN employee e ON org_resp.user_id = e.id").
+		Where("bids.author_id = ? AND e.username = ?", authorID, username).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
 
 func (r *bidRepository) IsUserAuthorizedToDeleteBid(ctx context.Context, username, bidID string) (bool, error) {
@@ -231,7 +413,7 @@ func (r *bidRepository) IsUserAuthorizedToDeleteBid(ctx context.Context, usernam
 
 func (r *bidRepository) IsUserAuthorizedToViewBids(ctx context.Context, tenderID string, username string) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Table("tenders").
 		Joins("JOIN organization_responsible org_resp ON tenders.organization_id = org_resp.organization_id").
 		Joins("JOIN employee e ON org_resp.user_id = e.id").
@@ -242,3 +424,163 @@ func (r *bidRepository) IsUserAuthorizedToViewBids(ctx context.Context, tenderID
 	}
 	return count > 0, nil
 }
+
+// GetLatestTender returns the latest version of the tender a bid belongs to.
+func (r *bidRepository) GetLatestTender(ctx context.Context, tenderID string) (*models.Tender, error) {
+	var tender models.Tender
+	err := conn(ctx, r.db).
+		Where("id = ?", tenderID).
+		Order("version desc").
+		First(&tender).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tender, nil
+}
+
+// HasAuthorBidOnTender reports whether the author has any bid on the
+// tender, whatever its status.
+func (r *bidRepository) HasAuthorBidOnTender(ctx context.Context, tenderID, authorID string) (bool, error) {
+	var count int64
+	err := conn(ctx, r.resolver.Reader(ctx)).
+		Model(&models.Bid{}).
+		Where("tender_id = ? AND author_id = ?", tenderID, authorID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CountActiveBids counts the author's bids on the tender that are still
+// active, ignoring excludeBidID when it is set.
+func (r *bidRepository) CountActiveBids(ctx context.Context, tenderID, authorID, excludeBidID string) (int64, error) {
+	var count int64
+	query := conn(ctx, r.db).
+		Model(&models.Bid{}).
+		Where("tender_id = ? AND author_id = ?", tenderID, authorID).
+		Where("status IN ?", []models.BidStatus{models.BidStatusCreated, models.BidStatusPublished})
+	if excludeBidID != "" {
+		query = query.Where("id <> ?", excludeBidID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *bidRepository) HasBidDecision(ctx context.Context, bidID string) (bool, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&models.BidDecision{}).
+		Where("bid_id = ?", bidID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *bidRepository) CreateBidStatusChange(ctx context.Context, change *models.BidStatusChange) error {
+	return conn(ctx, r.db).Create(change).Error
+}
+
+func (r *bidRepository) GetBidStatusHistory(ctx context.Context, bidID string) ([]*models.BidStatusChange, error) {
+	var changes []*models.BidStatusChange
+	err := conn(ctx, r.db).
+		Where("bid_id = ?", bidID).
+		Order("created_at").
+		Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *bidRepository) ExportTenderBids(ctx context.Context, tenderID string, fn func(*models.BidExportRow) error) error {
+	db := conn(ctx, r.resolver.Reader(ctx))
+	rows, err := db.Raw(`
+		SELECT
+			bids.id, bids.name, bids.status, bids.version, bids.author_type, bids.author_id,
+			COALESCE(e.username, o.name, '') AS author_name,
+			bids.amount, bids.currency, bids.delivery_days, bids.valid_until, bids.created_at,
+			counts.approvals, counts.rejections,
+			COALESCE(d.decision, '') AS decision,
+			COALESCE(approver.username, '') AS decided_by,
+			d.created_at AS decided_at,
+			bids.feedback
+		FROM bids
+		LEFT JOIN employee e ON bids.author_type = ? AND e.id = bids.author_id
+		LEFT JOIN organization o ON bids.author_type = ? AND o.id = bids.author_id
+		LEFT JOIN LATERAL (
+			SELECT
+				COUNT(*) FILTER (WHERE decision = ?) AS approvals,
+				COUNT(*) FILTER (WHERE decision = ?) AS rejections
+			FROM bid_decisions
+			WHERE bid_decisions.bid_id = bids.id
+		) counts ON true
+		LEFT JOIN LATERAL (
+			SELECT decision, approver_id, created_at FROM bid_decisions
+			WHERE bid_decisions.bid_id = bids.id
+			ORDER BY created_at DESC
+			LIMIT 1
+		) d ON true
+		LEFT JOIN employee approver ON approver.id = d.approver_id
+		WHERE bids.tender_id = ?
+		ORDER BY bids.created_at, bids.id`,
+		models.AuthorTypeUser, models.AuthorTypeOrganization,
+		models.BidDecisionApproved, models.BidDecisionRejected,
+		tenderID).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.BidExportRow
+		if err := db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *bidRepository) GetTenderDecisions(ctx context.Context, tenderID string) ([]*models.ProtocolDecision, error) {
+	var decisions []*models.ProtocolDecision
+	err := conn(ctx, r.resolver.Reader(ctx)).Raw(`
+		SELECT
+			bids.id AS bid_id, bids.name AS bid_name, d.decision,
+			COALESCE(e.username, '') AS approver, d.created_at
+		FROM bid_decisions d
+		JOIN bids ON bids.id = d.bid_id
+		LEFT JOIN employee e ON e.id = d.approver_id
+		WHERE bids.tender_id = ?
+		ORDER BY d.created_at, d.id`, tenderID).
+		Scan(&decisions).Error
+	if err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
+
+func (r *bidRepository) GetTenderReviews(ctx context.Context, tenderID string) ([]*models.ProtocolReview, error) {
+	var reviews []*models.ProtocolReview
+	err := conn(ctx, r.resolver.Reader(ctx)).Raw(`
+		SELECT
+			bids.id AS bid_id, bids.name AS bid_name,
+			COALESCE(e.username, '') AS reviewer,
+			rv.rating, rv.category, rv.review, rv.created_at
+		FROM bid_reviews rv
+		JOIN bids ON bids.id = rv.bid_id
+		LEFT JOIN employee e ON e.id = rv.reviewer_id
+		WHERE bids.tender_id = ?
+		ORDER BY rv.created_at, rv.id`, tenderID).
+		Scan(&reviews).Error
+	if err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+	"testing"
+	database "zadanie-6105/internal/db"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Keywords PostgreSQL reserves, which cannot be used as table aliases
+var reservedKeywords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true,
+	"as": true, "asc": true, "asymmetric": true, "both": true, "case": true, "cast": true,
+	"check": true, "collate": true, "column": true, "constraint": true, "create": true,
+	"current_catalog": true, "current_date": true, "current_role": true, "current_time": true,
+	"current_timestamp": true, "current_user": true, "default": true, "deferrable": true,
+	"desc": true, "distinct": true, "do": true, "else": true, "end": true, "except": true,
+	"false": true, "fetch": true, "for": true, "foreign": true, "from": true, "grant": true,
+	"group": true, "having": true, "in": true, "initially": true, "intersect": true,
+	"into": true, "lateral": true, "leading": true, "limit": true, "localtime": true,
+	"localtimestamp": true, "not": true, "null": true, "offset": true, "on": true, "only": true,
+	"or": true, "order": true, "placing": true, "primary": true, "references": true,
+	"returning": true, "select": true, "session_user": true, "some": true, "symmetric": true,
+	"system_user": true, "table": true, "then": true, "to": true, "trailing": true, "true": true,
+	"union": true, "unique": true, "user": true, "using": true, "variadic": true, "when": true,
+	"where": true, "window": true, "with": true,
+}
+
+// Keywords that may follow a table without an alias
+var clauseKeywords = map[string]bool{
+	"on": true, "where": true, "join": true, "inner": true, "left": true, "right": true,
+	"full": true, "cross": true, "natural": true, "group": true, "order": true, "limit": true,
+	"offset": true, "using": true, "union": true, "for": true,
+}
+
+// tableAliases returns the aliases given to the tables of a statement.
+func tableAliases(sql string) []string {
+	var aliases []string
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ", ",", " , ").Replace(sql))
+	for i := 0; i+2 < len(tokens); i++ {
+		keyword := strings.ToLower(tokens[i])
+		if keyword != "from" && keyword != "join" || tokens[i+1] == "(" {
+			continue
+		}
+		alias := tokens[i+2]
+		if strings.EqualFold(alias, "as") && i+3 < len(tokens) {
+			alias = tokens[i+3]
+		} else if clauseKeywords[strings.ToLower(alias)] || alias == ")" || alias == "," {
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// dryRunResolver returns a resolver whose statements are built but never
+// sent to a database, and the SQL of the statements run through it.
+func dryRunResolver(t *testing.T) (*database.Resolver, *[]string) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.Open("host=primary"), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	var statements []string
+	err = db.Callback().Query().After("gorm:query").Register("test:record", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	return database.NewResolver(db), &statements
+}
+
+func TestResponsibilityQueriesAliases(t *testing.T) {
+	resolver, statements := dryRunResolver(t)
+	bidRepo := NewBidRepository(resolver)
+	tenderRepo := NewTenderRepository(resolver)
+	ctx := context.Background()
+
+	checks := map[string]func() error{
+		"TenderRepository.IsUserResponsibleForTender": func() error {
+			_, err := tenderRepo.IsUserResponsibleForTender("user1", "7b0e8c44-1b7e-4c55-8a3e-5d2f1c0b9a11")
+			return err
+		},
+		"BidRepository.IsUserResponsibleForTender": func() error {
+			_, err := bidRepo.IsUserResponsibleForTender(ctx, "user1", "7b0e8c44-1b7e-4c55-8a3e-5d2f1c0b9a11")
+			return err
+		},
+		"BidRepository.IsUserResponsibleForBidTender": func() error {
+			_, err := bidRepo.IsUserResponsibleForBidTender(ctx, "user1", "7b0e8c44-1b7e-4c55-8a3e-5d2f1c0b9a11")
+			return err
+		},
+	}
+
+	for name, check := range checks {
+		t.Run(name, func(t *testing.T) {
+			*statements = nil
+			if err := check(); err != nil {
+				t.Fatalf("%s() error = %v", name, err)
+			}
+			if len(*statements) != 1 {
+				t.Fatalf("%s() ran %d statements, want 1", name, len(*statements))
+			}
+
+			sql := (*statements)[0]
+			if !strings.Contains(sql, "JOIN organization_responsible") {
+				t.Errorf("statement %q does not join organization_responsible", sql)
+			}
+			for _, alias := range tableAliases(sql) {
+				if reservedKeywords[strings.ToLower(alias)] {
+					t.Errorf("statement %q uses the reserved keyword %s as an alias", sql, alias)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type EmailRepository interface {
+	CreateMessages(ctx context.Context, messages []*models.EmailMessage) error
+	// ClaimDueMessages leases up to limit pending messages due at now to the
+	// caller until now+lease, so that they can be sent outside of a
+	// transaction without another sender picking them up.
+	ClaimDueMessages(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.EmailMessage, error)
+	UpdateMessage(ctx context.Context, message *models.EmailMessage) error
+}
+
+type emailRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailRepository(db *gorm.DB) EmailRepository {
+	return &emailRepository{db: db}
+}
+
+func (r *emailRepository) CreateMessages(ctx context.Context, messages []*models.EmailMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Create(messages).Error
+}
+
+func (r *emailRepository) ClaimDueMessages(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.EmailMessage, error) {
+	var messages []*models.EmailMessage
+	err := conn(ctx, r.db).Raw(`
+		WITH claimed AS (
+			UPDATE email_messages SET locked_until = ?
+			WHERE id IN (
+				SELECT id FROM email_messages
+				WHERE status = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until <= ?)
+				ORDER BY next_attempt_at
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT * FROM claimed ORDER BY next_attempt_at`,
+		now.Add(lease), models.EmailMessagePending, now, now, limit).
+		Scan(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *emailRepository) UpdateMessage(ctx context.Context, message *models.EmailMessage) error {
+	return conn(ctx, r.db).Save(message).Error
+}
@@ -10,6 +10,7 @@ import (
 type EmployeeRepository interface {
 	GetEmployeeIDByUsername(ctx context.Context, username string) (string, error)
 	IsEmployeeExists(ctx context.Context, employeeID string) (bool, error)
+	GetEmployeesByIDs(ctx context.Context, employeeIDs []string) ([]*models.Employee, error)
 }
 
 type employeeRepository struct {
@@ -22,7 +23,7 @@ func NewEmployeeRepository(db *gorm.DB) EmployeeRepository {
 
 func (r *employeeRepository) GetEmployeeIDByUsername(ctx context.Context, username string) (string, error) {
 	var employee models.Employee
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Where("username = ?", username).
 		First(&employee).Error
 	if err != nil {
@@ -33,7 +34,7 @@ func (r *employeeRepository) GetEmployeeIDByUsername(ctx context.Context, userna
 
 func (r *employeeRepository) IsEmployeeExists(ctx context.Context, employeeID string) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Table("employee").
 		Where("id = ?", employeeID).
 		Count(&count).Error
@@ -42,3 +43,17 @@ func (r *employeeRepository) IsEmployeeExists(ctx context.Context, employeeID st
 	}
 	return count > 0, nil
 }
+
+func (r *employeeRepository) GetEmployeesByIDs(ctx context.Context, employeeIDs []string) ([]*models.Employee, error) {
+	var employees []*models.Employee
+	if len(employeeIDs) == 0 {
+		return employees, nil
+	}
+	err := conn(ctx, r.db).
+		Where("id IN ?", employeeIDs).
+		Find(&employees).Error
+	if err != nil {
+		return nil, err
+	}
+	return employees, nil
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EvaluationRepository interface {
+	ReplaceCriteria(ctx context.Context, tenderID string, criteria []*models.EvaluationCriterion) error
+	GetCriteria(ctx context.Context, tenderID string) ([]*models.EvaluationCriterion, error)
+	UpsertBidScores(ctx context.Context, scores []*models.BidScore) error
+	GetBidScoresForTender(ctx context.Context, tenderID string) ([]*models.BidScore, error)
+}
+
+type evaluationRepository struct {
+	db *gorm.DB
+}
+
+func NewEvaluationRepository(db *gorm.DB) EvaluationRepository {
+	return &evaluationRepository{db: db}
+}
+
+// ReplaceCriteria swaps the tender's criteria for the given set. Scores of
+// removed criteria are deleted with them.
+func (r *evaluationRepository) ReplaceCriteria(ctx context.Context, tenderID string, criteria []*models.EvaluationCriterion) error {
+	db := conn(ctx, r.db)
+
+	err := db.Where("criterion_id IN (?)",
+		db.Model(&models.EvaluationCriterion{}).Select("id").Where("tender_id = ?", tenderID)).
+		Delete(&models.BidScore{}).Error
+	if err != nil {
+		return err
+	}
+
+	if err := db.Where("tender_id = ?", tenderID).Delete(&models.EvaluationCriterion{}).Error; err != nil {
+		return err
+	}
+
+	if len(criteria) == 0 {
+		return nil
+	}
+	return db.Create(&criteria).Error
+}
+
+func (r *evaluationRepository) GetCriteria(ctx context.Context, tenderID string) ([]*models.EvaluationCriterion, error) {
+	var criteria []*models.EvaluationCriterion
+	err := conn(ctx, r.db).
+		Where("tender_id = ?", tenderID).
+		Order("created_at, name").
+		Find(&criteria).Error
+	if err != nil {
+		return nil, err
+	}
+	return criteria, nil
+}
+
+func (r *evaluationRepository) UpsertBidScores(ctx context.Context, scores []*models.BidScore) error {
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "bid_id"}, {Name: "criterion_id"}, {Name: "scorer_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"score", "comment", "updated_at"}),
+		}).
+		Create(&scores).Error
+}
+
+func (r *evaluationRepository) GetBidScoresForTender(ctx context.Context, tenderID string) ([]*models.BidScore, error) {
+	var scores []*models.BidScore
+	err := conn(ctx, r.db).
+		Table("bid_scores").
+		Select("bid_scores.*").
+		Joins("JOIN evaluation_criteria ec ON bid_scores.criterion_id = ec.id").
+		Where("ec.tender_id = ?", tenderID).
+		Find(&scores).Error
+	if err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
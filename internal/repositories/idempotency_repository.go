@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository interface {
+	ReserveKey(ctx context.Context, key *models.IdempotencyKey) (bool, error)
+	GetKey(ctx context.Context, owner, key string) (*models.IdempotencyKey, error)
+	CompleteKey(ctx context.Context, owner, key string, statusCode int, contentType string, body []byte) error
+	DeleteKey(ctx context.Context, owner, key string) error
+	// DeleteStaleKey deletes the key if it expired before now or is still in
+	// progress since before leaseStart.
+	DeleteStaleKey(ctx context.Context, owner, key string, now, leaseStart time.Time) error
+	DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// ReserveKey inserts the key unless it already exists and reports whether
+// this call created it.
+func (r *idempotencyRepository) ReserveKey(ctx context.Context, key *models.IdempotencyKey) (bool, error) {
+	result := conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(key)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *idempotencyRepository) GetKey(ctx context.Context, owner, key string) (*models.IdempotencyKey, error) {
+	var stored models.IdempotencyKey
+	err := conn(ctx, r.db).
+		Where("owner = ? AND key = ?", owner, key).
+		First(&stored).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (r *idempotencyRepository) CompleteKey(ctx context.Context, owner, key string, statusCode int, contentType string, body []byte) error {
+	return conn(ctx, r.db).
+		Model(&models.IdempotencyKey{}).
+		Where("owner = ? AND key = ?", owner, key).
+		Updates(map[string]interface{}{
+			"status_code":   statusCode,
+			"content_type":  contentType,
+			"response_body": body,
+		}).Error
+}
+
+func (r *idempotencyRepository) DeleteKey(ctx context.Context, owner, key string) error {
+	return conn(ctx, r.db).
+		Where("owner = ? AND key = ?", owner, key).
+		Delete(&models.IdempotencyKey{}).Error
+}
+
+func (r *idempotencyRepository) DeleteStaleKey(ctx context.Context, owner, key string, now, leaseStart time.Time) error {
+	return conn(ctx, r.db).
+		Where("owner = ? AND key = ?", owner, key).
+		Where("expires_at < ? OR (status_code = 0 AND created_at < ?)", now, leaseStart).
+		Delete(&models.IdempotencyKey{}).Error
+}
+
+func (r *idempotencyRepository) DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error) {
+	result := conn(ctx, r.db).
+		Where("expires_at < ?", now).
+		Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
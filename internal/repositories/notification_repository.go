@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	CreateNotifications(ctx context.Context, notifications []*models.Notification) error
+	GetNotifications(ctx context.Context, recipientID string, unreadOnly bool, limit, offset int) ([]*models.Notification, error)
+	CountUnread(ctx context.Context, recipientID string) (int64, error)
+	// MarkRead returns gorm.ErrRecordNotFound unless the notification
+	// belongs to the recipient.
+	MarkRead(ctx context.Context, recipientID, id string, readAt time.Time) error
+	MarkAllRead(ctx context.Context, recipientID string, readAt time.Time) (int64, error)
+
+	GetPreferences(ctx context.Context, employeeID string) ([]*models.NotificationPreference, error)
+	SavePreferences(ctx context.Context, preferences []*models.NotificationPreference) error
+	// GetOptedOutEmployees returns those of employeeIDs who disabled eventType.
+	GetOptedOutEmployees(ctx context.Context, eventType models.DomainEventType, employeeIDs []string) ([]string, error)
+
+	// GetOrganizationResponsibles returns the IDs of the employees
+	// responsible for the organization.
+	GetOrganizationResponsibles(ctx context.Context, organizationID string) ([]string, error)
+	// GetInvitedEmployees returns the employees responsible for the
+	// organizations invited to the tender that have not declined.
+	GetInvitedEmployees(ctx context.Context, tenderID string) ([]string, error)
+	// GetActiveBidAuthors returns the active bids of the tender, one per
+	// author.
+	GetActiveBidAuthors(ctx context.Context, tenderID string) ([]*models.Bid, error)
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) CreateNotifications(ctx context.Context, notifications []*models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Create(notifications).Error
+}
+
+func (r *notificationRepository) GetNotifications(ctx context.Context, recipientID string, unreadOnly bool, limit, offset int) ([]*models.Notification, error) {
+	var notifications []*models.Notification
+	query := conn(ctx, r.db).Where("recipient_id = ?", recipientID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *notificationRepository) CountUnread(ctx context.Context, recipientID string) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&models.Notification{}).
+		Where("recipient_id = ? AND read_at IS NULL", recipientID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepository) MarkRead(ctx context.Context, recipientID, id string, readAt time.Time) error {
+	result := conn(ctx, r.db).
+		Model(&models.Notification{}).
+		Where("id = ? AND recipient_id = ?", id, recipientID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", readAt))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *notificationRepository) MarkAllRead(ctx context.Context, recipientID string, readAt time.Time) (int64, error) {
+	result := conn(ctx, r.db).
+		Model(&models.Notification{}).
+		Where("recipient_id = ? AND read_at IS NULL", recipientID).
+		Update("read_at", readAt)
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepository) GetPreferences(ctx context.Context, employeeID string) ([]*models.NotificationPreference, error) {
+	var preferences []*models.NotificationPreference
+	err := conn(ctx, r.db).Where("employee_id = ?", employeeID).Find(&preferences).Error
+	if err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+func (r *notificationRepository) SavePreferences(ctx context.Context, preferences []*models.NotificationPreference) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "employee_id"}, {Name: "event_type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"enabled"}),
+		}).
+		Create(preferences).Error
+}
+
+func (r *notificationRepository) GetOptedOutEmployees(ctx context.Context, eventType models.DomainEventType, employeeIDs []string) ([]string, error) {
+	var ids []string
+	if len(employeeIDs) == 0 {
+		return ids, nil
+	}
+	err := conn(ctx, r.db).
+		Model(&models.NotificationPreference{}).
+		Where("event_type = ? AND NOT enabled AND employee_id IN ?", eventType, employeeIDs).
+		Pluck("employee_id", &ids).Error
+	return ids, err
+}
+
+func (r *notificationRepository) GetOrganizationResponsibles(ctx context.Context, organizationID string) ([]string, error) {
+	var ids []string
+	err := conn(ctx, r.db).
+		Table("organization_responsible").
+		Where("organization_id = ?", organizationID).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+func (r *notificationRepository) GetInvitedEmployees(ctx context.Context, tenderID string) ([]string, error) {
+	var ids []string
+	err := conn(ctx, r.db).
+		Table("tender_invitations").
+		Joins("JOIN organization_responsible org_resp ON tender_invitations.organization_id = org_resp.organization_id").
+		Where("tender_invitations.tender_id = ? AND tender_invitations.status <> ?", tenderID, models.InvitationStatusDeclined).
+		Distinct().
+		Pluck("org_resp.user_id", &ids).Error
+	return ids, err
+}
+
+func (r *notificationRepository) GetActiveBidAuthors(ctx context.Context, tenderID string) ([]*models.Bid, error) {
+	var bids []*models.Bid
+	err := conn(ctx, r.db).
+		Select("DISTINCT ON (author_id) *").
+		Where("tender_id = ? AND status IN ?", tenderID, []models.BidStatus{models.BidStatusCreated, models.BidStatusPublished}).
+		Order("author_id").
+		Find(&bids).Error
+	if err != nil {
+		return nil, err
+	}
+	return bids, nil
+}
@@ -8,6 +8,7 @@ import (
 
 type OrganizationRepository interface {
 	IsOrganizationExists(ctx context.Context, organizationID string) (bool, error)
+	GetOrganizationName(ctx context.Context, organizationID string) (string, error)
 }
 
 type organizationRepository struct {
@@ -20,7 +21,7 @@ func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
 
 func (r *organizationRepository) IsOrganizationExists(ctx context.Context, organizationID string) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Table("organization").
 		Where("id = ?", organizationID).
 		Count(&count).Error
@@ -29,3 +30,13 @@ func (r *organizationRepository) IsOrganizationExists(ctx context.Context, organ
 	}
 	return count > 0, nil
 }
+
+func (r *organizationRepository) GetOrganizationName(ctx context.Context, organizationID string) (string, error) {
+	var name string
+	err := conn(ctx, r.db).
+		Table("organization").
+		Select("name").
+		Where("id = ?", organizationID).
+		Scan(&name).Error
+	return name, err
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	CreateEvent(ctx context.Context, event *models.OutboxEvent) error
+	GetEventByID(ctx context.Context, id string) (*models.OutboxEvent, error)
+	// ClaimUndispatchedEvents marks up to limit events as dispatched and
+	// returns them, oldest first. Events locked by a concurrent dispatcher are
+	// skipped; the claim is undone if the transaction rolls back.
+	ClaimUndispatchedEvents(ctx context.Context, limit int) ([]*models.OutboxEvent, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) CreateEvent(ctx context.Context, event *models.OutboxEvent) error {
+	return conn(ctx, r.db).Create(event).Error
+}
+
+func (r *outboxRepository) GetEventByID(ctx context.Context, id string) (*models.OutboxEvent, error) {
+	var event models.OutboxEvent
+	if err := conn(ctx, r.db).First(&event, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *outboxRepository) ClaimUndispatchedEvents(ctx context.Context, limit int) ([]*models.OutboxEvent, error) {
+	var events []*models.OutboxEvent
+	err := conn(ctx, r.db).Raw(`
+		WITH claimed AS (
+			UPDATE outbox_events SET dispatched_at = now()
+			WHERE id IN (
+				SELECT id FROM outbox_events
+				WHERE dispatched_at IS NULL
+				ORDER BY created_at
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT * FROM claimed ORDER BY created_at`, limit).
+		Scan(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// notifyChannel sends a Postgres notification, delivered to the listeners
+// once the current transaction commits.
+func notifyChannel(db *gorm.DB, channel, payload string) error {
+	return db.Exec("SELECT pg_notify(?, ?)", channel, payload).Error
+}
+
+// listenChannel takes a connection out of the pool, listens on channel and
+// calls ready, then handle with the payload of every notification until ctx
+// is canceled or the connection fails. The connection is discarded
+// afterwards rather than handed back with the LISTEN registration.
+func listenChannel(ctx context.Context, db *gorm.DB, channel string, ready func() error, handle func(payload string) error) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	c, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var listenErr error
+	c.Raw(func(driverConn interface{}) error {
+		listenErr = listen(ctx, driverConn, channel, ready, handle)
+		return driver.ErrBadConn
+	})
+	return listenErr
+}
+
+func listen(ctx context.Context, driverConn interface{}, channel string, ready func() error, handle func(payload string) error) error {
+	stdConn, ok := driverConn.(*stdlib.Conn)
+	if !ok {
+		return fmt.Errorf("unexpected driver connection %T", driverConn)
+	}
+	pgxConn := stdConn.Conn()
+
+	if _, err := pgxConn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return err
+	}
+	if err := ready(); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := pgxConn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		if err := handle(notification.Payload); err != nil {
+			return err
+		}
+	}
+}
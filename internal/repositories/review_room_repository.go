@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ReviewRoomNotifyChannel is the Postgres NOTIFY channel announcing, with the
+// tender ID as payload, that the participants of a review room changed.
+const ReviewRoomNotifyChannel = "review_rooms"
+
+type ReviewRoomRepository interface {
+	// AddParticipant, UpdateParticipantEditing and RemoveParticipant notify
+	// the listeners of the participant's room.
+	AddParticipant(ctx context.Context, participant *models.ReviewParticipant) error
+	UpdateParticipantEditing(ctx context.Context, participant *models.ReviewParticipant) error
+	RemoveParticipant(ctx context.Context, participant *models.ReviewParticipant) error
+	// GetParticipants returns the room's participants that have not expired
+	// by now, in the order they joined.
+	GetParticipants(ctx context.Context, tenderID string, now time.Time) ([]*models.ReviewParticipant, error)
+	ExtendParticipants(ctx context.Context, ids []string, expiresAt time.Time) error
+	DeleteExpiredParticipants(ctx context.Context, now time.Time) (int64, error)
+	// Listen calls ready once it listens on ReviewRoomNotifyChannel, then
+	// handle with the tender ID of every notification until ctx is canceled
+	// or the connection fails.
+	Listen(ctx context.Context, ready func() error, handle func(tenderID string)) error
+}
+
+type reviewRoomRepository struct {
+	db *gorm.DB
+}
+
+// NewReviewRoomRepository must be given the primary, like
+// NewStreamRepository.
+func NewReviewRoomRepository(db *gorm.DB) ReviewRoomRepository {
+	return &reviewRoomRepository{db: db}
+}
+
+func (r *reviewRoomRepository) AddParticipant(ctx context.Context, participant *models.ReviewParticipant) error {
+	db := conn(ctx, r.db)
+	if err := db.Create(participant).Error; err != nil {
+		return err
+	}
+	return notifyChannel(db, ReviewRoomNotifyChannel, participant.TenderID)
+}
+
+func (r *reviewRoomRepository) UpdateParticipantEditing(ctx context.Context, participant *models.ReviewParticipant) error {
+	db := conn(ctx, r.db)
+	err := db.Model(&models.ReviewParticipant{}).
+		Where("id = ?", participant.ID).
+		Update("editing_bid_id", participant.EditingBidID).Error
+	if err != nil {
+		return err
+	}
+	return notifyChannel(db, ReviewRoomNotifyChannel, participant.TenderID)
+}
+
+func (r *reviewRoomRepository) RemoveParticipant(ctx context.Context, participant *models.ReviewParticipant) error {
+	db := conn(ctx, r.db)
+	if err := db.Delete(&models.ReviewParticipant{}, "id = ?", participant.ID).Error; err != nil {
+		return err
+	}
+	return notifyChannel(db, ReviewRoomNotifyChannel, participant.TenderID)
+}
+
+func (r *reviewRoomRepository) GetParticipants(ctx context.Context, tenderID string, now time.Time) ([]*models.ReviewParticipant, error) {
+	var participants []*models.ReviewParticipant
+	err := conn(ctx, r.db).
+		Where("tender_id = ? AND expires_at > ?", tenderID, now).
+		Order("joined_at, id").
+		Find(&participants).Error
+	if err != nil {
+		return nil, err
+	}
+	return participants, nil
+}
+
+func (r *reviewRoomRepository) ExtendParticipants(ctx context.Context, ids []string, expiresAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).
+		Model(&models.ReviewParticipant{}).
+		Where("id IN ?", ids).
+		Update("expires_at", expiresAt).Error
+}
+
+func (r *reviewRoomRepository) DeleteExpiredParticipants(ctx context.Context, now time.Time) (int64, error) {
+	result := conn(ctx, r.db).Where("expires_at <= ?", now).Delete(&models.ReviewParticipant{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *reviewRoomRepository) Listen(ctx context.Context, ready func() error, handle func(tenderID string)) error {
+	return listenChannel(ctx, r.db, ReviewRoomNotifyChannel, ready, func(payload string) error {
+		handle(payload)
+		return nil
+	})
+}
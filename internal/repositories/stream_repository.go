@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// StreamNotifyChannel is the Postgres NOTIFY channel announcing new stream
+// events to the listeners of every replica.
+const StreamNotifyChannel = "stream_events"
+
+// StreamNotification is the payload of a StreamNotifyChannel notification.
+type StreamNotification struct {
+	ID       int64  `json:"id"`
+	TenderID string `json:"tenderId"`
+}
+
+type StreamRepository interface {
+	// CreateEvent stores the event and notifies the listeners once the
+	// transaction commits.
+	CreateEvent(ctx context.Context, event *models.StreamEvent) error
+	GetEventByID(ctx context.Context, id int64) (*models.StreamEvent, error)
+	// GetTenderEventsAfter returns the tender's events with IDs above afterID
+	// in ID order.
+	GetTenderEventsAfter(ctx context.Context, tenderID string, afterID int64, limit int) ([]*models.StreamEvent, error)
+	// GetEventsAfter returns the events of all tenders with IDs above afterID
+	// in ID order.
+	GetEventsAfter(ctx context.Context, afterID int64, limit int) ([]*models.StreamEvent, error)
+	GetLastEventID(ctx context.Context) (int64, error)
+	DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error)
+	// Listen calls ready once it listens on StreamNotifyChannel, then handle
+	// for every notification until ctx is canceled or the connection fails.
+	// The connection is taken out of the pool and discarded afterwards.
+	Listen(ctx context.Context, ready func() error, handle func(*StreamNotification)) error
+}
+
+type streamRepository struct {
+	db *gorm.DB
+}
+
+// NewStreamRepository must be given the primary: notifications are not
+// delivered to sessions on read replicas.
+func NewStreamRepository(db *gorm.DB) StreamRepository {
+	return &streamRepository{db: db}
+}
+
+func (r *streamRepository) CreateEvent(ctx context.Context, event *models.StreamEvent) error {
+	db := conn(ctx, r.db)
+	if err := db.Create(event).Error; err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(&StreamNotification{ID: event.ID, TenderID: event.TenderID})
+	if err != nil {
+		return err
+	}
+	return notifyChannel(db, StreamNotifyChannel, string(payload))
+}
+
+func (r *streamRepository) GetEventByID(ctx context.Context, id int64) (*models.StreamEvent, error) {
+	var event models.StreamEvent
+	if err := conn(ctx, r.db).First(&event, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *streamRepository) GetTenderEventsAfter(ctx context.Context, tenderID string, afterID int64, limit int) ([]*models.StreamEvent, error) {
+	var events []*models.StreamEvent
+	err := conn(ctx, r.db).
+		Where("tender_id = ? AND id > ?", tenderID, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *streamRepository) GetEventsAfter(ctx context.Context, afterID int64, limit int) ([]*models.StreamEvent, error) {
+	var events []*models.StreamEvent
+	err := conn(ctx, r.db).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *streamRepository) GetLastEventID(ctx context.Context) (int64, error) {
+	var id int64
+	err := conn(ctx, r.db).
+		Model(&models.StreamEvent{}).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id).Error
+	return id, err
+}
+
+func (r *streamRepository) DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).Where("created_at < ?", before).Delete(&models.StreamEvent{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *streamRepository) Listen(ctx context.Context, ready func() error, handle func(*StreamNotification)) error {
+	return listenChannel(ctx, r.db, StreamNotifyChannel, ready, func(payload string) error {
+		var notification StreamNotification
+		if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+			return fmt.Errorf("malformed %s notification %q: %w", StreamNotifyChannel, payload, err)
+		}
+		handle(&notification)
+		return nil
+	})
+}
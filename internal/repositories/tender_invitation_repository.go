@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TenderInvitationRepository interface {
+	// CreateInvitation invites the organization, re-opening a previously
+	// declined invitation as pending.
+	CreateInvitation(ctx context.Context, invitation *models.TenderInvitation) error
+	GetInvitationByID(ctx context.Context, id string) (*models.TenderInvitation, error)
+	GetInvitations(ctx context.Context, tenderID string) ([]*models.TenderInvitation, error)
+	GetInvitationsForUser(ctx context.Context, username string, limit, offset int) ([]*models.TenderInvitation, error)
+	UpdateInvitationStatus(ctx context.Context, id string, status models.InvitationStatus, respondedBy string) error
+	DeleteInvitation(ctx context.Context, id string) error
+	// HasAcceptedInvitation reports whether the bid author, an organization
+	// or an employee of one, holds an accepted invitation to the tender.
+	HasAcceptedInvitation(ctx context.Context, tenderID, authorID string) (bool, error)
+}
+
+type tenderInvitationRepository struct {
+	db *gorm.DB
+}
+
+func NewTenderInvitationRepository(db *gorm.DB) TenderInvitationRepository {
+	return &tenderInvitationRepository{db: db}
+}
+
+func (r *tenderInvitationRepository) CreateInvitation(ctx context.Context, invitation *models.TenderInvitation) error {
+	invitation.Status = models.InvitationStatusPending
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "tender_id"}, {Name: "organization_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"status":       gorm.Expr("CASE WHEN tender_invitations.status = ? THEN ? ELSE tender_invitations.status END", models.InvitationStatusDeclined, models.InvitationStatusPending),
+				"responded_by": gorm.Expr("CASE WHEN tender_invitations.status = ? THEN NULL ELSE tender_invitations.responded_by END", models.InvitationStatusDeclined),
+				"responded_at": gorm.Expr("CASE WHEN tender_invitations.status = ? THEN NULL ELSE tender_invitations.responded_at END", models.InvitationStatusDeclined),
+			}),
+		}).
+		Clauses(clause.Returning{}).
+		Create(invitation).Error
+}
+
+func (r *tenderInvitationRepository) GetInvitationByID(ctx context.Context, id string) (*models.TenderInvitation, error) {
+	var invitation models.TenderInvitation
+	err := conn(ctx, r.db).First(&invitation, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *tenderInvitationRepository) GetInvitations(ctx context.Context, tenderID string) ([]*models.TenderInvitation, error) {
+	var invitations []*models.TenderInvitation
+	err := conn(ctx, r.db).
+		Where("tender_id = ?", tenderID).
+		Order("created_at").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (r *tenderInvitationRepository) GetInvitationsForUser(ctx context.Context, username string, limit, offset int) ([]*models.TenderInvitation, error) {
+	var invitations []*models.TenderInvitation
+	err := conn(ctx, r.db).
+		Table("tender_invitations").
+		Joins("JOIN organization_responsible org_resp ON tender_invitations.organization_id = org_resp.organization_id").
+		Joins("JOIN employee e ON org_resp.user_id = e.id").
+		Where("e.username = ?", username).
+		Order("tender_invitations.created_at desc").
+		Limit(limit).
+		Offset(offset).
+		Select("tender_invitations.*").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (r *tenderInvitationRepository) UpdateInvitationStatus(ctx context.Context, id string, status models.InvitationStatus, respondedBy string) error {
+	return conn(ctx, r.db).
+		Model(&models.TenderInvitation{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"responded_by": respondedBy,
+			"responded_at": time.Now(),
+		}).Error
+}
+
+func (r *tenderInvitationRepository) DeleteInvitation(ctx context.Context, id string) error {
+	return conn(ctx, r.db).Delete(&models.TenderInvitation{}, "id = ?", id).Error
+}
+
+func (r *tenderInvitationRepository) HasAcceptedInvitation(ctx context.Context, tenderID, authorID string) (bool, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&models.TenderInvitation{}).
+		Where("tender_id = ? AND status = ?", tenderID, models.InvitationStatusAccepted).
+		Where("organization_id = ? OR organization_id IN (SELECT organization_id FROM organization_responsible WHERE user_id = ?)", authorID, authorID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
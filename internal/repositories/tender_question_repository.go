@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type TenderQuestionRepository interface {
+	CreateQuestion(ctx context.Context, question *models.TenderQuestion) error
+	GetQuestionByID(ctx context.Context, id string) (*models.TenderQuestion, error)
+	AnswerQuestion(ctx context.Context, question *models.TenderQuestion) error
+	// GetQuestions returns all questions of the tender when viewerID is
+	// empty, otherwise the viewer's own questions and publicly answered ones.
+	GetQuestions(ctx context.Context, tenderID, viewerID string, limit, offset int) ([]*models.TenderQuestion, error)
+}
+
+type tenderQuestionRepository struct {
+	db *gorm.DB
+}
+
+func NewTenderQuestionRepository(db *gorm.DB) TenderQuestionRepository {
+	return &tenderQuestionRepository{db: db}
+}
+
+func (r *tenderQuestionRepository) CreateQuestion(ctx context.Context, question *models.TenderQuestion) error {
+	return conn(ctx, r.db).Create(question).Error
+}
+
+func (r *tenderQuestionRepository) GetQuestionByID(ctx context.Context, id string) (*models.TenderQuestion, error) {
+	var question models.TenderQuestion
+	err := conn(ctx, r.db).First(&question, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &question, nil
+}
+
+func (r *tenderQuestionRepository) AnswerQuestion(ctx context.Context, question *models.TenderQuestion) error {
+	return conn(ctx, r.db).
+		Model(&models.TenderQuestion{}).
+		Where("id = ?", question.ID).
+		Updates(map[string]interface{}{
+			"answer":      question.Answer,
+			"answered_by": question.AnsweredBy,
+			"answered_at": question.AnsweredAt,
+			"is_public":   question.IsPublic,
+		}).Error
+}
+
+func (r *tenderQuestionRepository) GetQuestions(ctx context.Context, tenderID, viewerID string, limit, offset int) ([]*models.TenderQuestion, error) {
+	var questions []*models.TenderQuestion
+
+	query := conn(ctx, r.db).Where("tender_id = ?", tenderID)
+	if viewerID != "" {
+		query = query.Where("author_id = ? OR (is_public AND answered_at IS NOT NULL)", viewerID)
+	}
+
+	err := query.
+		Order("created_at desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&questions).Error
+	if err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
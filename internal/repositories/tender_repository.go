@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+	database "zadanie-6105/internal/db"
 	"zadanie-6105/internal/models"
 
 	"gorm.io/gorm"
@@ -14,7 +15,7 @@ type TenderRepository interface {
 	CreateTender(ctx context.Context, tender *models.Tender) error
 	GetTenderByID(ctx context.Context, id string) (*models.Tender, error)
 	GetTendersByUser(ctx context.Context, username string, limit, offset int) ([]*models.Tender, error)
-	GetTenders(ctx context.Context, serviceTypes []models.TenderServiceType, limit, offset int) ([]*models.Tender, error)
+	GetTenders(ctx context.Context, serviceTypes []models.TenderServiceType, username, organizationID string, limit, offset int) ([]*models.Tender, error)
 	UpdateTenderStatus(ctx context.Context, id string, status models.TenderStatus) error
 	UpdateTender(ctx context.Context, tender *models.Tender) error
 	DeleteTender(ctx context.Context, id string) error
@@ -22,34 +23,47 @@ type TenderRepository interface {
 	RollbackTenderVersion(ctx context.Context, id string, version int) error
 	IsUserResponsibleForOrganization(username, organizationID string) (bool, error)
 	IsUserResponsibleForTender(username, tenderId string) (bool, error)
+	IsTenderVisibleTo(ctx context.Context, tenderID, username, organizationID string) (bool, error)
 	CheckUserExists(ctx context.Context, username string) (bool, error)
+	CloseExpiredTenders(ctx context.Context, now time.Time) ([]string, error)
+	SetTenderPublishAt(ctx context.Context, id string, publishAt *time.Time) error
+	GetScheduledTenders(ctx context.Context, organizationID string, limit, offset int) ([]*models.Tender, error)
+	PublishDueTenders(ctx context.Context, now time.Time) ([]string, error)
+	// ExportOrganizationTenders calls fn for the organization's tenders in
+	// creation order, reading them from the database one at a time.
+	ExportOrganizationTenders(ctx context.Context, organizationID string, fn func(*models.TenderExportRow) error) error
 }
 
 type tenderRepository struct {
-	db *gorm.DB
+	db       *gorm.DB
+	resolver *database.Resolver
 }
 
-func NewTenderRepository(db *gorm.DB) TenderRepository {
-	return &tenderRepository{db: db}
+func NewTenderRepository(resolver *database.Resolver) TenderRepository {
+	return &tenderRepository{db: resolver.Primary(), resolver: resolver}
 }
 
 func (r *tenderRepository) CreateTender(ctx context.Context, tender *models.Tender) error {
 	tender.Version = 1
-	return r.db.WithContext(ctx).Create(tender).Error
+	return conn(ctx, r.db).Create(tender).Error
 }
 
 func (r *tenderRepository) GetTenderByID(ctx context.Context, id string) (*models.Tender, error) {
 	var tender models.Tender
-	err := r.db.WithContext(ctx).First(&tender, "id = ?", id).Error
+	err := conn(ctx, r.db).Order("version desc").First(&tender, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &tender, nil
 }
 
-func (r *tenderRepository) GetTenders(ctx context.Context, serviceTypes []models.TenderServiceType, limit, offset int) ([]*models.Tender, error) {
+func (r *tenderRepository) GetTenders(ctx context.Context, serviceTypes []models.TenderServiceType, username, organizationID string, limit, offset int) ([]*models.Tender, error) {
 	var tenders []*models.Tender
-	query := r.db.WithContext(ctx).Limit(limit).Offset(offset).Order("created_at desc")
+	query := conn(ctx, r.resolver.Reader(ctx)).
+		Scopes(visibleTo(username, organizationID)).
+		Limit(limit).
+		Offset(offset).
+		Order("created_at desc")
 
 	if len(serviceTypes) > 0 {
 		query = query.Where("service_type IN ?", serviceTypes)
@@ -65,7 +79,7 @@ func (r *tenderRepository) GetTenders(ctx context.Context, serviceTypes []models
 func (r *tenderRepository) GetTendersByUser(ctx context.Context, username string, limit, offset int) ([]*models.Tender, error) {
 	var tenders []*models.Tender
 
-	err := r.db.Table("tenders").
+	err := conn(ctx, r.resolver.Reader(ctx)).Table("tenders").
 		Joins("JOIN organization_responsible org_resp ON tenders.organization_id = org_resp.organization_id").
 		Joins("JOIN employee e ON org_resp.user_id = e.id").
 		Where("e.username = ?", username).
@@ -81,7 +95,7 @@ func (r *tenderRepository) GetTendersByUser(ctx context.Context, username string
 }
 
 func (r *tenderRepository) UpdateTenderStatus(ctx context.Context, id string, status models.TenderStatus) error {
-	result := r.db.Model(&models.Tender{}).
+	result := conn(ctx, r.db).Model(&models.Tender{}).
 		Where("id = ?", id).
 		Update("status", status)
 
@@ -111,10 +125,59 @@ func (r *tenderRepository) IsUserResponsibleForTender(username, tenderId string)
 	return count > 0, nil
 }
 
+// IsTenderVisibleTo applies the same visibility rules as GetTenders to a
+// single tender.
+func (r *tenderRepository) IsTenderVisibleTo(ctx context.Context, tenderID, username, organizationID string) (bool, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&models.Tender{}).
+		Scopes(visibleTo(username, organizationID)).
+		Where("id = ?", tenderID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// visibleTo limits a tenders query to public tenders, tenders of the
+// viewer's own organizations and invite-only tenders the viewer's
+// organizations were invited to and have not declined. The viewer is either
+// an employee (username) or an organization. Visibility is taken from the
+// latest version, so that older versions of a tender made invite-only do not
+// keep it visible.
+func visibleTo(username, organizationID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		viewerOrgs := `SELECT org_resp.organization_id FROM organization_responsible org_resp
+			JOIN employee e ON org_resp.user_id = e.id WHERE e.username = @username
+			UNION SELECT CAST(NULLIF(@organization, '') AS uuid)`
+
+		return db.Where(`EXISTS (
+			SELECT 1 FROM tenders latest
+			WHERE latest.id = tenders.id
+			AND latest.version = (SELECT MAX(t.version) FROM tenders t WHERE t.id = tenders.id)
+			AND (
+				latest.visibility = @public
+				OR latest.organization_id IN (`+viewerOrgs+`)
+				OR EXISTS (
+					SELECT 1 FROM tender_invitations ti
+					WHERE ti.tender_id = latest.id AND ti.status <> @declined
+					AND ti.organization_id IN (`+viewerOrgs+`)
+				)
+			)
+		)`,
+			sql.Named("public", models.TenderVisibilityPublic),
+			sql.Named("declined", models.InvitationStatusDeclined),
+			sql.Named("username", username),
+			sql.Named("organization", organizationID),
+		)
+	}
+}
+
 func (r *tenderRepository) UpdateTender(ctx context.Context, tender *models.Tender) error {
 	// Получаем текущую максимальную версию тендера
 	var currentVersion int
-	err := r.db.WithContext(ctx).Model(&models.Tender{}).
+	err := conn(ctx, r.db).Model(&models.Tender{}).
 		Where("id = ?", tender.ID).
 		Select("MAX(version)").Scan(&currentVersion).Error
 	if err != nil {
@@ -125,7 +188,7 @@ func (r *tenderRepository) UpdateTender(ctx context.Context, tender *models.Tend
 	tender.Version = currentVersion + 1
 
 	// Создаем новую запись с обновленными данными
-	err = r.db.WithContext(ctx).Create(tender).Error
+	err = conn(ctx, r.db).Create(tender).Error
 	if err != nil {
 		return err
 	}
@@ -134,12 +197,12 @@ func (r *tenderRepository) UpdateTender(ctx context.Context, tender *models.Tend
 }
 
 func (r *tenderRepository) DeleteTender(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&models.Tender{}, "id = ?", id).Error
+	return conn(ctx, r.db).Delete(&models.Tender{}, "id = ?", id).Error
 }
 
 func (r *tenderRepository) GetTenderVersions(ctx context.Context, id string) ([]*models.Tender, error) {
 	var versions []*models.Tender
-	err := r.db.WithContext(ctx).Where("id = ?", id).Order("version asc").Find(&versions).Error
+	err := conn(ctx, r.db).Where("id = ?", id).Order("version asc").Find(&versions).Error
 	if err != nil {
 		return nil, err
 	}
@@ -149,14 +212,14 @@ func (r *tenderRepository) GetTenderVersions(ctx context.Context, id string) ([]
 func (r *tenderRepository) RollbackTenderVersion(ctx context.Context, id string, version int) error {
 	// Получаем данные указанной версии
 	var versionData models.Tender
-	err := r.db.WithContext(ctx).Where("id = ? AND version = ?", id, version).First(&versionData).Error
+	err := conn(ctx, r.db).Where("id = ? AND version = ?", id, version).First(&versionData).Error
 	if err != nil {
 		return fmt.Errorf("failed to find version %d for tender %s: %w", version, id, err)
 	}
 
 	// Получаем текущую максимальную версию
 	var currentVersion int
-	err = r.db.WithContext(ctx).Model(&models.Tender{}).
+	err = conn(ctx, r.db).Model(&models.Tender{}).
 		Where("id = ?", id).
 		Select("MAX(version)").Scan(&currentVersion).Error
 	if err != nil {
@@ -169,7 +232,7 @@ func (r *tenderRepository) RollbackTenderVersion(ctx context.Context, id string,
 	newVersion.CreatedAt = time.Now()
 
 	// Сохраняем новую версию
-	err = r.db.WithContext(ctx).Create(&newVersion).Error
+	err = conn(ctx, r.db).Create(&newVersion).Error
 	if err != nil {
 		return fmt.Errorf("failed to create new version during rollback: %w", err)
 	}
@@ -197,3 +260,125 @@ func (r *tenderRepository) CheckUserExists(ctx context.Context, username string)
 	}
 	return count > 0, nil
 }
+
+// CloseExpiredTenders closes every published tender whose submission
+// deadline, as set in its latest version, has passed and returns their IDs.
+// All versions of a closed tender get the new status.
+func (r *tenderRepository) CloseExpiredTenders(ctx context.Context, now time.Time) ([]string, error) {
+	var ids []string
+	err := conn(ctx, r.db).Raw(`
+		WITH expired AS (
+			SELECT id FROM tenders
+			WHERE status = ? AND submission_deadline IS NOT NULL AND submission_deadline <= ?
+				AND version = (SELECT MAX(t.version) FROM tenders t WHERE t.id = tenders.id)
+		), closed AS (
+			UPDATE tenders SET status = ?
+			WHERE id IN (SELECT id FROM expired)
+			RETURNING id
+		)
+		SELECT DISTINCT id FROM closed`, models.TenderStatusPublished, now, models.TenderStatusClosed).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *tenderRepository) SetTenderPublishAt(ctx context.Context, id string, publishAt *time.Time) error {
+	result := conn(ctx, r.db).Model(&models.Tender{}).
+		Where("id = ?", id).
+		Update("publish_at", publishAt)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetScheduledTenders returns the latest version of the organization's
+// Created tenders that have a publication time, soonest first.
+func (r *tenderRepository) GetScheduledTenders(ctx context.Context, organizationID string, limit, offset int) ([]*models.Tender, error) {
+	var tenders []*models.Tender
+
+	err := conn(ctx, r.resolver.Reader(ctx)).
+		Where("organization_id = ? AND status = ? AND publish_at IS NOT NULL", organizationID, models.TenderStatusCreated).
+		Where("version = (SELECT MAX(t.version) FROM tenders t WHERE t.id = tenders.id)").
+		Order("publish_at asc").
+		Limit(limit).
+		Offset(offset).
+		Find(&tenders).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return tenders, nil
+}
+
+// PublishDueTenders publishes every Created tender whose publication time,
+// as set in its latest version, has come and returns their IDs. All
+// versions of a published tender get the new status.
+func (r *tenderRepository) PublishDueTenders(ctx context.Context, now time.Time) ([]string, error) {
+	var ids []string
+	err := conn(ctx, r.db).Raw(`
+		WITH due AS (
+			SELECT id FROM tenders
+			WHERE status = ? AND publish_at IS NOT NULL AND publish_at <= ?
+				AND version = (SELECT MAX(t.version) FROM tenders t WHERE t.id = tenders.id)
+		), published AS (
+			UPDATE tenders SET status = ?, publish_at = NULL
+			WHERE id IN (SELECT id FROM due)
+			RETURNING id
+		)
+		SELECT DISTINCT id FROM published`, models.TenderStatusCreated, now, models.TenderStatusPublished).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *tenderRepository) ExportOrganizationTenders(ctx context.Context, organizationID string, fn func(*models.TenderExportRow) error) error {
+	db := conn(ctx, r.resolver.Reader(ctx))
+	rows, err := db.Raw(`
+		SELECT
+			t.id, t.name, t.service_type, t.status, t.visibility, t.version,
+			t.creator_username, t.created_at, t.publish_at,
+			t.submission_deadline, t.decision_deadline, t.budget, t.currency,
+			(SELECT COUNT(*) FROM bids WHERE bids.tender_id = t.id) AS bid_count,
+			(
+				SELECT COUNT(*) FROM bids
+				JOIN LATERAL (
+					SELECT decision FROM bid_decisions
+					WHERE bid_decisions.bid_id = bids.id
+					ORDER BY created_at DESC
+					LIMIT 1
+				) d ON true
+				WHERE bids.tender_id = t.id AND d.decision = ?
+			) AS approved_bid_count
+		FROM (
+			SELECT DISTINCT ON (id) * FROM tenders
+			WHERE organization_id = ?
+			ORDER BY id, version DESC
+		) t
+		ORDER BY t.created_at, t.id`, models.BidDecisionApproved, organizationID).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.TenderExportRow
+		if err := db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
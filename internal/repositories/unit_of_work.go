@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	database "zadanie-6105/internal/db"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+const (
+	maxTxAttempts  = 5
+	txRetryBackoff = 20 * time.Millisecond
+)
+
+type txContextKey struct{}
+
+// UnitOfWork runs several repository calls in one database transaction.
+// Repositories pick the transaction up from the context passed to fn, so a
+// service only has to thread ctx through as usual.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type unitOfWork struct {
+	db        *gorm.DB
+	isolation sql.IsolationLevel
+}
+
+// NewUnitOfWork returns a UnitOfWork running serializable transactions on
+// the primary. Serialization failures and deadlocks are retried.
+func NewUnitOfWork(resolver *database.Resolver) UnitOfWork {
+	return &unitOfWork{db: resolver.Primary(), isolation: sql.LevelSerializable}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Nested calls join the outer transaction
+	if _, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	var err error
+	backoff := txRetryBackoff
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txContextKey{}, tx))
+		}, &sql.TxOptions{Isolation: u.isolation})
+
+		if err == nil || !isRetryableTxError(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return err
+}
+
+// isRetryableTxError reports serialization_failure and deadlock_detected,
+// and a unique violation of the audit chain: a transaction whose snapshot
+// predates the latest audit event links to a stale chain head and has to
+// start over.
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if pgErr.Code == "23505" && pgErr.ConstraintName == "idx_audit_events_prev_hash" {
+			return true
+		}
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	return false
+}
+
+// conn returns the transaction bound to ctx by UnitOfWork.Do, or db otherwise.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"zadanie-6105/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	GetSubscriptionByID(ctx context.Context, id string) (*models.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context, organizationID string) ([]*models.WebhookSubscription, error)
+	// GetMatchingSubscriptions returns the organization's subscriptions that
+	// receive events of eventType.
+	GetMatchingSubscriptions(ctx context.Context, organizationID string, eventType models.DomainEventType) ([]*models.WebhookSubscription, error)
+	// DeleteSubscription removes the subscription and its deliveries.
+	DeleteSubscription(ctx context.Context, id string) error
+
+	CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error
+	GetDeliveryByID(ctx context.Context, id string) (*models.WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, subscriptionID string, status models.WebhookDeliveryStatus, limit, offset int) ([]*models.WebhookDelivery, error)
+	// ClaimDueDeliveries leases up to limit pending deliveries due at now to
+	// the caller until now+lease, so that they can be sent outside of a
+	// transaction without another dispatcher picking them up.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return conn(ctx, r.db).Create(subscription).Error
+}
+
+func (r *webhookRepository) GetSubscriptionByID(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := conn(ctx, r.db).First(&subscription, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *webhookRepository) GetSubscriptions(ctx context.Context, organizationID string) ([]*models.WebhookSubscription, error) {
+	var subscriptions []*models.WebhookSubscription
+	err := conn(ctx, r.db).
+		Where("organization_id = ?", organizationID).
+		Order("created_at").
+		Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *webhookRepository) GetMatchingSubscriptions(ctx context.Context, organizationID string, eventType models.DomainEventType) ([]*models.WebhookSubscription, error) {
+	filter, err := models.WebhookEventTypes{eventType}.Value()
+	if err != nil {
+		return nil, err
+	}
+
+	var subscriptions []*models.WebhookSubscription
+	err = conn(ctx, r.db).
+		Where("organization_id = ?", organizationID).
+		Where("event_types IS NULL OR event_types = '[]'::jsonb OR event_types @> ?::jsonb", filter).
+		Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	db := conn(ctx, r.db)
+	if err := db.Delete(&models.WebhookDelivery{}, "subscription_id = ?", id).Error; err != nil {
+		return err
+	}
+	return db.Delete(&models.WebhookSubscription{}, "id = ?", id).Error
+}
+
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Create(deliveries).Error
+}
+
+func (r *webhookRepository) GetDeliveryByID(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := conn(ctx, r.db).First(&delivery, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) GetDeliveries(ctx context.Context, subscriptionID string, status models.WebhookDeliveryStatus, limit, offset int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	query := conn(ctx, r.db).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := conn(ctx, r.db).Raw(`
+		WITH claimed AS (
+			UPDATE webhook_deliveries SET locked_until = ?
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until <= ?)
+				ORDER BY next_attempt_at
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT * FROM claimed ORDER BY next_attempt_at`,
+		now.Add(lease), models.WebhookDeliveryPending, now, now, limit).
+		Scan(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return conn(ctx, r.db).Save(delivery).Error
+}
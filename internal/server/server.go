@@ -5,41 +5,130 @@ import (
 	"net/http"
 	"time"
 
+	"zadanie-6105/internal/audit"
 	"zadanie-6105/internal/config"
+	database "zadanie-6105/internal/db"
 	"zadanie-6105/internal/handlers"
 	"zadanie-6105/internal/middlewares"
+	"zadanie-6105/internal/notify"
 	"zadanie-6105/internal/repositories"
 	"zadanie-6105/internal/services"
+	"zadanie-6105/internal/storage"
 
 	"github.com/gorilla/mux"
-	"gorm.io/gorm"
 )
 
 type Server struct {
 	httpServer *http.Server
+	// Stops background jobs started by NewServer
+	stopJobs context.CancelFunc
 }
 
-func NewServer(cfg *config.Config, db *gorm.DB) (*Server, error) {
-	tenderRepo := repositories.NewTenderRepository(db)
-	bidRepo := repositories.NewBidRepository(db)
+func NewServer(cfg *config.Config, resolver *database.Resolver) (*Server, error) {
+	db := resolver.Primary()
+
+	tenderRepo := repositories.NewTenderRepository(resolver)
+	bidRepo := repositories.NewBidRepository(resolver)
 	employeeRepo := repositories.NewEmployeeRepository(db)
 	organizationRepo := repositories.NewOrganizationRepository(db)
+	idempotencyRepo := repositories.NewIdempotencyRepository(db)
+	evaluationRepo := repositories.NewEvaluationRepository(db)
+	attachmentRepo := repositories.NewAttachmentRepository(db)
+	questionRepo := repositories.NewTenderQuestionRepository(db)
+	invitationRepo := repositories.NewTenderInvitationRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
+	emailRepo := repositories.NewEmailRepository(db)
+	streamRepo := repositories.NewStreamRepository(db)
+	reviewRoomRepo := repositories.NewReviewRoomRepository(db)
+
+	attachmentStorage, err := storage.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Email notifications are enabled by configuring an SMTP relay
+	var emailChannel *notify.SMTPChannel
+	if cfg.SMTPHost != "" {
+		emailChannel, err = notify.NewSMTPChannel(notify.SMTPOptions{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			Timeout:  cfg.SMTPTimeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	uow := repositories.NewUnitOfWork(resolver)
 
-	tenderService := services.NewTenderService(tenderRepo)
-	bidService := services.NewBidService(bidRepo, employeeRepo, organizationRepo)
+	auditService, err := NewAuditService(cfg, resolver)
+	if err != nil {
+		return nil, err
+	}
+	notificationService := services.NewNotificationService(notificationRepo, tenderRepo, employeeRepo, uow)
+	eventPublisher := services.NewEventPublisher(outboxRepo)
+	eventStream := services.NewEventStream(streamRepo, bidRepo, cfg.StreamRetention)
+	eventPublisher.Subscribe(notificationService.HandleEvent)
+	eventPublisher.Subscribe(eventStream.HandleEvent)
+	if emailChannel != nil {
+		eventPublisher.Subscribe(services.NewEmailNotifier(emailRepo, notificationRepo, tenderRepo, employeeRepo, cfg.EmailDefaultLanguage).HandleEvent)
+	}
+	tenderService := services.NewTenderService(tenderRepo, uow, auditService, eventPublisher, cfg.TenderImportMaxRows)
+	bidService := services.NewBidService(bidRepo, employeeRepo, organizationRepo, invitationRepo, uow, auditService, eventPublisher, cfg.ReviewEditWindow, services.BidRules{
+		SingleActiveBid:            cfg.BidSingleActive,
+		AllowWithdrawAfterDecision: cfg.BidWithdrawAfterDecision,
+	})
+	evaluationService := services.NewEvaluationService(evaluationRepo, tenderRepo, bidRepo, employeeRepo, uow)
+	attachmentService := services.NewAttachmentService(attachmentRepo, tenderRepo, bidRepo, employeeRepo, attachmentStorage, cfg.AttachmentMaxSize, cfg.AttachmentAllowedTypes)
+	questionService := services.NewTenderQuestionService(questionRepo, tenderRepo, employeeRepo, uow)
+	invitationService := services.NewTenderInvitationService(invitationRepo, tenderRepo, organizationRepo, employeeRepo, uow)
+	webhookService := services.NewWebhookService(webhookRepo, tenderRepo, employeeRepo, uow, cfg.WebhookAllowPrivateTargets)
+	exportService := services.NewExportService(tenderRepo, bidRepo)
+	protocolService := services.NewProtocolService(tenderRepo, bidRepo, organizationRepo)
+	reviewRooms := services.NewReviewRooms(reviewRoomRepo, bidRepo, eventStream, cfg.ReviewRoomPresenceTTL)
 
 	tenderHandler := handlers.NewTenderHandler(tenderService)
+	exportHandler := handlers.NewExportHandler(exportService)
+	protocolHandler := handlers.NewProtocolHandler(protocolService)
 	bidHandler := handlers.NewBidHandler(bidService)
+	evaluationHandler := handlers.NewEvaluationHandler(evaluationService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+	questionHandler := handlers.NewTenderQuestionHandler(questionService)
+	invitationHandler := handlers.NewTenderInvitationHandler(invitationService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	streamHandler := handlers.NewStreamHandler(eventStream, cfg.StreamHeartbeatInterval)
+	reviewRoomHandler := handlers.NewReviewRoomHandler(reviewRooms, cfg.StreamHeartbeatInterval, cfg.ReviewRoomAllowedOrigins)
 
 	router := mux.NewRouter()
 
 	authMiddleware := middlewares.AuthMiddleware(db)
 
 	apiRouter := router.PathPrefix("/api").Subrouter()
+	apiRouter.Use(middlewares.RequestInfoMiddleware(cfg.TrustProxyHeaders))
+	apiRouter.Use(middlewares.ConsistencyMiddleware(cfg.ForcePrimaryWindow))
 	apiRouter.Use(authMiddleware)
+	apiRouter.Use(middlewares.IdempotencyMiddleware(idempotencyRepo, cfg.IdempotencyKeyTTL, "/api/tenders/new", "/api/bids/new"))
 
+	exportHandler.RegisterRoutes(apiRouter)
 	tenderHandler.RegisterRoutes(apiRouter)
+	protocolHandler.RegisterRoutes(apiRouter)
 	bidHandler.RegisterRoutes(apiRouter)
+	evaluationHandler.RegisterRoutes(apiRouter)
+	attachmentHandler.RegisterRoutes(apiRouter)
+	questionHandler.RegisterRoutes(apiRouter)
+	invitationHandler.RegisterRoutes(apiRouter)
+	auditHandler.RegisterRoutes(apiRouter)
+	webhookHandler.RegisterRoutes(apiRouter)
+	notificationHandler.RegisterRoutes(apiRouter)
+	streamHandler.RegisterRoutes(apiRouter)
+	reviewRoomHandler.RegisterRoutes(apiRouter)
 
 	srv := &http.Server{
 		Addr:         cfg.ServerAddress,
@@ -48,15 +137,63 @@ func NewServer(cfg *config.Config, db *gorm.DB) (*Server, error) {
 		ReadTimeout:  15 * time.Second,
 	}
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	go services.NewIdempotencySweeper(idempotencyRepo, cfg.IdempotencySweepInterval).Run(jobsCtx)
+	go services.NewTenderScheduler(tenderRepo, repositories.NewJobLocker(resolver), auditService, eventPublisher, cfg.TenderSchedulerInterval).Run(jobsCtx)
+	go services.NewWebhookDispatcher(outboxRepo, webhookRepo, uow, services.NewWebhookClient(cfg.WebhookTimeout, cfg.WebhookAllowPrivateTargets), services.WebhookDispatchOptions{
+		BatchSize:       100,
+		MaxAttempts:     cfg.WebhookMaxAttempts,
+		RetryBackoff:    cfg.WebhookRetryBackoff,
+		MaxRetryBackoff: cfg.WebhookMaxRetryBackoff,
+		Lease:           2 * cfg.WebhookTimeout,
+	}, cfg.WebhookDispatchInterval).Run(jobsCtx)
+	go eventStream.Run(jobsCtx)
+	go reviewRooms.Run(jobsCtx)
+	if emailChannel != nil {
+		go services.NewEmailSender(emailRepo, emailChannel, services.EmailSendOptions{
+			BatchSize:       100,
+			MaxAttempts:     cfg.EmailMaxAttempts,
+			RetryBackoff:    cfg.EmailRetryBackoff,
+			MaxRetryBackoff: cfg.EmailMaxRetryBackoff,
+			Lease:           2 * cfg.SMTPTimeout,
+		}, cfg.EmailSendInterval).Run(jobsCtx)
+	}
+	if cfg.AuditSigningKey != "" {
+		go services.NewAuditDigester(auditService, repositories.NewJobLocker(resolver), cfg.AuditDigestInterval).Run(jobsCtx)
+	}
+
 	return &Server{
 		httpServer: srv,
+		stopJobs:   stopJobs,
 	}, nil
 }
 
+// NewAuditService builds the audit service shared by the HTTP server and the
+// audit commands of cmd/app.
+func NewAuditService(cfg *config.Config, resolver *database.Resolver) (*services.AuditService, error) {
+	var signer *audit.Signer
+	if cfg.AuditSigningKey != "" {
+		var err error
+		if signer, err = audit.NewSigner(cfg.AuditSigningKey); err != nil {
+			return nil, err
+		}
+	}
+
+	db := resolver.Primary()
+	return services.NewAuditService(
+		repositories.NewAuditRepository(db),
+		repositories.NewEmployeeRepository(db),
+		repositories.NewTenderRepository(resolver),
+		repositories.NewUnitOfWork(resolver),
+		signer,
+	), nil
+}
+
 func (s *Server) Start() error {
 	return s.httpServer.ListenAndServe()
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopJobs()
 	return s.httpServer.Shutdown(ctx)
 }
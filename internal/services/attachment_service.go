@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+	"zadanie-6105/internal/storage"
+
+	"github.com/gabriel-vasile/mimetype"
+	"gorm.io/gorm"
+)
+
+// Bytes read from the start of a file to detect its type
+const mimeSniffLength = 3072
+
+type AttachmentService struct {
+	attachmentRepo repositories.AttachmentRepository
+	tenderRepo     repositories.TenderRepository
+	bidRepo        repositories.BidRepository
+	employeeRepo   repositories.EmployeeRepository
+	storage        storage.Storage
+	maxSize        int64
+	allowedTypes   []string
+}
+
+func NewAttachmentService(
+	attachmentRepo repositories.AttachmentRepository,
+	tenderRepo repositories.TenderRepository,
+	bidRepo repositories.BidRepository,
+	employeeRepo repositories.EmployeeRepository,
+	storage storage.Storage,
+	maxSize int64,
+	allowedTypes []string,
+) *AttachmentService {
+	return &AttachmentService{
+		attachmentRepo: attachmentRepo,
+		tenderRepo:     tenderRepo,
+		bidRepo:        bidRepo,
+		employeeRepo:   employeeRepo,
+		storage:        storage,
+		maxSize:        maxSize,
+		allowedTypes:   allowedTypes,
+	}
+}
+
+func (s *AttachmentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// IsUserAuthorizedToViewAttachments follows the visibility of the owner:
+// attachments of published or closed tenders are visible to everyone who can
+// see the tender, the rest only to the tender's responsible employees. Bid attachments are
+// visible to the bid author and to those responsible for its tender.
+func (s *AttachmentService) IsUserAuthorizedToViewAttachments(ctx context.Context, username string, ownerType models.AttachmentOwnerType, ownerID string) (bool, error) {
+	switch ownerType {
+	case models.AttachmentOwnerTender:
+		tender, err := s.tenderRepo.GetTenderByID(ctx, ownerID)
+		if err != nil {
+			return false, err
+		}
+		if tender.Status == models.TenderStatusPublished || tender.Status == models.TenderStatusClosed {
+			return s.tenderRepo.IsTenderVisibleTo(ctx, ownerID, username, "")
+		}
+		return s.tenderRepo.IsUserResponsibleForTender(username, ownerID)
+	case models.AttachmentOwnerBid:
+		author, err := s.bidRepo.IsUserAuthorizedForBid(ctx, username, ownerID)
+		if err != nil || author {
+			return author, err
+		}
+		return s.bidRepo.IsUserResponsibleForBidTender(ctx, username, ownerID)
+	default:
+		return false, nil
+	}
+}
+
+// IsUserAuthorizedToModifyAttachments allows the same users who may edit the
+// owner: the tender's responsible employees or the bid author.
+func (s *AttachmentService) IsUserAuthorizedToModifyAttachments(ctx context.Context, username string, ownerType models.AttachmentOwnerType, ownerID string) (bool, error) {
+	switch ownerType {
+	case models.AttachmentOwnerTender:
+		return s.tenderRepo.IsUserResponsibleForTender(username, ownerID)
+	case models.AttachmentOwnerBid:
+		return s.bidRepo.IsUserAuthorizedForBid(ctx, username, ownerID)
+	default:
+		return false, nil
+	}
+}
+
+// OwnerExists reports whether the tender or bid an attachment belongs to exists.
+func (s *AttachmentService) OwnerExists(ctx context.Context, ownerType models.AttachmentOwnerType, ownerID string) (bool, error) {
+	switch ownerType {
+	case models.AttachmentOwnerTender:
+		return s.bidRepo.IsTenderExists(ctx, ownerID)
+	case models.AttachmentOwnerBid:
+		if _, err := s.bidRepo.GetBidByID(ctx, ownerID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// UploadAttachment spools the file to disk while computing its checksum and
+// enforcing the size limit, checks the detected MIME type against the allow
+// list and only then writes it to storage and records the metadata.
+func (s *AttachmentService) UploadAttachment(ctx context.Context, ownerType models.AttachmentOwnerType, ownerID, username, fileName string, file io.Reader) (*models.Attachment, error) {
+	uploaderID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(file, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > s.maxSize {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrAttachmentTooLarge, s.maxSize)
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidAttachment)
+	}
+
+	header := make([]byte, mimeSniffLength)
+	n, err := tmp.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	detected := mimetype.Detect(header[:n])
+	if !mimetype.EqualsAny(detected.String(), s.allowedTypes...) {
+		return nil, fmt.Errorf("%w: %s", ErrAttachmentTypeNotAllowed, detected.String())
+	}
+
+	key, err := newStorageKey(ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := s.storage.Put(ctx, key, tmp, size, detected.String()); err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	attachment := &models.Attachment{
+		OwnerType:   ownerType,
+		OwnerID:     ownerID,
+		FileName:    sanitizeFileName(fileName),
+		ContentType: detected.String(),
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  key,
+		UploadedBy:  uploaderID,
+	}
+	if err := s.attachmentRepo.CreateAttachment(ctx, attachment); err != nil {
+		if delErr := s.storage.Delete(context.WithoutCancel(ctx), key); delErr != nil {
+			log.Printf("Failed to remove orphaned attachment %s: %v", key, delErr)
+		}
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (s *AttachmentService) GetAttachments(ctx context.Context, ownerType models.AttachmentOwnerType, ownerID string) ([]*models.Attachment, error) {
+	return s.attachmentRepo.GetAttachments(ctx, ownerType, ownerID)
+}
+
+func (s *AttachmentService) GetAttachment(ctx context.Context, id string) (*models.Attachment, error) {
+	return s.attachmentRepo.GetAttachmentByID(ctx, id)
+}
+
+func (s *AttachmentService) OpenAttachment(ctx context.Context, attachment *models.Attachment) (io.ReadCloser, error) {
+	return s.storage.Get(ctx, attachment.StorageKey)
+}
+
+// DeleteAttachment removes the metadata first so the file is never listed
+// without contents; a failure to delete the object only leaves an orphan.
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, attachment *models.Attachment) error {
+	if err := s.attachmentRepo.DeleteAttachment(ctx, attachment.ID); err != nil {
+		return err
+	}
+	if err := s.storage.Delete(ctx, attachment.StorageKey); err != nil {
+		log.Printf("Failed to delete attachment object %s: %v", attachment.StorageKey, err)
+	}
+	return nil
+}
+
+func newStorageKey(ownerType models.AttachmentOwnerType, ownerID string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%ss/%s/%s", strings.ToLower(string(ownerType)), ownerID, hex.EncodeToString(buf)), nil
+}
+
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
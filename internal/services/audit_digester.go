@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"zadanie-6105/internal/repositories"
+)
+
+// AuditDigester signs a digest of the audit chain for every completed UTC
+// day. Like TenderScheduler it runs on every replica and only the holder of
+// the advisory lock does the work.
+type AuditDigester struct {
+	audit    *AuditService
+	locker   repositories.JobLocker
+	interval time.Duration
+}
+
+func NewAuditDigester(audit *AuditService, locker repositories.JobLocker, interval time.Duration) *AuditDigester {
+	return &AuditDigester{audit: audit, locker: locker, interval: interval}
+}
+
+// Run ticks until ctx is canceled.
+func (d *AuditDigester) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.tick(ctx); err != nil {
+				log.Printf("Audit digester failed: %v", err)
+			}
+		}
+	}
+}
+
+func (d *AuditDigester) tick(ctx context.Context) error {
+	_, err := d.locker.RunExclusive(ctx, repositories.AuditDigestLockID, func(ctx context.Context) error {
+		digests, err := d.audit.CreateDueDigests(ctx, time.Now())
+		for _, digest := range digests {
+			log.Printf("Audit digest for %s signed: %d events, head %s", digest.Day.Format("2006-01-02"), digest.EventCount, digest.HeadHash)
+		}
+		return err
+	})
+	return err
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"zadanie-6105/internal/audit"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// auditVerifyBatchSize is how many events VerifyChain loads at a time.
+const auditVerifyBatchSize = 1000
+
+type AuditService struct {
+	auditRepo    repositories.AuditRepository
+	employeeRepo repositories.EmployeeRepository
+	tenderRepo   repositories.TenderRepository
+	uow          repositories.UnitOfWork
+	// Signs daily digests; nil when AUDIT_SIGNING_KEY is not configured
+	signer *audit.Signer
+}
+
+func NewAuditService(
+	auditRepo repositories.AuditRepository,
+	employeeRepo repositories.EmployeeRepository,
+	tenderRepo repositories.TenderRepository,
+	uow repositories.UnitOfWork,
+	signer *audit.Signer,
+) *AuditService {
+	return &AuditService{
+		auditRepo:    auditRepo,
+		employeeRepo: employeeRepo,
+		tenderRepo:   tenderRepo,
+		uow:          uow,
+		signer:       signer,
+	}
+}
+
+// Record appends an audit event for a mutation. It must be called inside
+// the unit of work that performs the mutation so that the event is written
+// if and only if the change is committed. The actor, request ID and client
+// IP are taken from ctx.
+func (s *AuditService) Record(
+	ctx context.Context,
+	action models.AuditAction,
+	entityType models.AuditEntityType,
+	entityID, organizationID string,
+	before, after interface{},
+) error {
+	actor := audit.ActorFromContext(ctx)
+	request := audit.RequestInfoFromContext(ctx)
+
+	event := &models.AuditEvent{
+		ActorUsername:  actor.Username,
+		ActorOrgID:     actor.OrganizationID,
+		Action:         action,
+		EntityType:     entityType,
+		EntityID:       entityID,
+		OrganizationID: organizationID,
+		RequestID:      request.RequestID,
+		ClientIP:       request.ClientIP,
+	}
+
+	if actor.Username != "" && actor != audit.SystemActor {
+		actorID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, actor.Username)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if actorID != "" {
+			event.ActorID = &actorID
+		}
+	}
+
+	var err error
+	if event.Before, err = snapshot(before); err != nil {
+		return err
+	}
+	if event.After, err = snapshot(after); err != nil {
+		return err
+	}
+
+	prevHash, now, err := s.auditRepo.LockChainHead(ctx)
+	if err != nil {
+		return err
+	}
+	event.OccurredAt = now.UTC().Truncate(time.Microsecond)
+	event.PrevHash = prevHash
+	if event.Hash, err = audit.Hash(prevHash, event); err != nil {
+		return err
+	}
+
+	return s.auditRepo.CreateEvent(ctx, event)
+}
+
+func snapshot(state interface{}) (models.AuditSnapshot, error) {
+	if state == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	return models.AuditSnapshot(data), nil
+}
+
+func (s *AuditService) IsUserAuthorizedToViewAudit(username, organizationID string) (bool, error) {
+	return s.tenderRepo.IsUserResponsibleForOrganization(username, organizationID)
+}
+
+func (s *AuditService) GetEvents(ctx context.Context, filter models.AuditFilter, limit, offset int) ([]*models.AuditEvent, error) {
+	return s.auditRepo.GetEvents(ctx, filter, limit, offset)
+}
+
+// AuditChainBreak is the first inconsistency found by VerifyChain.
+type AuditChainBreak struct {
+	EventID int64
+	Reason  string
+}
+
+// VerifyChain walks the audit trail in chain order, recomputing every hash,
+// and then checks the stored daily digests against the chain. It returns the
+// number of verified events and the first broken link, if any.
+func (s *AuditService) VerifyChain(ctx context.Context) (int64, *AuditChainBreak, error) {
+	var verified, lastID int64
+	prevHash := ""
+	hashes := map[int64]string{}
+
+	digests, err := s.auditRepo.GetDigests(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, digest := range digests {
+		hashes[digest.LastEventID] = ""
+	}
+
+	for {
+		events, err := s.auditRepo.GetEventsAfter(ctx, lastID, auditVerifyBatchSize)
+		if err != nil {
+			return verified, nil, err
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		for _, event := range events {
+			if event.PrevHash != prevHash {
+				return verified, &AuditChainBreak{EventID: event.ID, Reason: "previous hash does not match the preceding event"}, nil
+			}
+			hash, err := audit.Hash(prevHash, event)
+			if err != nil {
+				return verified, nil, err
+			}
+			if event.Hash != hash {
+				return verified, &AuditChainBreak{EventID: event.ID, Reason: "event content does not match its hash"}, nil
+			}
+			if _, ok := hashes[event.ID]; ok {
+				hashes[event.ID] = hash
+			}
+
+			prevHash = hash
+			lastID = event.ID
+			verified++
+		}
+	}
+
+	prevHead := ""
+	for _, digest := range digests {
+		day := digest.Day.Format(audit.DayLayout)
+
+		if s.signer != nil {
+			payload, err := json.Marshal(audit.NewDigestPayload(digest))
+			if err != nil {
+				return verified, nil, err
+			}
+			if err := s.signer.Verify(payload, digest.Signature); err != nil {
+				return verified, &AuditChainBreak{EventID: digest.LastEventID, Reason: fmt.Sprintf("digest %s: %v", day, err)}, nil
+			}
+		}
+		if digest.PrevHash != prevHead {
+			return verified, &AuditChainBreak{EventID: digest.FirstEventID, Reason: fmt.Sprintf("digest %s does not continue the previous digest", day)}, nil
+		}
+		if digest.LastEventID != 0 && hashes[digest.LastEventID] != digest.HeadHash {
+			return verified, &AuditChainBreak{EventID: digest.LastEventID, Reason: fmt.Sprintf("digest %s head does not match the chain", day)}, nil
+		}
+		prevHead = digest.HeadHash
+	}
+
+	return verified, nil, nil
+}
+
+// CreateDueDigests creates the digests of all completed UTC days since the
+// last digest, or since the first audit event.
+func (s *AuditService) CreateDueDigests(ctx context.Context, now time.Time) ([]*models.AuditDigest, error) {
+	if s.signer == nil {
+		return nil, ErrAuditSigningKeyMissing
+	}
+
+	var day time.Time
+	last, err := s.auditRepo.GetLastDigest(ctx)
+	switch {
+	case err == nil:
+		day = last.Day.AddDate(0, 0, 1)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		first, err := s.auditRepo.GetEventsAfter(ctx, 0, 1)
+		if err != nil || len(first) == 0 {
+			return nil, err
+		}
+		day = first[0].OccurredAt
+	default:
+		return nil, err
+	}
+
+	today := truncateToDay(now)
+	var created []*models.AuditDigest
+	for day = truncateToDay(day); day.Before(today); day = day.AddDate(0, 0, 1) {
+		digest, err := s.createDigest(ctx, day, last)
+		if err != nil {
+			return created, err
+		}
+		created = append(created, digest)
+		last = digest
+	}
+	return created, nil
+}
+
+// createDigest covers the events appended after the previous digest up to
+// the last one that occurred before the end of day.
+func (s *AuditService) createDigest(ctx context.Context, day time.Time, prev *models.AuditDigest) (*models.AuditDigest, error) {
+	digest := &models.AuditDigest{Day: day}
+	if prev != nil {
+		digest.PrevHash = prev.HeadHash
+		digest.HeadHash = prev.HeadHash
+	}
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var afterID int64
+		if prev != nil {
+			afterID = prev.LastEventID
+		}
+
+		var err error
+		digest.FirstEventID, digest.LastEventID, digest.EventCount, err = s.auditRepo.GetDigestRange(ctx, afterID, day.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+
+		if digest.EventCount > 0 {
+			first, err := s.auditRepo.GetEventByID(ctx, digest.FirstEventID)
+			if err != nil {
+				return err
+			}
+			last, err := s.auditRepo.GetEventByID(ctx, digest.LastEventID)
+			if err != nil {
+				return err
+			}
+			digest.PrevHash = first.PrevHash
+			digest.HeadHash = last.Hash
+		} else if prev != nil {
+			digest.LastEventID = prev.LastEventID
+		}
+
+		if digest.Signature, err = s.signer.Sign(audit.NewDigestPayload(digest)); err != nil {
+			return err
+		}
+		return s.auditRepo.CreateDigest(ctx, digest)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return digest, nil
+}
+
+func truncateToDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// ExportDigest returns the signed digest of day for archiving.
+func (s *AuditService) ExportDigest(ctx context.Context, day time.Time) (*audit.SignedDigest, error) {
+	if s.signer == nil {
+		return nil, ErrAuditSigningKeyMissing
+	}
+
+	digest, err := s.auditRepo.GetDigest(ctx, truncateToDay(day))
+	if err != nil {
+		return nil, err
+	}
+	return s.signer.Export(digest)
+}
+
+// VerifyExport checks the signature of an exported digest and that the
+// chain still contains the head it pinned.
+func (s *AuditService) VerifyExport(ctx context.Context, export *audit.SignedDigest) error {
+	if s.signer == nil {
+		return ErrAuditSigningKeyMissing
+	}
+	if err := s.signer.Verify(export.Digest, export.Signature); err != nil {
+		return err
+	}
+
+	var payload audit.DigestPayload
+	if err := json.Unmarshal(export.Digest, &payload); err != nil {
+		return err
+	}
+	if payload.LastEventID == 0 {
+		return nil
+	}
+
+	event, err := s.auditRepo.GetEventByID(ctx, payload.LastEventID)
+	if err != nil {
+		return err
+	}
+	if event.Hash != payload.HeadHash {
+		return fmt.Errorf("%w: event %d has hash %s, digest pinned %s", ErrAuditDigestMismatch, event.ID, event.Hash, payload.HeadHash)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"zadanie-6105/internal/audit"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+)
+
+// chainAuditRepository serves a fixed audit trail; the methods VerifyChain
+// does not use are left to the embedded nil interface.
+type chainAuditRepository struct {
+	repositories.AuditRepository
+	events  []*models.AuditEvent
+	digests []*models.AuditDigest
+}
+
+func (r *chainAuditRepository) GetEventsAfter(ctx context.Context, afterID int64, limit int) ([]*models.AuditEvent, error) {
+	var events []*models.AuditEvent
+	for _, event := range r.events {
+		if event.ID > afterID && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *chainAuditRepository) GetDigests(ctx context.Context) ([]*models.AuditDigest, error) {
+	return r.digests, nil
+}
+
+// auditTrail returns a chain of n valid events, one per day, and a signed
+// digest for each day.
+func auditTrail(t *testing.T, signer *audit.Signer, n int) ([]*models.AuditEvent, []*models.AuditDigest) {
+	t.Helper()
+
+	var events []*models.AuditEvent
+	var digests []*models.AuditDigest
+	prevHash := ""
+	for i := 1; i <= n; i++ {
+		day := time.Date(2026, 10, i, 0, 0, 0, 0, time.UTC)
+		event := &models.AuditEvent{
+			ID:             int64(i),
+			OccurredAt:     day.Add(time.Hour),
+			ActorUsername:  "user1",
+			Action:         models.AuditTenderUpdated,
+			EntityType:     models.AuditEntityTender,
+			EntityID:       "7b0e8c44-1b7e-4c55-8a3e-5d2f1c0b9a11",
+			OrganizationID: "0e6a5b2d-3c4f-4a1b-9e8d-7c6b5a4f3e2d",
+			After:          models.AuditSnapshot(`{"version":` + strconv.Itoa(i) + `}`),
+			PrevHash:       prevHash,
+		}
+		var err error
+		if event.Hash, err = audit.Hash(prevHash, event); err != nil {
+			t.Fatalf("Hash() error = %v", err)
+		}
+
+		digest := &models.AuditDigest{
+			Day:          day,
+			FirstEventID: event.ID,
+			LastEventID:  event.ID,
+			EventCount:   1,
+			PrevHash:     prevHash,
+			HeadHash:     event.Hash,
+		}
+		if digest.Signature, err = signer.Sign(audit.NewDigestPayload(digest)); err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+
+		events = append(events, event)
+		digests = append(digests, digest)
+		prevHash = event.Hash
+	}
+	return events, digests
+}
+
+func TestVerifyChain(t *testing.T) {
+	signer, err := audit.NewSigner(base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize)))
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	otherSigner, err := audit.NewSigner(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", ed25519.SeedSize))))
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		signer *audit.Signer
+		// tamper changes the valid trail of three events
+		tamper       func(events []*models.AuditEvent, digests []*models.AuditDigest) ([]*models.AuditEvent, []*models.AuditDigest)
+		wantVerified int64
+		wantBreakID  int64
+		wantReason   string
+	}{
+		{name: "valid", signer: signer, wantVerified: 3},
+		{name: "valid without signing key", wantVerified: 3},
+		{
+			name:   "changed content",
+			signer: signer,
+			tamper: func(events []*models.AuditEvent, digests []*models.AuditDigest) ([]*models.AuditEvent, []*models.AuditDigest) {
+				events[1].ActorUsername = "user2"
+				return events, digests
+			},
+			wantVerified: 1,
+			wantBreakID:  2,
+			wantReason:   "event content does not match its hash",
+		},
+		{
+			name:   "removed event",
+			signer: signer,
+			tamper: func(events []*models.AuditEvent, digests []*models.AuditDigest) ([]*models.AuditEvent, []*models.AuditDigest) {
+				return append(events[:1], events[2:]...), digests
+			},
+			wantVerified: 1,
+			wantBreakID:  3,
+			wantReason:   "previous hash does not match the preceding event",
+		},
+		{
+			name:   "rehashed tail",
+			signer: signer,
+			tamper: func(events []*models.AuditEvent, digests []*models.AuditDigest) ([]*models.AuditEvent, []*models.AuditDigest) {
+				// Rewriting the last event and its hash keeps the chain intact
+				// but no longer matches the signed digest
+				events[2].ActorUsername = "user2"
+				events[2].Hash, _ = audit.Hash(events[2].PrevHash, events[2])
+				return events, digests
+			},
+			wantVerified: 3,
+			wantBreakID:  3,
+			wantReason:   "digest 2026-10-03 head does not match the chain",
+		},
+		{
+			name:   "foreign signature",
+			signer: otherSigner,
+			tamper: func(events []*models.AuditEvent, digests []*models.AuditDigest) ([]*models.AuditEvent, []*models.AuditDigest) {
+				return events, digests
+			},
+			wantVerified: 3,
+			wantBreakID:  1,
+			wantReason:   "digest 2026-10-01: invalid digest signature",
+		},
+		{
+			name:   "missing digest",
+			signer: signer,
+			tamper: func(events []*models.AuditEvent, digests []*models.AuditDigest) ([]*models.AuditEvent, []*models.AuditDigest) {
+				return events, append(digests[:1], digests[2:]...)
+			},
+			wantVerified: 3,
+			wantBreakID:  3,
+			wantReason:   "digest 2026-10-03 does not continue the previous digest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, digests := auditTrail(t, signer, 3)
+			if tt.tamper != nil {
+				events, digests = tt.tamper(events, digests)
+			}
+			service := NewAuditService(&chainAuditRepository{events: events, digests: digests}, nil, nil, nil, tt.signer)
+
+			verified, broken, err := service.VerifyChain(context.Background())
+			if err != nil {
+				t.Fatalf("VerifyChain() error = %v", err)
+			}
+			if verified != tt.wantVerified {
+				t.Errorf("verified = %d, want %d", verified, tt.wantVerified)
+			}
+
+			if tt.wantReason == "" {
+				if broken != nil {
+					t.Fatalf("break = %+v, want none", broken)
+				}
+				return
+			}
+			if broken == nil {
+				t.Fatalf("break = nil, want %q at event %d", tt.wantReason, tt.wantBreakID)
+			}
+			if broken.EventID != tt.wantBreakID || broken.Reason != tt.wantReason {
+				t.Errorf("break = %+v, want %q at event %d", broken, tt.wantReason, tt.wantBreakID)
+			}
+		})
+	}
+}
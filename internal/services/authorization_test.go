@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	database "zadanie-6105/internal/db"
+	"zadanie-6105/internal/repositories"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// recordedStatement is a statement built against a dry-run database.
+type recordedStatement struct {
+	sql  string
+	vars []interface{}
+}
+
+// dryRunResolver returns a resolver whose statements are built but never
+// sent to a database, and the statements run through it. Queries return no
+// rows.
+func dryRunResolver(t *testing.T) (*database.Resolver, *[]recordedStatement) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.Open("host=primary"), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	var statements []recordedStatement
+	err = db.Callback().Query().After("gorm:query").Register("test:record", func(tx *gorm.DB) {
+		statements = append(statements, recordedStatement{sql: tx.Statement.SQL.String(), vars: tx.Statement.Vars})
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	return database.NewResolver(db), &statements
+}
+
+// TestResponsibleEmployeeAuthorization runs the checks limited to the
+// employees responsible for a tender's organization through the real
+// repositories, down to the statement sent to the database.
+func TestResponsibleEmployeeAuthorization(t *testing.T) {
+	const tenderID = "7b0e8c44-1b7e-4c55-8a3e-5d2f1c0b9a11"
+
+	tests := []struct {
+		name  string
+		check func(ctx context.Context, bidRepo repositories.BidRepository) (bool, error)
+	}{
+		{
+			name: "review room",
+			check: func(ctx context.Context, bidRepo repositories.BidRepository) (bool, error) {
+				return NewReviewRooms(nil, bidRepo, nil, 0).IsUserAuthorizedToJoin(ctx, "user1", tenderID)
+			},
+		},
+		{
+			name: "bid export",
+			check: func(ctx context.Context, bidRepo repositories.BidRepository) (bool, error) {
+				return NewExportService(nil, bidRepo).IsUserAuthorizedToExportBids(ctx, "user1", tenderID)
+			},
+		},
+		{
+			name: "award protocol",
+			check: func(ctx context.Context, bidRepo repositories.BidRepository) (bool, error) {
+				return NewProtocolService(nil, bidRepo, nil).IsUserAuthorizedToViewProtocol(ctx, "user1", tenderID)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, statements := dryRunResolver(t)
+
+			authorized, err := tt.check(context.Background(), repositories.NewBidRepository(resolver))
+			if err != nil {
+				t.Fatalf("authorization error = %v", err)
+			}
+			// The dry run finds no responsible employee
+			if authorized {
+				t.Error("authorized = true, want false")
+			}
+
+			if len(*statements) != 1 {
+				t.Fatalf("ran %d statements, want 1", len(*statements))
+			}
+			statement := (*statements)[0]
+			if !strings.Contains(statement.sql, "JOIN organization_responsible org_resp ON tenders.organization_id = org_resp.organization_id") {
+				t.Errorf("statement %q does not join the responsible employees of the tender's organization", statement.sql)
+			}
+			if want := []interface{}{tenderID, "user1"}; !reflect.DeepEqual(statement.vars, want) {
+				t.Errorf("statement vars = %v, want %v", statement.vars, want)
+			}
+		})
+	}
+}
@@ -2,25 +2,58 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"time"
 	"zadanie-6105/internal/models"
 	"zadanie-6105/internal/repositories"
+	"zadanie-6105/pkg/utils"
+
+	"gorm.io/gorm"
 )
 
+const recentReviewsLimit = 5
+
+// BidRules are the configurable bid lifecycle rules.
+type BidRules struct {
+	// At most one active (Created or Published) bid per author and tender
+	SingleActiveBid bool
+	// Allow withdrawing or canceling a bid after a decision is recorded
+	AllowWithdrawAfterDecision bool
+}
+
 type BidService struct {
 	bidRepo          repositories.BidRepository
 	employeeRepo     repositories.EmployeeRepository
 	organizationRepo repositories.OrganizationRepository
+	invitationRepo   repositories.TenderInvitationRepository
+	uow              repositories.UnitOfWork
+	audit            *AuditService
+	events           *EventPublisher
+	reviewEditWindow time.Duration
+	rules            BidRules
 }
 
 func NewBidService(
 	bidRepo repositories.BidRepository,
 	employeeRepo repositories.EmployeeRepository,
 	organizationRepo repositories.OrganizationRepository,
+	invitationRepo repositories.TenderInvitationRepository,
+	uow repositories.UnitOfWork,
+	audit *AuditService,
+	events *EventPublisher,
+	reviewEditWindow time.Duration,
+	rules BidRules,
 ) *BidService {
 	return &BidService{
 		bidRepo:          bidRepo,
 		employeeRepo:     employeeRepo,
 		organizationRepo: organizationRepo,
+		invitationRepo:   invitationRepo,
+		uow:              uow,
+		audit:            audit,
+		events:           events,
+		reviewEditWindow: reviewEditWindow,
+		rules:            rules,
 	}
 }
 
@@ -33,7 +66,164 @@ func (s *BidService) IsEmployeeExists(ctx context.Context, employeeID string) (b
 }
 
 func (s *BidService) CreateBid(ctx context.Context, bid *models.Bid) error {
-	return s.bidRepo.CreateBid(ctx, bid)
+	if err := validateBidTerms(bid); err != nil {
+		return err
+	}
+	if err := s.checkSubmissionDeadline(ctx, bid.TenderID); err != nil {
+		return err
+	}
+	if err := s.checkInvitation(ctx, bid); err != nil {
+		return err
+	}
+
+	if bid.Status == "" {
+		bid.Status = models.BidStatusCreated
+	}
+	if !bid.Status.IsActive() {
+		return fmt.Errorf("%w: a new bid must be Created or Published", ErrInvalidBidStatus)
+	}
+
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.checkSingleActiveBid(ctx, bid.TenderID, bid.AuthorID, ""); err != nil {
+			return err
+		}
+		if err := s.bidRepo.CreateBid(ctx, bid); err != nil {
+			return err
+		}
+		if err := s.recordEvent(ctx, models.AuditBidCreated, models.AuditEntityBid, bid.ID, bid.TenderID, nil, bid); err != nil {
+			return err
+		}
+		return s.publishEvent(ctx, models.EventBidCreated, bid, bid)
+	})
+}
+
+// recordEvent audits a change to a bid or one of its records. Bid events
+// belong to the organization that owns the tender, so that its responsible
+// employees see them in the organization's audit log.
+func (s *BidService) recordEvent(
+	ctx context.Context,
+	action models.AuditAction,
+	entityType models.AuditEntityType,
+	entityID, tenderID string,
+	before, after interface{},
+) error {
+	tender, err := s.bidRepo.GetLatestTender(ctx, tenderID)
+	if err != nil {
+		return err
+	}
+	return s.audit.Record(ctx, action, entityType, entityID, tender.OrganizationID, before, after)
+}
+
+// publishEvent emits a domain event about bid to the subscribers of the
+// organization that owns its tender.
+func (s *BidService) publishEvent(ctx context.Context, eventType models.DomainEventType, bid *models.Bid, data interface{}) error {
+	tender, err := s.bidRepo.GetLatestTender(ctx, bid.TenderID)
+	if err != nil {
+		return err
+	}
+	return s.events.Publish(ctx, eventType, tender.OrganizationID, bid.ID, data)
+}
+
+// recordReviewEvent audits a change to a bid review.
+func (s *BidService) recordReviewEvent(ctx context.Context, action models.AuditAction, review *models.BidReview, before, after interface{}) error {
+	bid, err := s.bidRepo.GetBidByID(ctx, review.BidID)
+	if err != nil {
+		return err
+	}
+	return s.recordEvent(ctx, action, models.AuditEntityBidReview, review.ID, bid.TenderID, before, after)
+}
+
+// checkSingleActiveBid enforces BidRules.SingleActiveBid. It runs inside a
+// serializable transaction so concurrent submissions cannot both pass.
+func (s *BidService) checkSingleActiveBid(ctx context.Context, tenderID, authorID, excludeBidID string) error {
+	if !s.rules.SingleActiveBid {
+		return nil
+	}
+	count, err := s.bidRepo.CountActiveBids(ctx, tenderID, authorID, excludeBidID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrActiveBidExists
+	}
+	return nil
+}
+
+// checkWithdrawal blocks taking a decided bid out of the tender unless
+// BidRules.AllowWithdrawAfterDecision is set.
+func (s *BidService) checkWithdrawal(ctx context.Context, bidID string) error {
+	if s.rules.AllowWithdrawAfterDecision {
+		return nil
+	}
+	decided, err := s.bidRepo.HasBidDecision(ctx, bidID)
+	if err != nil {
+		return err
+	}
+	if decided {
+		return ErrBidDecisionRecorded
+	}
+	return nil
+}
+
+// checkInvitation only lets organizations that accepted an invitation, and
+// their employees, bid on invite-only tenders.
+func (s *BidService) checkInvitation(ctx context.Context, bid *models.Bid) error {
+	tender, err := s.bidRepo.GetLatestTender(ctx, bid.TenderID)
+	if err != nil {
+		return err
+	}
+	if tender.Visibility != models.TenderVisibilityInviteOnly {
+		return nil
+	}
+
+	accepted, err := s.invitationRepo.HasAcceptedInvitation(ctx, bid.TenderID, bid.AuthorID)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return ErrTenderInvitationRequired
+	}
+	return nil
+}
+
+func (s *BidService) checkSubmissionDeadline(ctx context.Context, tenderID string) error {
+	tender, err := s.bidRepo.GetLatestTender(ctx, tenderID)
+	if err != nil {
+		return err
+	}
+	if tender.SubmissionDeadline != nil && !time.Now().Before(*tender.SubmissionDeadline) {
+		return ErrSubmissionDeadlinePassed
+	}
+	return nil
+}
+
+func validateBidTerms(bid *models.Bid) error {
+	if bid.Amount.Valid {
+		if bid.Amount.Decimal.IsNegative() {
+			return fmt.Errorf("%w: amount must not be negative", ErrInvalidBidTerms)
+		}
+		if bid.Currency == "" {
+			return fmt.Errorf("%w: currency is required with amount", ErrInvalidBidTerms)
+		}
+	}
+	if bid.Currency != "" && utils.ValidateVar(bid.Currency, "iso4217") != nil {
+		return fmt.Errorf("%w: currency must be an ISO 4217 code", ErrInvalidBidTerms)
+	}
+	if bid.DeliveryDays < 0 {
+		return fmt.Errorf("%w: deliveryDays must not be negative", ErrInvalidBidTerms)
+	}
+	for i, item := range bid.LineItems {
+		if item.Description == "" {
+			return fmt.Errorf("%w: line item %d has no description", ErrInvalidBidTerms, i+1)
+		}
+		if !item.Quantity.IsPositive() {
+			return fmt.Errorf("%w: line item %d quantity must be positive", ErrInvalidBidTerms, i+1)
+		}
+		if item.UnitPrice.IsNegative() {
+			return fmt.Errorf("%w: line item %d unit price must not be negative", ErrInvalidBidTerms, i+1)
+		}
+	}
+	return nil
 }
 
 func (s *BidService) IsTenderExists(ctx context.Context, tenderID string) (bool, error) {
@@ -81,8 +271,27 @@ func (s *BidService) GetUserBids(ctx context.Context, username string, limit, of
 	return s.bidRepo.GetBidsByUser(ctx, username, limit, offset)
 }
 
-func (s *BidService) GetBidsForTender(ctx context.Context, tenderID string, limit, offset int) ([]*models.Bid, error) {
-	return s.bidRepo.GetBidsForTender(ctx, tenderID, limit, offset)
+// GetBidsForTender lists the tender's bids and flags the ones priced above
+// the tender budget in the same currency.
+func (s *BidService) GetBidsForTender(ctx context.Context, tenderID string, filter models.BidListFilter, limit, offset int) ([]*models.Bid, error) {
+	bids, err := s.bidRepo.GetBidsForTender(ctx, tenderID, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	tender, err := s.bidRepo.GetLatestTender(ctx, tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tender.Budget.Valid {
+		for _, bid := range bids {
+			bid.OverBudget = bid.Amount.Valid && bid.Currency == tender.Currency &&
+				bid.Amount.Decimal.GreaterThan(tender.Budget.Decimal)
+		}
+	}
+
+	return bids, nil
 }
 
 func (s *BidService) IsUserAuthorizedToViewBids(ctx context.Context, username, tenderID string) (bool, error) {
@@ -101,30 +310,234 @@ func (s *BidService) IsUserAuthorizedToViewBid(ctx context.Context, username, bi
 	return s.bidRepo.IsUserAuthorizedForBid(ctx, username, bidID)
 }
 
-func (s *BidService) UpdateBidStatus(ctx context.Context, bidID string, status string) error {
-	return s.bidRepo.UpdateBidStatus(ctx, bidID, status)
-}
+// UpdateBidStatus applies the same lifecycle rules as WithdrawBid and
+// ResubmitBid: a decided bid cannot be taken out of the tender and
+// re-activating a bid must not break the single active bid rule.
+func (s *BidService) UpdateBidStatus(ctx context.Context, bidID string, status string, username string, expectedVersion int) error {
+	newStatus := models.BidStatus(status)
+	if !newStatus.IsValid() {
+		return fmt.Errorf("%w: %s", ErrInvalidBidStatus, status)
+	}
 
-func (s *BidService) IsUserAuthorizedToChangeStatus(ctx context.Context, username, bidID string) (bool, error) {
-	return s.bidRepo.IsUserResponsibleForBid(ctx, username, bidID)
-}
+	changedBy, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
 
-func (s *BidService) UpdateBid(ctx context.Context, bidID string, updatedBid *models.Bid) (*models.Bid, error) {
-	existingBid, err := s.bidRepo.GetBidByID(ctx, bidID)
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		bid, err := s.bidRepo.GetBidByID(ctx, bidID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(bid.Version, expectedVersion); err != nil {
+			return err
+		}
+
+		if bid.Status == newStatus {
+			return nil
+		}
+		if bid.Status.IsActive() && !newStatus.IsActive() {
+			if err := s.checkWithdrawal(ctx, bidID); err != nil {
+				return err
+			}
+		}
+		if !bid.Status.IsActive() && newStatus.IsActive() {
+			if err := s.checkSingleActiveBid(ctx, bid.TenderID, bid.AuthorID, bidID); err != nil {
+				return err
+			}
+		}
+
+		// A status change is a new version, so that the ETag changes with it
+		after := *bid
+		after.Status = newStatus
+		after.Version++
+		if err := s.bidRepo.UpdateBid(ctx, &after); err != nil {
+			return err
+		}
+
+		if err := s.bidRepo.CreateBidStatusChange(ctx, &models.BidStatusChange{
+			BidID:      bidID,
+			FromStatus: bid.Status,
+			ToStatus:   newStatus,
+			Version:    after.Version,
+			ChangedBy:  changedBy,
+		}); err != nil {
+			return err
+		}
+
+		if err := s.recordEvent(ctx, models.AuditBidStatusChanged, models.AuditEntityBid, bidID, bid.TenderID, bid, &after); err != nil {
+			return err
+		}
+		return s.publishEvent(ctx, models.EventBidStatusChanged, &after, &after)
+	})
+}
+
+// WithdrawBid takes an active bid out of the tender. The bid keeps its data
+// and history and can be resubmitted with ResubmitBid.
+func (s *BidService) WithdrawBid(ctx context.Context, bidID, username, reason string, expectedVersion int) (*models.Bid, error) {
+	return s.changeBidStatus(ctx, bidID, username, reason, models.AuditBidWithdrawn, expectedVersion, func(ctx context.Context, bid *models.Bid) error {
+		if !bid.Status.IsActive() {
+			return fmt.Errorf("%w: only Created or Published bids can be withdrawn", ErrInvalidBidTransition)
+		}
+		if err := s.checkWithdrawal(ctx, bid.ID); err != nil {
+			return err
+		}
+		bid.Status = models.BidStatusWithdrawn
+		return nil
+	})
+}
+
+// ResubmitBid publishes a withdrawn bid again, subject to the same deadline,
+// invitation and single active bid checks as a new bid.
+func (s *BidService) ResubmitBid(ctx context.Context, bidID, username string, expectedVersion int) (*models.Bid, error) {
+	return s.changeBidStatus(ctx, bidID, username, "", models.AuditBidResubmitted, expectedVersion, func(ctx context.Context, bid *models.Bid) error {
+		if bid.Status != models.BidStatusWithdrawn {
+			return fmt.Errorf("%w: only withdrawn bids can be resubmitted", ErrInvalidBidTransition)
+		}
+		if err := s.checkSubmissionDeadline(ctx, bid.TenderID); err != nil {
+			return err
+		}
+		if err := s.checkInvitation(ctx, bid); err != nil {
+			return err
+		}
+		if err := s.checkSingleActiveBid(ctx, bid.TenderID, bid.AuthorID, bid.ID); err != nil {
+			return err
+		}
+		bid.Status = models.BidStatusPublished
+		return nil
+	})
+}
+
+// changeBidStatus runs transition, which validates and sets the new status,
+// then stores the bid as a new version and records the change.
+func (s *BidService) changeBidStatus(
+	ctx context.Context,
+	bidID, username, reason string,
+	action models.AuditAction,
+	expectedVersion int,
+	transition func(ctx context.Context, bid *models.Bid) error,
+) (*models.Bid, error) {
+	changedBy, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
 	if err != nil {
 		return nil, err
 	}
 
-	if updatedBid.Name != "" {
-		existingBid.Name = updatedBid.Name
+	var bid *models.Bid
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		bid, err = s.bidRepo.GetBidByID(ctx, bidID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(bid.Version, expectedVersion); err != nil {
+			return err
+		}
+
+		before := *bid
+		if err := transition(ctx, bid); err != nil {
+			return err
+		}
+
+		bid.Version++
+		if err := s.bidRepo.UpdateBid(ctx, bid); err != nil {
+			return err
+		}
+
+		if err := s.bidRepo.CreateBidStatusChange(ctx, &models.BidStatusChange{
+			BidID:      bidID,
+			FromStatus: before.Status,
+			ToStatus:   bid.Status,
+			Version:    bid.Version,
+			Reason:     reason,
+			ChangedBy:  changedBy,
+		}); err != nil {
+			return err
+		}
+
+		if err := s.recordEvent(ctx, action, models.AuditEntityBid, bidID, bid.TenderID, &before, bid); err != nil {
+			return err
+		}
+		return s.publishEvent(ctx, models.EventBidStatusChanged, bid, bid)
+	})
+	if err != nil {
+		return nil, err
 	}
-	if updatedBid.Description != "" {
-		existingBid.Description = updatedBid.Description
+
+	return bid, nil
+}
+
+// IsUserAuthorizedToViewBidHistory allows the bid author and the employees
+// responsible for the bid's tender.
+func (s *BidService) IsUserAuthorizedToViewBidHistory(ctx context.Context, username, bidID string) (bool, error) {
+	author, err := s.bidRepo.IsUserAuthorizedForBid(ctx, username, bidID)
+	if err != nil || author {
+		return author, err
 	}
+	return s.bidRepo.IsUserResponsibleForBidTender(ctx, username, bidID)
+}
+
+func (s *BidService) GetBidStatusHistory(ctx context.Context, bidID string) ([]*models.BidStatusChange, error) {
+	return s.bidRepo.GetBidStatusHistory(ctx, bidID)
+}
 
-	existingBid.Version++
+func (s *BidService) IsUserAuthorizedToChangeStatus(ctx context.Context, username, bidID string) (bool, error) {
+	return s.bidRepo.IsUserResponsibleForBid(ctx, username, bidID)
+}
 
-	err = s.bidRepo.UpdateBid(ctx, existingBid)
+func (s *BidService) UpdateBid(ctx context.Context, bidID string, updatedBid *models.Bid, expectedVersion int) (*models.Bid, error) {
+	var existingBid *models.Bid
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		existingBid, err = s.bidRepo.GetBidByID(ctx, bidID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion(existingBid.Version, expectedVersion); err != nil {
+			return err
+		}
+
+		if err := s.checkSubmissionDeadline(ctx, existingBid.TenderID); err != nil {
+			return err
+		}
+
+		before := *existingBid
+
+		if updatedBid.Name != "" {
+			existingBid.Name = updatedBid.Name
+		}
+		if updatedBid.Description != "" {
+			existingBid.Description = updatedBid.Description
+		}
+		if updatedBid.Amount.Valid {
+			existingBid.Amount = updatedBid.Amount
+		}
+		if updatedBid.Currency != "" {
+			existingBid.Currency = updatedBid.Currency
+		}
+		if updatedBid.DeliveryDays != 0 {
+			existingBid.DeliveryDays = updatedBid.DeliveryDays
+		}
+		if updatedBid.ValidUntil != nil {
+			existingBid.ValidUntil = updatedBid.ValidUntil
+		}
+		if updatedBid.LineItems != nil {
+			existingBid.LineItems = updatedBid.LineItems
+		}
+
+		if err := validateBidTerms(existingBid); err != nil {
+			return err
+		}
+
+		existingBid.Version++
+
+		if err := s.bidRepo.UpdateBid(ctx, existingBid); err != nil {
+			return err
+		}
+
+		return s.recordEvent(ctx, models.AuditBidUpdated, models.AuditEntityBid, bidID, existingBid.TenderID, &before, existingBid)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -136,24 +549,64 @@ func (s *BidService) IsUserAuthorizedToEditBid(ctx context.Context, username, bi
 	return s.bidRepo.IsUserAuthorizedForBid(ctx, username, bidID)
 }
 
-func (s *BidService) SubmitBidDecision(ctx context.Context, bidID string, decision string) error {
-	bid, err := s.bidRepo.GetBidByID(ctx, bidID)
-	if err != nil {
-		return err
-	}
-
-	if decision == "Approved" {
-		bid.Status = models.BidStatusPublished
-	} else if decision == "Rejected" {
-		bid.Status = models.BidStatusCanceled
-	}
-
-	err = s.bidRepo.UpdateBid(ctx, bid)
+func (s *BidService) SubmitBidDecision(ctx context.Context, bidID string, decision string, username string, expectedVersion int) error {
+	approverID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		bid, err := s.bidRepo.GetBidByID(ctx, bidID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion(bid.Version, expectedVersion); err != nil {
+			return err
+		}
+
+		before := *bid
+
+		if decision == "Approved" {
+			bid.Status = models.BidStatusPublished
+		} else if decision == "Rejected" {
+			bid.Status = models.BidStatusCanceled
+		}
+		if bid.Status != before.Status {
+			bid.Version++
+		}
+
+		if err := s.bidRepo.UpdateBid(ctx, bid); err != nil {
+			return err
+		}
+
+		bidDecision := &models.BidDecision{
+			BidID:      bidID,
+			Decision:   models.BidDecisionType(decision),
+			ApproverID: approverID,
+		}
+		if err := s.bidRepo.CreateBidDecision(ctx, bidDecision); err != nil {
+			return err
+		}
+
+		if err := s.recordEvent(ctx, models.AuditBidStatusChanged, models.AuditEntityBid, bidID, bid.TenderID, &before, bid); err != nil {
+			return err
+		}
+		if err := s.recordEvent(ctx, models.AuditBidDecisionSubmitted, models.AuditEntityBidDecision, bidDecision.ID, bid.TenderID, nil, bidDecision); err != nil {
+			return err
+		}
+
+		if bid.Status != before.Status {
+			if err := s.publishEvent(ctx, models.EventBidStatusChanged, bid, bid); err != nil {
+				return err
+			}
+		}
+
+		return s.publishEvent(ctx, models.EventBidDecided, bid, &models.BidDecidedEvent{
+			Bid:      bid,
+			Decision: bidDecision,
+		})
+	})
 }
 
 func (s *BidService) IsUserAuthorizedToSubmitDecision(ctx context.Context, username, bidID string) (bool, error) {
@@ -161,7 +614,16 @@ func (s *BidService) IsUserAuthorizedToSubmitDecision(ctx context.Context, usern
 }
 
 func (s *BidService) DeleteBid(ctx context.Context, id string) error {
-	return s.bidRepo.DeleteBid(ctx, id)
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		bid, err := s.bidRepo.GetBidByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.bidRepo.DeleteBid(ctx, id); err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, models.AuditBidDeleted, models.AuditEntityBid, id, bid.TenderID, bid, nil)
+	})
 }
 
 func (s *BidService) IsUserAuthorizedToDeleteBid(ctx context.Context, username, bidID string) (bool, error) {
@@ -169,39 +631,60 @@ func (s *BidService) IsUserAuthorizedToDeleteBid(ctx context.Context, username,
 }
 
 func (s *BidService) SubmitBidFeedback(ctx context.Context, bidID string, feedback string) error {
-	bid, err := s.bidRepo.GetBidByID(ctx, bidID)
-	if err != nil {
-		return err
-	}
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		bid, err := s.bidRepo.GetBidByID(ctx, bidID)
+		if err != nil {
+			return err
+		}
 
-	bid.Feedback = feedback
+		before := *bid
+		bid.Feedback = feedback
 
-	err = s.bidRepo.UpdateBid(ctx, bid)
-	if err != nil {
-		return err
-	}
+		if err := s.bidRepo.UpdateBid(ctx, bid); err != nil {
+			return err
+		}
 
-	return nil
+		if err := s.recordEvent(ctx, models.AuditBidFeedbackSubmitted, models.AuditEntityBid, bidID, bid.TenderID, &before, bid); err != nil {
+			return err
+		}
+		return s.publishEvent(ctx, models.EventBidFeedbackSubmitted, bid, bid)
+	})
 }
 
 func (s *BidService) IsUserAuthorizedToSubmitFeedback(ctx context.Context, username, bidID string) (bool, error) {
 	return s.bidRepo.IsUserResponsibleForBid(ctx, username, bidID)
 }
 
-func (s *BidService) RollbackBidVersion(ctx context.Context, bidID string, version int) error {
-	oldBid, err := s.bidRepo.GetBidByVersion(ctx, bidID, version)
-	if err != nil {
-		return err
-	}
+func (s *BidService) RollbackBidVersion(ctx context.Context, bidID string, version int, expectedVersion int) error {
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		current, err := s.bidRepo.GetBidByID(ctx, bidID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(current.Version, expectedVersion); err != nil {
+			return err
+		}
 
-	oldBid.Version++
+		oldBid, err := s.bidRepo.GetBidByVersion(ctx, bidID, version)
+		if err != nil {
+			return err
+		}
 
-	err = s.bidRepo.UpdateBid(ctx, oldBid)
-	if err != nil {
-		return err
-	}
+		oldBid.Version++
 
-	return nil
+		if err := s.bidRepo.UpdateBid(ctx, oldBid); err != nil {
+			return err
+		}
+
+		if err := s.recordEvent(ctx, models.AuditBidRolledBack, models.AuditEntityBid, bidID, oldBid.TenderID, current, oldBid); err != nil {
+			return err
+		}
+
+		if oldBid.Status == current.Status {
+			return nil
+		}
+		return s.publishEvent(ctx, models.EventBidStatusChanged, oldBid, oldBid)
+	})
 }
 
 func (s *BidService) IsUserAuthorizedToRollback(ctx context.Context, username, bidID string) (bool, error) {
@@ -216,12 +699,207 @@ func (s *BidService) IsUserAuthorizedToViewReviews(ctx context.Context, username
 	return s.bidRepo.IsUserResponsibleForTender(ctx, username, tenderID)
 }
 
-func (s *BidService) AddBidReview(ctx context.Context, review *models.BidReview) error {
-	return s.bidRepo.CreateBidReview(ctx, review)
-}
+func (s *BidService) AddBidReview(ctx context.Context, username string, review *models.BidReview) error {
+	reviewerID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
 
+	review.ReviewerID = reviewerID
+
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.bidRepo.CreateBidReview(ctx, review); err != nil {
+			return err
+		}
+		if err := s.recordReviewEvent(ctx, models.AuditBidReviewCreated, review, nil, review); err != nil {
+			return err
+		}
+
+		bid, err := s.bidRepo.GetBidByID(ctx, review.BidID)
+		if err != nil {
+			return err
+		}
+		return s.publishEvent(ctx, models.EventBidReviewed, bid, &models.BidReviewedEvent{
+			Bid:    bid,
+			Review: review,
+		})
+	})
+}
+
+// IsUserAuthorizedToAddReview allows reviews only from employees responsible
+// for the organization that owns the bid's tender.
 func (s *BidService) IsUserAuthorizedToAddReview(ctx context.Context, username string, bidID string) (bool, error) {
-	return true, nil
+	return s.bidRepo.IsUserResponsibleForBidTender(ctx, username, bidID)
+}
+
+func (s *BidService) GetBidReview(ctx context.Context, reviewID string) (*models.BidReview, error) {
+	return s.bidRepo.GetBidReviewByID(ctx, reviewID)
+}
+
+func (s *BidService) UpdateBidReview(ctx context.Context, reviewID string, updates *models.BidReview) (*models.BidReview, error) {
+	var review *models.BidReview
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		review, err = s.bidRepo.GetBidReviewByID(ctx, reviewID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.checkReviewEditWindow(review); err != nil {
+			return err
+		}
+
+		before := *review
+
+		if updates.Review != "" {
+			review.Review = updates.Review
+		}
+		if updates.Rating != 0 {
+			review.Rating = updates.Rating
+		}
+		if updates.Category != "" {
+			review.Category = updates.Category
+		}
+
+		if err := s.bidRepo.UpdateBidReview(ctx, review); err != nil {
+			return err
+		}
+
+		return s.recordReviewEvent(ctx, models.AuditBidReviewUpdated, review, &before, review)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func (s *BidService) DeleteBidReview(ctx context.Context, reviewID string) error {
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		review, err := s.bidRepo.GetBidReviewByID(ctx, reviewID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.checkReviewEditWindow(review); err != nil {
+			return err
+		}
+
+		if err := s.bidRepo.DeleteBidReview(ctx, reviewID); err != nil {
+			return err
+		}
+
+		return s.recordReviewEvent(ctx, models.AuditBidReviewDeleted, review, review, nil)
+	})
+}
+
+// IsUserAuthorizedToModifyReview allows only the reviewer to change or delete a review.
+func (s *BidService) IsUserAuthorizedToModifyReview(ctx context.Context, username, reviewID string) (bool, error) {
+	review, err := s.bidRepo.GetBidReviewByID(ctx, reviewID)
+	if err != nil {
+		return false, err
+	}
+
+	reviewerID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return false, err
+	}
+
+	return review.ReviewerID == reviewerID, nil
+}
+
+func (s *BidService) checkReviewEditWindow(review *models.BidReview) error {
+	if s.reviewEditWindow > 0 && time.Since(review.CreatedAt) > s.reviewEditWindow {
+		return ErrReviewEditWindowExpired
+	}
+	return nil
+}
+
+// GetAuthorReputation returns the aggregated review rating of the employee
+// with the given username across all of their bids, failing with
+// ErrAuthorHasNoBids unless they have bid on the tender.
+func (s *BidService) GetAuthorReputation(ctx context.Context, tenderID, authorUsername string) (*models.AuthorReputation, error) {
+	authorID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, authorUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	hasBids, err := s.bidRepo.HasAuthorBidOnTender(ctx, tenderID, authorID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasBids {
+		return nil, ErrAuthorHasNoBids
+	}
+
+	return s.bidRepo.GetAuthorReputation(ctx, authorID)
+}
+
+// GetAuthorProfile builds the bidding history summary of a User or
+// Organization author. The author type is detected from the ID.
+func (s *BidService) GetAuthorProfile(ctx context.Context, authorID string) (*models.AuthorProfile, error) {
+	authorType, err := s.resolveAuthorType(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.bidRepo.GetAuthorBidStats(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	reputation, err := s.bidRepo.GetAuthorReputation(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	recentReviews, err := s.bidRepo.GetRecentReviewsForAuthor(ctx, authorID, recentReviewsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &models.AuthorProfile{
+		AuthorID:      authorID,
+		AuthorType:    authorType,
+		BidsSubmitted: stats.BidsSubmitted,
+		BidsDecided:   stats.BidsDecided,
+		BidsApproved:  stats.BidsApproved,
+		ReviewCount:   reputation.ReviewCount,
+		AverageRating: reputation.AverageRating,
+		RecentReviews: recentReviews,
+	}
+	if stats.BidsDecided > 0 {
+		profile.ApprovalRate = float64(stats.BidsApproved) / float64(stats.BidsDecided)
+	}
+
+	return profile, nil
+}
+
+// IsUserAuthorizedToViewAuthorProfile allows employees responsible for a
+// tender the author has bid on.
+func (s *BidService) IsUserAuthorizedToViewAuthorProfile(ctx context.Context, username, authorID string) (bool, error) {
+	return s.bidRepo.IsUserResponsibleForAuthorTender(ctx, username, authorID)
+}
+
+func (s *BidService) resolveAuthorType(ctx context.Context, authorID string) (models.AuthorType, error) {
+	isEmployee, err := s.employeeRepo.IsEmployeeExists(ctx, authorID)
+	if err != nil {
+		return "", err
+	}
+	if isEmployee {
+		return models.AuthorTypeUser, nil
+	}
+
+	isOrganization, err := s.organizationRepo.IsOrganizationExists(ctx, authorID)
+	if err != nil {
+		return "", err
+	}
+	if isOrganization {
+		return models.AuthorTypeOrganization, nil
+	}
+
+	return "", gorm.ErrRecordNotFound
 }
 
 func (s *BidService) GetAuthorIDByUsername(ctx context.Context, username string) (string, error) {
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/notify"
+	"zadanie-6105/internal/repositories"
+)
+
+// bidEmail is the data of the bid notification templates.
+type bidEmail struct {
+	RecipientName string
+	BidName       string
+	TenderName    string
+	Feedback      string
+}
+
+// EmailNotifier queues email to bid authors when a decision is made on their
+// bid or feedback is left on it. Messages are rendered in the recipient's
+// language when they are queued and sent later by EmailSender.
+type EmailNotifier struct {
+	emailRepo        repositories.EmailRepository
+	notificationRepo repositories.NotificationRepository
+	tenderRepo       repositories.TenderRepository
+	employeeRepo     repositories.EmployeeRepository
+	defaultLanguage  string
+}
+
+func NewEmailNotifier(
+	emailRepo repositories.EmailRepository,
+	notificationRepo repositories.NotificationRepository,
+	tenderRepo repositories.TenderRepository,
+	employeeRepo repositories.EmployeeRepository,
+	defaultLanguage string,
+) *EmailNotifier {
+	return &EmailNotifier{
+		emailRepo:        emailRepo,
+		notificationRepo: notificationRepo,
+		tenderRepo:       tenderRepo,
+		employeeRepo:     employeeRepo,
+		defaultLanguage:  defaultLanguage,
+	}
+}
+
+// HandleEvent is an EventHandler queueing the email for bid.decided and
+// bid.feedback_submitted events.
+func (n *EmailNotifier) HandleEvent(ctx context.Context, event *models.OutboxEvent) error {
+	var (
+		bid      *models.Bid
+		template notify.Template
+	)
+
+	switch event.Type {
+	case models.EventBidDecided:
+		var data models.BidDecidedEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return err
+		}
+		if data.Bid == nil || data.Decision == nil {
+			return fmt.Errorf("malformed %s event %s", event.Type, event.ID)
+		}
+		bid = data.Bid
+		template = notify.TemplateBidRejected
+		if data.Decision.Decision == models.BidDecisionApproved {
+			template = notify.TemplateBidApproved
+		}
+
+	case models.EventBidFeedbackSubmitted:
+		bid = &models.Bid{}
+		if err := json.Unmarshal(event.Data, bid); err != nil {
+			return err
+		}
+		template = notify.TemplateBidFeedback
+
+	default:
+		return nil
+	}
+
+	tender, err := n.tenderRepo.GetTenderByID(ctx, bid.TenderID)
+	if err != nil {
+		return err
+	}
+	recipients, err := n.recipients(ctx, event.Type, bid)
+	if err != nil {
+		return err
+	}
+
+	var messages []*models.EmailMessage
+	for _, recipient := range recipients {
+		language := recipient.Language
+		if !notify.IsSupportedLanguage(language) {
+			language = n.defaultLanguage
+		}
+
+		rendered, err := notify.Render(language, template, &bidEmail{
+			RecipientName: strings.TrimSpace(recipient.FirstName + " " + recipient.LastName),
+			BidName:       bid.Name,
+			TenderName:    tender.Name,
+			Feedback:      bid.Feedback,
+		})
+		if err != nil {
+			return err
+		}
+
+		messages = append(messages, &models.EmailMessage{
+			RecipientID:   recipient.ID,
+			EventID:       event.ID,
+			To:            recipient.Email,
+			Subject:       rendered.Subject,
+			Body:          rendered.Body,
+			Status:        models.EmailMessagePending,
+			NextAttemptAt: event.CreatedAt,
+		})
+	}
+
+	return n.emailRepo.CreateMessages(ctx, messages)
+}
+
+// recipients returns the bid authors with an email address who have not
+// turned the event type off.
+func (n *EmailNotifier) recipients(ctx context.Context, eventType models.DomainEventType, bid *models.Bid) ([]*models.Employee, error) {
+	ids, err := bidAuthorRecipients(ctx, n.notificationRepo, bid)
+	if err != nil {
+		return nil, err
+	}
+	optedOut, err := n.notificationRepo.GetOptedOutEmployees(ctx, eventType, ids)
+	if err != nil {
+		return nil, err
+	}
+	skip := make(map[string]bool, len(optedOut))
+	for _, id := range optedOut {
+		skip[id] = true
+	}
+
+	employees, err := n.employeeRepo.GetEmployeesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipients []*models.Employee
+	for _, employee := range employees {
+		if employee.Email != "" && !skip[employee.ID] {
+			recipients = append(recipients, employee)
+		}
+	}
+	return recipients, nil
+}
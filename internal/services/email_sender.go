@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/notify"
+	"zadanie-6105/internal/repositories"
+)
+
+// EmailSendOptions tune email retries.
+type EmailSendOptions struct {
+	// Messages sent per tick
+	BatchSize int
+	// Attempts before a message is given up on
+	MaxAttempts int
+	// Delay before the first retry, doubled on every further attempt up to
+	// MaxRetryBackoff
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// How long a claimed message is reserved for one sender
+	Lease time.Duration
+}
+
+// EmailSender sends the queued email through a notification channel. Like
+// the webhook dispatcher it may run on any number of replicas: messages are
+// claimed with SKIP LOCKED and sent outside of a transaction under a lease.
+type EmailSender struct {
+	emailRepo repositories.EmailRepository
+	channel   notify.Channel
+	options   EmailSendOptions
+	interval  time.Duration
+}
+
+func NewEmailSender(emailRepo repositories.EmailRepository, channel notify.Channel, options EmailSendOptions, interval time.Duration) *EmailSender {
+	return &EmailSender{
+		emailRepo: emailRepo,
+		channel:   channel,
+		options:   options,
+		interval:  interval,
+	}
+}
+
+// Run ticks until ctx is canceled.
+func (s *EmailSender) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.SendOnce(ctx); err != nil {
+				log.Printf("Email sender failed: %v", err)
+			}
+		}
+	}
+}
+
+// SendOnce sends the messages that are due.
+func (s *EmailSender) SendOnce(ctx context.Context) error {
+	messages, err := s.emailRepo.ClaimDueMessages(ctx, time.Now(), s.options.Lease, s.options.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		err := s.channel.Send(ctx, &notify.Message{
+			To:      message.To,
+			Subject: message.Subject,
+			Body:    message.Body,
+		})
+		s.recordAttempt(message, err, time.Now())
+
+		if err := s.emailRepo.UpdateMessage(ctx, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordAttempt applies the outcome of a send attempt: success, a retry with
+// exponential backoff, or the failed state.
+func (s *EmailSender) recordAttempt(message *models.EmailMessage, err error, now time.Time) {
+	message.Attempts++
+	message.LockedUntil = nil
+
+	if err == nil {
+		message.Status = models.EmailMessageSent
+		message.SentAt = &now
+		message.LastError = ""
+		return
+	}
+
+	message.LastError = err.Error()
+	if message.Attempts >= s.options.MaxAttempts {
+		message.Status = models.EmailMessageFailed
+		log.Printf("Email %s to %s failed after %d attempts: %v", message.ID, message.To, message.Attempts, err)
+		return
+	}
+	message.NextAttemptAt = now.Add(retryBackoff(message.Attempts, s.options.RetryBackoff, s.options.MaxRetryBackoff))
+}
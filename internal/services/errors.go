@@ -0,0 +1,120 @@
+package services
+
+import "errors"
+
+// ErrPreconditionFailed is returned when the If-Match version supplied by
+// the client no longer matches the stored entity.
+var ErrPreconditionFailed = errors.New("precondition failed: resource has been modified")
+
+// ErrReviewEditWindowExpired is returned when a review is changed or deleted
+// after the configured edit window.
+var ErrReviewEditWindowExpired = errors.New("review edit window has expired")
+
+// checkVersion compares the stored version with the one the client expects.
+// An expected version of zero means the client sent no precondition.
+func checkVersion(current, expected int) error {
+	if expected != 0 && current != expected {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// ErrInvalidTenderDeadlines is returned when the decision deadline of a
+// tender precedes its submission deadline.
+var ErrInvalidTenderDeadlines = errors.New("decision deadline must not be before submission deadline")
+
+// ErrSubmissionDeadlinePassed is returned when a bid is created or edited
+// after the tender's submission deadline.
+var ErrSubmissionDeadlinePassed = errors.New("tender submission deadline has passed")
+
+// ErrTenderNotSchedulable is returned when publication is scheduled for a
+// tender that is no longer in Created status.
+var ErrTenderNotSchedulable = errors.New("only tenders in Created status can be scheduled for publication")
+
+// ErrPublishAtInPast is returned when the requested publication time has
+// already passed.
+var ErrPublishAtInPast = errors.New("publication time must be in the future")
+
+// ErrInvalidBidTerms is returned when the commercial terms of a bid are
+// inconsistent; the wrapping error carries the detail.
+var ErrInvalidBidTerms = errors.New("invalid bid terms")
+
+// ErrInvalidTenderBudget is returned when a tender budget is negative or
+// given without a currency.
+var ErrInvalidTenderBudget = errors.New("invalid tender budget")
+
+// ErrInvalidScore is returned when a bid score refers to a criterion that
+// cannot be scored manually for the bid's tender.
+var ErrInvalidScore = errors.New("invalid bid score")
+
+// ErrAttachmentTooLarge, ErrAttachmentTypeNotAllowed and ErrInvalidAttachment
+// reject uploads that exceed ATTACHMENT_MAX_SIZE, have a MIME type outside
+// ATTACHMENT_ALLOWED_TYPES or are otherwise unusable.
+var (
+	ErrAttachmentTooLarge       = errors.New("attachment is too large")
+	ErrAttachmentTypeNotAllowed = errors.New("attachment type is not allowed")
+	ErrInvalidAttachment        = errors.New("invalid attachment")
+)
+
+// ErrTenderNotOpenForQuestions is returned when a question is asked about a
+// tender that is not published.
+var ErrTenderNotOpenForQuestions = errors.New("tender is not open for questions")
+
+// ErrTenderInvitationRequired is returned when a bid is created for an
+// invite-only tender without an accepted invitation.
+var ErrTenderInvitationRequired = errors.New("an accepted invitation is required to bid on this tender")
+
+// ErrInvalidInvitation is returned when a tender owner invites its own
+// organization; ErrInvitationAlreadyAnswered when an invitation is accepted
+// twice or answered after being declined.
+var (
+	ErrInvalidInvitation         = errors.New("organization cannot be invited to its own tender")
+	ErrInvitationAlreadyAnswered = errors.New("invitation has already been answered")
+)
+
+// Bid lifecycle errors: ErrActiveBidExists when the author already has an
+// active bid on the tender, ErrBidDecisionRecorded when a decided bid is
+// withdrawn, ErrInvalidBidTransition for transitions from the wrong status
+// and ErrInvalidBidStatus for unknown statuses.
+var (
+	ErrActiveBidExists      = errors.New("author already has an active bid on this tender")
+	ErrBidDecisionRecorded  = errors.New("bid cannot be withdrawn after a decision has been recorded")
+	ErrInvalidBidTransition = errors.New("invalid bid status transition")
+	ErrInvalidBidStatus     = errors.New("invalid bid status")
+)
+
+// ErrAuditSigningKeyMissing is returned by digest operations when
+// AUDIT_SIGNING_KEY is not configured; ErrAuditDigestMismatch when an
+// exported digest no longer matches the audit chain.
+var (
+	ErrAuditSigningKeyMissing = errors.New("audit signing key is not configured")
+	ErrAuditDigestMismatch    = errors.New("audit digest does not match the chain")
+)
+
+// ErrInvalidWebhook is returned for a subscription with an unusable URL or
+// unknown event types; ErrWebhookDeliveryPending when a delivery that is
+// still being retried is redelivered.
+var (
+	ErrInvalidWebhook         = errors.New("invalid webhook subscription")
+	ErrWebhookDeliveryPending = errors.New("webhook delivery is still pending")
+)
+
+// ErrInvalidEventType is returned for an unknown domain event type.
+var ErrInvalidEventType = errors.New("unknown event type")
+
+// ErrInvalidTenderImport is returned for an import file that cannot be read
+// at all, such as a CSV file with an unknown column; errors in single rows
+// are reported per row instead. ErrTenderImportTooLarge is returned for a
+// file with more rows than TENDER_IMPORT_MAX_ROWS.
+var (
+	ErrInvalidTenderImport  = errors.New("invalid tender import")
+	ErrTenderImportTooLarge = errors.New("tender import has too many rows")
+)
+
+// ErrAuthorHasNoBids is returned when the reputation of an author who has
+// not bid on the tender is requested.
+var ErrAuthorHasNoBids = errors.New("author has no bids on this tender")
+
+// ErrTenderNotClosed is returned when the award protocol of a tender that
+// is not closed yet is requested.
+var ErrTenderNotClosed = errors.New("award protocol is available only for closed tenders")
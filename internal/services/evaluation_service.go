@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+
+	"github.com/shopspring/decimal"
+)
+
+type EvaluationService struct {
+	evaluationRepo repositories.EvaluationRepository
+	tenderRepo     repositories.TenderRepository
+	bidRepo        repositories.BidRepository
+	employeeRepo   repositories.EmployeeRepository
+	uow            repositories.UnitOfWork
+}
+
+func NewEvaluationService(
+	evaluationRepo repositories.EvaluationRepository,
+	tenderRepo repositories.TenderRepository,
+	bidRepo repositories.BidRepository,
+	employeeRepo repositories.EmployeeRepository,
+	uow repositories.UnitOfWork,
+) *EvaluationService {
+	return &EvaluationService{
+		evaluationRepo: evaluationRepo,
+		tenderRepo:     tenderRepo,
+		bidRepo:        bidRepo,
+		employeeRepo:   employeeRepo,
+		uow:            uow,
+	}
+}
+
+func (s *EvaluationService) IsUserAuthorizedToManageCriteria(username, tenderID string) (bool, error) {
+	return s.tenderRepo.IsUserResponsibleForTender(username, tenderID)
+}
+
+// IsUserAuthorizedToViewCriteria mirrors tender visibility: criteria of a
+// tender that has not been published yet are visible only to its
+// responsible employees, those of an invite-only tender only to invitees.
+func (s *EvaluationService) IsUserAuthorizedToViewCriteria(ctx context.Context, username, tenderID string) (bool, error) {
+	tender, err := s.tenderRepo.GetTenderByID(ctx, tenderID)
+	if err != nil {
+		return false, err
+	}
+	if tender.Status == models.TenderStatusPublished || tender.Status == models.TenderStatusClosed {
+		return s.tenderRepo.IsTenderVisibleTo(ctx, tenderID, username, "")
+	}
+	return s.tenderRepo.IsUserResponsibleForTender(username, tenderID)
+}
+
+func (s *EvaluationService) IsUserAuthorizedToScoreBid(ctx context.Context, username, bidID string) (bool, error) {
+	return s.bidRepo.IsUserResponsibleForBidTender(ctx, username, bidID)
+}
+
+func (s *EvaluationService) SetCriteria(ctx context.Context, tenderID string, criteria []*models.EvaluationCriterion) ([]*models.EvaluationCriterion, error) {
+	for _, criterion := range criteria {
+		criterion.ID = ""
+		criterion.TenderID = tenderID
+	}
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		return s.evaluationRepo.ReplaceCriteria(ctx, tenderID, criteria)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return criteria, nil
+}
+
+func (s *EvaluationService) GetCriteria(ctx context.Context, tenderID string) ([]*models.EvaluationCriterion, error) {
+	return s.evaluationRepo.GetCriteria(ctx, tenderID)
+}
+
+// ScoreBid stores the employee's scores of a bid. Only manual criteria of
+// the bid's tender can be scored; automatic ones are derived from bid data.
+func (s *EvaluationService) ScoreBid(ctx context.Context, bidID, username string, scores []*models.BidScore) ([]*models.BidScore, error) {
+	scorerID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		bid, err := s.bidRepo.GetBidByID(ctx, bidID)
+		if err != nil {
+			return err
+		}
+
+		criteria, err := s.evaluationRepo.GetCriteria(ctx, bid.TenderID)
+		if err != nil {
+			return err
+		}
+
+		kinds := make(map[string]models.CriterionKind, len(criteria))
+		for _, criterion := range criteria {
+			kinds[criterion.ID] = criterion.Kind
+		}
+
+		scored := make(map[string]bool, len(scores))
+		for _, score := range scores {
+			if scored[score.CriterionID] {
+				return fmt.Errorf("%w: criterion %s is scored more than once", ErrInvalidScore, score.CriterionID)
+			}
+			scored[score.CriterionID] = true
+
+			kind, ok := kinds[score.CriterionID]
+			if !ok {
+				return fmt.Errorf("%w: criterion %s does not belong to the tender", ErrInvalidScore, score.CriterionID)
+			}
+			if kind.IsAutomatic() {
+				return fmt.Errorf("%w: criterion %s is scored automatically", ErrInvalidScore, score.CriterionID)
+			}
+			score.ID = ""
+			score.BidID = bidID
+			score.ScorerID = scorerID
+		}
+
+		if len(scores) == 0 {
+			return nil
+		}
+		return s.evaluationRepo.UpsertBidScores(ctx, scores)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return scores, nil
+}
+
+// GetComparison ranks the tender's published bids by their weighted total
+// score. Manual criteria use the average of all employees' scores; price and
+// delivery time are scored relative to the best offer (best = 10) and
+// reputation maps the author's 1..5 average rating onto 0..10.
+func (s *EvaluationService) GetComparison(ctx context.Context, tenderID string) (*models.BidComparison, error) {
+	criteria, err := s.evaluationRepo.GetCriteria(ctx, tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	bids, err := s.bidRepo.GetBidsForTender(ctx, tenderID, models.BidListFilter{Status: models.BidStatusPublished}, -1, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	scores, err := s.evaluationRepo.GetBidScoresForTender(ctx, tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	type scoreSum struct {
+		total float64
+		count int
+	}
+	manual := make(map[string]map[string]*scoreSum)
+	for _, score := range scores {
+		if manual[score.BidID] == nil {
+			manual[score.BidID] = make(map[string]*scoreSum)
+		}
+		sum := manual[score.BidID][score.CriterionID]
+		if sum == nil {
+			sum = &scoreSum{}
+			manual[score.BidID][score.CriterionID] = sum
+		}
+		sum.total += score.Score
+		sum.count++
+	}
+
+	// Best offers for the relative criteria. Prices are only compared within
+	// the tender currency, or the most common bid currency when it has none
+	tender, err := s.bidRepo.GetLatestTender(ctx, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	currency := tender.Currency
+	if currency == "" {
+		currency = majorityCurrency(bids)
+	}
+
+	var bestPrice decimal.NullDecimal
+	bestDelivery := 0
+	for _, bid := range bids {
+		if bid.Amount.Valid && bid.Currency == currency && bid.Amount.Decimal.IsPositive() &&
+			(!bestPrice.Valid || bid.Amount.Decimal.LessThan(bestPrice.Decimal)) {
+			bestPrice = bid.Amount
+		}
+		if bid.DeliveryDays > 0 && (bestDelivery == 0 || bid.DeliveryDays < bestDelivery) {
+			bestDelivery = bid.DeliveryDays
+		}
+	}
+
+	reputations := make(map[string]*models.AuthorReputation)
+
+	comparison := &models.BidComparison{TenderID: tenderID, Criteria: criteria}
+	for _, bid := range bids {
+		row := &models.BidComparisonRow{
+			Bid:      bid,
+			Scores:   make(map[string]*float64, len(criteria)),
+			Complete: true,
+		}
+
+		var weighted, weights float64
+		for _, criterion := range criteria {
+			var value *float64
+
+			switch criterion.Kind {
+			case models.CriterionKindPrice:
+				if bestPrice.Valid && bid.Amount.Valid && bid.Currency == currency && bid.Amount.Decimal.IsPositive() {
+					v, _ := bestPrice.Decimal.Div(bid.Amount.Decimal).Mul(decimal.NewFromInt(models.MaxCriterionScore)).Float64()
+					value = &v
+				}
+			case models.CriterionKindDeliveryTime:
+				if bestDelivery > 0 && bid.DeliveryDays > 0 {
+					v := float64(bestDelivery) / float64(bid.DeliveryDays) * models.MaxCriterionScore
+					value = &v
+				}
+			case models.CriterionKindReputation:
+				reputation, ok := reputations[bid.AuthorID]
+				if !ok {
+					reputation, err = s.bidRepo.GetAuthorReputation(ctx, bid.AuthorID)
+					if err != nil {
+						return nil, err
+					}
+					reputations[bid.AuthorID] = reputation
+				}
+				if reputation.ReviewCount > 0 {
+					v := (reputation.AverageRating - 1) / 4 * models.MaxCriterionScore
+					value = &v
+				}
+			default:
+				if sum := manual[bid.ID][criterion.ID]; sum != nil {
+					v := sum.total / float64(sum.count)
+					value = &v
+				}
+			}
+
+			row.Scores[criterion.ID] = value
+			if value == nil {
+				row.Complete = false
+				// Missing scores count as zero so incomplete bids do not outrank scored ones
+				weights += criterion.Weight
+				continue
+			}
+			weighted += criterion.Weight * *value
+			weights += criterion.Weight
+		}
+
+		if weights > 0 {
+			row.Total = weighted / weights
+		}
+		comparison.Rows = append(comparison.Rows, row)
+	}
+
+	sort.SliceStable(comparison.Rows, func(i, j int) bool {
+		return comparison.Rows[i].Total > comparison.Rows[j].Total
+	})
+	for i, row := range comparison.Rows {
+		row.Rank = i + 1
+	}
+
+	return comparison, nil
+}
+
+func majorityCurrency(bids []*models.Bid) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, bid := range bids {
+		if bid.Currency == "" {
+			continue
+		}
+		counts[bid.Currency]++
+		if counts[bid.Currency] > counts[best] || (counts[bid.Currency] == counts[best] && bid.Currency < best) {
+			best = bid.Currency
+		}
+	}
+	return best
+}
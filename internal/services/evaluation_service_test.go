@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// memoryEvaluationRepository holds the criteria and scores of one tender.
+type memoryEvaluationRepository struct {
+	repositories.EvaluationRepository
+	criteria []*models.EvaluationCriterion
+	scores   []*models.BidScore
+	upserted []*models.BidScore
+}
+
+func (r *memoryEvaluationRepository) GetCriteria(ctx context.Context, tenderID string) ([]*models.EvaluationCriterion, error) {
+	return r.criteria, nil
+}
+
+func (r *memoryEvaluationRepository) GetBidScoresForTender(ctx context.Context, tenderID string) ([]*models.BidScore, error) {
+	return r.scores, nil
+}
+
+func (r *memoryEvaluationRepository) UpsertBidScores(ctx context.Context, scores []*models.BidScore) error {
+	r.upserted = append(r.upserted, scores...)
+	return nil
+}
+
+// evaluationBidRepository serves the published bids of one tender and the
+// reputations of their authors.
+type evaluationBidRepository struct {
+	repositories.BidRepository
+	tender      *models.Tender
+	bids        []*models.Bid
+	reputations map[string]float64
+}
+
+func (r *evaluationBidRepository) GetBidsForTender(ctx context.Context, tenderID string, filter models.BidListFilter, limit, offset int) ([]*models.Bid, error) {
+	return r.bids, nil
+}
+
+func (r *evaluationBidRepository) GetBidByID(ctx context.Context, id string) (*models.Bid, error) {
+	for _, bid := range r.bids {
+		if bid.ID == id {
+			return bid, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *evaluationBidRepository) GetLatestTender(ctx context.Context, tenderID string) (*models.Tender, error) {
+	return r.tender, nil
+}
+
+func (r *evaluationBidRepository) GetAuthorReputation(ctx context.Context, authorID string) (*models.AuthorReputation, error) {
+	reputation := &models.AuthorReputation{AuthorID: authorID}
+	if rating, ok := r.reputations[authorID]; ok {
+		reputation.ReviewCount = 3
+		reputation.AverageRating = rating
+	}
+	return reputation, nil
+}
+
+type staticEmployeeRepository struct {
+	repositories.EmployeeRepository
+}
+
+func (staticEmployeeRepository) GetEmployeeIDByUsername(ctx context.Context, username string) (string, error) {
+	return "scorer-" + username, nil
+}
+
+// visibilityTenderRepository answers the visibility checks of one tender.
+type visibilityTenderRepository struct {
+	repositories.TenderRepository
+	tender      *models.Tender
+	visible     bool
+	responsible bool
+}
+
+func (r *visibilityTenderRepository) GetTenderByID(ctx context.Context, id string) (*models.Tender, error) {
+	if r.tender == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.tender, nil
+}
+
+func (r *visibilityTenderRepository) IsTenderVisibleTo(ctx context.Context, tenderID, username, organizationID string) (bool, error) {
+	return r.visible, nil
+}
+
+func (r *visibilityTenderRepository) IsUserResponsibleForTender(username, tenderId string) (bool, error) {
+	return r.responsible, nil
+}
+
+func testBid(id string, amount string, currency string, deliveryDays int) *models.Bid {
+	bid := &models.Bid{ID: id, AuthorID: "author-" + id, Currency: currency, DeliveryDays: deliveryDays}
+	if amount != "" {
+		bid.Amount = decimal.NewNullDecimal(decimal.RequireFromString(amount))
+	}
+	return bid
+}
+
+func TestGetComparison(t *testing.T) {
+	price := &models.EvaluationCriterion{ID: "price", Kind: models.CriterionKindPrice, Weight: 3}
+	delivery := &models.EvaluationCriterion{ID: "delivery", Kind: models.CriterionKindDeliveryTime, Weight: 1}
+	reputation := &models.EvaluationCriterion{ID: "reputation", Kind: models.CriterionKindReputation, Weight: 1}
+	quality := &models.EvaluationCriterion{ID: "quality", Kind: models.CriterionKindQuality, Weight: 1}
+	custom := &models.EvaluationCriterion{ID: "custom", Kind: models.CriterionKindCustom, Weight: 1}
+
+	score := func(bidID, criterionID string, value float64) *models.BidScore {
+		return &models.BidScore{BidID: bidID, CriterionID: criterionID, Score: value}
+	}
+	ptr := func(v float64) *float64 { return &v }
+
+	type wantRow struct {
+		bidID    string
+		scores   map[string]*float64
+		total    float64
+		complete bool
+	}
+
+	tests := []struct {
+		name           string
+		tenderCurrency string
+		criteria       []*models.EvaluationCriterion
+		bids           []*models.Bid
+		scores         []*models.BidScore
+		reputations    map[string]float64
+		// Rows in the expected ranking order
+		want []wantRow
+	}{
+		{
+			name:     "relative price and delivery",
+			criteria: []*models.EvaluationCriterion{price, delivery},
+			bids:     []*models.Bid{testBid("b", "200", "RUB", 5), testBid("a", "100", "RUB", 10)},
+			want: []wantRow{
+				{bidID: "a", scores: map[string]*float64{"price": ptr(10), "delivery": ptr(5)}, total: 8.75, complete: true},
+				{bidID: "b", scores: map[string]*float64{"price": ptr(5), "delivery": ptr(10)}, total: 6.25, complete: true},
+			},
+		},
+		{
+			name:        "reputation",
+			criteria:    []*models.EvaluationCriterion{reputation},
+			bids:        []*models.Bid{testBid("c", "", "", 0), testBid("b", "", "", 0), testBid("a", "", "", 0)},
+			reputations: map[string]float64{"author-a": 5, "author-b": 3},
+			want: []wantRow{
+				{bidID: "a", scores: map[string]*float64{"reputation": ptr(10)}, total: 10, complete: true},
+				{bidID: "b", scores: map[string]*float64{"reputation": ptr(5)}, total: 5, complete: true},
+				{bidID: "c", scores: map[string]*float64{"reputation": nil}, total: 0},
+			},
+		},
+		{
+			name:     "missing scores count as zero",
+			criteria: []*models.EvaluationCriterion{quality, custom},
+			bids:     []*models.Bid{testBid("b", "", "", 0), testBid("a", "", "", 0)},
+			scores: []*models.BidScore{
+				score("a", "quality", 8), score("a", "quality", 6), score("a", "custom", 9),
+				score("b", "quality", 10),
+			},
+			want: []wantRow{
+				{bidID: "a", scores: map[string]*float64{"quality": ptr(7), "custom": ptr(9)}, total: 8, complete: true},
+				{bidID: "b", scores: map[string]*float64{"quality": ptr(10), "custom": nil}, total: 5},
+			},
+		},
+		{
+			name:     "majority currency without a tender currency",
+			criteria: []*models.EvaluationCriterion{price},
+			bids:     []*models.Bid{testBid("c", "50", "EUR", 0), testBid("b", "150", "USD", 0), testBid("a", "100", "USD", 0)},
+			want: []wantRow{
+				{bidID: "a", scores: map[string]*float64{"price": ptr(10)}, total: 10, complete: true},
+				{bidID: "b", scores: map[string]*float64{"price": ptr(100.0 / 150 * 10)}, total: 100.0 / 150 * 10, complete: true},
+				{bidID: "c", scores: map[string]*float64{"price": nil}, total: 0},
+			},
+		},
+		{
+			name:           "tender currency",
+			tenderCurrency: "EUR",
+			criteria:       []*models.EvaluationCriterion{price},
+			bids:           []*models.Bid{testBid("b", "150", "USD", 0), testBid("a", "50", "EUR", 0), testBid("c", "0", "EUR", 0)},
+			want: []wantRow{
+				{bidID: "a", scores: map[string]*float64{"price": ptr(10)}, total: 10, complete: true},
+				{bidID: "b", scores: map[string]*float64{"price": nil}, total: 0},
+				{bidID: "c", scores: map[string]*float64{"price": nil}, total: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewEvaluationService(
+				&memoryEvaluationRepository{criteria: tt.criteria, scores: tt.scores},
+				nil,
+				&evaluationBidRepository{tender: &models.Tender{Currency: tt.tenderCurrency}, bids: tt.bids, reputations: tt.reputations},
+				nil,
+				directUnitOfWork{},
+			)
+
+			comparison, err := service.GetComparison(context.Background(), "tender")
+			if err != nil {
+				t.Fatalf("GetComparison() error = %v", err)
+			}
+			if len(comparison.Rows) != len(tt.want) {
+				t.Fatalf("GetComparison() returned %d rows, want %d", len(comparison.Rows), len(tt.want))
+			}
+
+			for i, want := range tt.want {
+				row := comparison.Rows[i]
+				if row.Bid.ID != want.bidID || row.Rank != i+1 {
+					t.Fatalf("row %d = bid %s ranked %d, want bid %s", i+1, row.Bid.ID, row.Rank, want.bidID)
+				}
+				if math.Abs(row.Total-want.total) > 1e-9 || row.Complete != want.complete {
+					t.Errorf("bid %s total = %v, complete = %v, want %v, %v", want.bidID, row.Total, row.Complete, want.total, want.complete)
+				}
+				for criterionID, wantScore := range want.scores {
+					got, ok := row.Scores[criterionID]
+					if !ok {
+						t.Errorf("bid %s has no %s score", want.bidID, criterionID)
+						continue
+					}
+					if (got == nil) != (wantScore == nil) || got != nil && math.Abs(*got-*wantScore) > 1e-9 {
+						t.Errorf("bid %s %s score = %v, want %v", want.bidID, criterionID, formatScore(got), formatScore(wantScore))
+					}
+				}
+			}
+		})
+	}
+}
+
+func formatScore(score *float64) interface{} {
+	if score == nil {
+		return nil
+	}
+	return *score
+}
+
+func TestScoreBid(t *testing.T) {
+	criteria := []*models.EvaluationCriterion{
+		{ID: "quality", Kind: models.CriterionKindQuality, Weight: 1},
+		{ID: "custom", Kind: models.CriterionKindCustom, Weight: 1},
+		{ID: "price", Kind: models.CriterionKindPrice, Weight: 1},
+	}
+
+	tests := []struct {
+		name         string
+		criterionIDs []string
+		wantErr      error
+	}{
+		{name: "manual criteria", criterionIDs: []string{"quality", "custom"}},
+		{name: "same criterion twice", criterionIDs: []string{"quality", "custom", "quality"}, wantErr: ErrInvalidScore},
+		{name: "automatic criterion", criterionIDs: []string{"price"}, wantErr: ErrInvalidScore},
+		{name: "criterion of another tender", criterionIDs: []string{"other"}, wantErr: ErrInvalidScore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluationRepo := &memoryEvaluationRepository{criteria: criteria}
+			service := NewEvaluationService(
+				evaluationRepo,
+				nil,
+				&evaluationBidRepository{bids: []*models.Bid{{ID: "bid", TenderID: "tender"}}},
+				staticEmployeeRepository{},
+				directUnitOfWork{},
+			)
+
+			var scores []*models.BidScore
+			for _, criterionID := range tt.criterionIDs {
+				scores = append(scores, &models.BidScore{ID: "client id", CriterionID: criterionID, Score: 7})
+			}
+			_, err := service.ScoreBid(context.Background(), "bid", "user1", scores)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ScoreBid() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if len(evaluationRepo.upserted) != 0 {
+					t.Errorf("ScoreBid() stored %d scores, want none", len(evaluationRepo.upserted))
+				}
+				return
+			}
+			if len(evaluationRepo.upserted) != len(scores) {
+				t.Fatalf("ScoreBid() stored %d scores, want %d", len(evaluationRepo.upserted), len(scores))
+			}
+			for _, stored := range evaluationRepo.upserted {
+				if stored.ID != "" || stored.BidID != "bid" || stored.ScorerID != "scorer-user1" {
+					t.Errorf("stored score = %+v, want no id, bid and scorer filled in", stored)
+				}
+			}
+		})
+	}
+}
+
+func TestIsUserAuthorizedToViewCriteria(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      models.TenderStatus
+		missing     bool
+		visible     bool
+		responsible bool
+		want        bool
+		wantErr     error
+	}{
+		{name: "published and visible", status: models.TenderStatusPublished, visible: true, want: true},
+		{name: "published and hidden", status: models.TenderStatusPublished, responsible: true},
+		{name: "closed and visible", status: models.TenderStatusClosed, visible: true, want: true},
+		{name: "created for responsible", status: models.TenderStatusCreated, responsible: true, want: true},
+		{name: "created for others", status: models.TenderStatusCreated, visible: true},
+		{name: "missing tender", missing: true, wantErr: gorm.ErrRecordNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenderRepo := &visibilityTenderRepository{visible: tt.visible, responsible: tt.responsible}
+			if !tt.missing {
+				tenderRepo.tender = &models.Tender{ID: "tender", Status: tt.status}
+			}
+			service := NewEvaluationService(nil, tenderRepo, nil, nil, directUnitOfWork{})
+
+			authorized, err := service.IsUserAuthorizedToViewCriteria(context.Background(), "user1", "tender")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("IsUserAuthorizedToViewCriteria() error = %v, want %v", err, tt.wantErr)
+			}
+			if authorized != tt.want {
+				t.Errorf("IsUserAuthorizedToViewCriteria() = %v, want %v", authorized, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+)
+
+// EventHandler reacts to a published event inside the publishing
+// transaction; an error rolls the change back.
+type EventHandler func(ctx context.Context, event *models.OutboxEvent) error
+
+// EventPublisher writes domain events to the transactional outbox and passes
+// them to the in-process handlers. Publish must be called inside the unit of
+// work that makes the change, so that an event is delivered if and only if
+// the change is committed.
+type EventPublisher struct {
+	outboxRepo repositories.OutboxRepository
+	handlers   []EventHandler
+}
+
+func NewEventPublisher(outboxRepo repositories.OutboxRepository) *EventPublisher {
+	return &EventPublisher{outboxRepo: outboxRepo}
+}
+
+// Subscribe registers handler for all subsequently published events. It is
+// meant to be called during startup, before any event is published.
+func (p *EventPublisher) Subscribe(handler EventHandler) {
+	p.handlers = append(p.handlers, handler)
+}
+
+func (p *EventPublisher) Publish(ctx context.Context, eventType models.DomainEventType, organizationID, aggregateID string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	event := &models.OutboxEvent{
+		Type:           eventType,
+		OrganizationID: organizationID,
+		AggregateID:    aggregateID,
+		Data:           models.AuditSnapshot(payload),
+	}
+	if err := p.outboxRepo.CreateEvent(ctx, event); err != nil {
+		return err
+	}
+
+	for _, handler := range p.handlers {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
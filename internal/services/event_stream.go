@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+)
+
+// Events buffered per subscriber before it is dropped as too slow
+const streamSubscriberBuffer = 64
+
+// StreamSubscription receives the live events of one tender.
+type StreamSubscription struct {
+	tenderID string
+	events   chan *models.StreamEvent
+}
+
+// Events is closed when the subscriber falls too far behind; the client is
+// expected to reconnect and resume from the last event it received.
+func (s *StreamSubscription) Events() <-chan *models.StreamEvent {
+	return s.events
+}
+
+// EventStream pushes tender and bid events to live subscribers. Events are
+// stored in the publishing transaction and announced with NOTIFY on commit;
+// every replica listens and fans them out to its own subscribers, so a
+// client may be connected to any replica.
+type EventStream struct {
+	streamRepo repositories.StreamRepository
+	bidRepo    repositories.BidRepository
+	retention  time.Duration
+
+	mu          sync.Mutex
+	subscribers map[string]map[*StreamSubscription]bool
+	// Highest event ID announced so far, to catch up after a reconnect
+	lastID    int64
+	listening bool
+}
+
+func NewEventStream(streamRepo repositories.StreamRepository, bidRepo repositories.BidRepository, retention time.Duration) *EventStream {
+	return &EventStream{
+		streamRepo:  streamRepo,
+		bidRepo:     bidRepo,
+		retention:   retention,
+		subscribers: make(map[string]map[*StreamSubscription]bool),
+	}
+}
+
+// HandleEvent is an EventHandler storing bid created, bid status changed,
+// bid decided, bid reviewed and tender status changed events in their
+// tender's stream.
+func (s *EventStream) HandleEvent(ctx context.Context, event *models.OutboxEvent) error {
+	var tenderID string
+
+	switch event.Type {
+	case models.EventTenderStatusChanged:
+		tenderID = event.AggregateID
+
+	case models.EventBidCreated, models.EventBidStatusChanged:
+		var bid models.Bid
+		if err := json.Unmarshal(event.Data, &bid); err != nil {
+			return err
+		}
+		tenderID = bid.TenderID
+
+	case models.EventBidDecided:
+		var data models.BidDecidedEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return err
+		}
+		if data.Bid == nil {
+			return fmt.Errorf("malformed %s event %s", event.Type, event.ID)
+		}
+		tenderID = data.Bid.TenderID
+
+	case models.EventBidReviewed:
+		var data models.BidReviewedEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return err
+		}
+		if data.Bid == nil {
+			return fmt.Errorf("malformed %s event %s", event.Type, event.ID)
+		}
+		tenderID = data.Bid.TenderID
+
+	default:
+		return nil
+	}
+
+	return s.streamRepo.CreateEvent(ctx, &models.StreamEvent{
+		TenderID: tenderID,
+		Type:     event.Type,
+		Data:     event.Data,
+	})
+}
+
+// IsUserAuthorizedToSubscribe allows the employees who may list the
+// tender's bids.
+func (s *EventStream) IsUserAuthorizedToSubscribe(ctx context.Context, username, tenderID string) (bool, error) {
+	return s.bidRepo.IsUserAuthorizedToViewBids(ctx, tenderID, username)
+}
+
+// GetEventsAfter returns the stored events of the tender following afterID,
+// for clients resuming with Last-Event-ID.
+func (s *EventStream) GetEventsAfter(ctx context.Context, tenderID string, afterID int64, limit int) ([]*models.StreamEvent, error) {
+	return s.streamRepo.GetTenderEventsAfter(ctx, tenderID, afterID, limit)
+}
+
+func (s *EventStream) Subscribe(tenderID string) *StreamSubscription {
+	subscription := &StreamSubscription{
+		tenderID: tenderID,
+		events:   make(chan *models.StreamEvent, streamSubscriberBuffer),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscribers[tenderID] == nil {
+		s.subscribers[tenderID] = make(map[*StreamSubscription]bool)
+	}
+	s.subscribers[tenderID][subscription] = true
+	return subscription
+}
+
+func (s *EventStream) Unsubscribe(subscription *StreamSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(subscription)
+}
+
+// remove must be called with s.mu held.
+func (s *EventStream) remove(subscription *StreamSubscription) {
+	subscribers := s.subscribers[subscription.tenderID]
+	if !subscribers[subscription] {
+		return
+	}
+	delete(subscribers, subscription)
+	if len(subscribers) == 0 {
+		delete(s.subscribers, subscription.tenderID)
+	}
+	close(subscription.events)
+}
+
+// Run listens for new events until ctx is canceled and prunes events older
+// than the retention period.
+func (s *EventStream) Run(ctx context.Context) {
+	go s.prune(ctx)
+
+	keepListening(ctx, "Event stream", func(connected func()) error {
+		return s.streamRepo.Listen(ctx, func() error {
+			connected()
+			return s.catchUp(ctx)
+		}, func(notification *repositories.StreamNotification) {
+			s.dispatch(ctx, notification)
+		})
+	})
+}
+
+// keepListening runs listen until ctx is canceled, reconnecting with
+// exponential backoff. listen calls connected once it listens, which resets
+// the backoff.
+func keepListening(ctx context.Context, name string, listen func(connected func()) error) {
+	const maxBackoff = 30 * time.Second
+	backoff := time.Second
+
+	for {
+		err := listen(func() {
+			backoff = time.Second
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("%s listener failed: %v. Reconnecting in %v", name, err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// catchUp runs whenever the listener (re)connects. On the first connect it
+// only notes the latest event; after a reconnect it delivers the events
+// committed in between. An event whose transaction was still running when
+// a later one committed may be missed by the live subscribers then.
+func (s *EventStream) catchUp(ctx context.Context) error {
+	s.mu.Lock()
+	lastID, reconnected := s.lastID, s.listening
+	s.mu.Unlock()
+
+	if !reconnected {
+		id, err := s.streamRepo.GetLastEventID(ctx)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		if id > s.lastID {
+			s.lastID = id
+		}
+		s.listening = true
+		s.mu.Unlock()
+		return nil
+	}
+
+	const batchSize = 100
+	for {
+		events, err := s.streamRepo.GetEventsAfter(ctx, lastID, batchSize)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			s.publish(event)
+			lastID = event.ID
+		}
+		if len(events) < batchSize {
+			return nil
+		}
+	}
+}
+
+func (s *EventStream) dispatch(ctx context.Context, notification *repositories.StreamNotification) {
+	s.mu.Lock()
+	if notification.ID > s.lastID {
+		s.lastID = notification.ID
+	}
+	_, subscribed := s.subscribers[notification.TenderID]
+	s.mu.Unlock()
+
+	if !subscribed {
+		return
+	}
+
+	event, err := s.streamRepo.GetEventByID(ctx, notification.ID)
+	if err != nil {
+		log.Printf("Failed to load stream event %d: %v", notification.ID, err)
+		return
+	}
+	s.publish(event)
+}
+
+// publish hands the event to the tender's subscribers, dropping the ones
+// whose buffer is full.
+func (s *EventStream) publish(event *models.StreamEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if event.ID > s.lastID {
+		s.lastID = event.ID
+	}
+	for subscription := range s.subscribers[event.TenderID] {
+		select {
+		case subscription.events <- event:
+		default:
+			s.remove(subscription)
+		}
+	}
+}
+
+func (s *EventStream) prune(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.streamRepo.DeleteEventsBefore(ctx, time.Now().Add(-s.retention))
+			if err != nil {
+				log.Printf("Failed to prune stream events: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Pruned %d stream events", deleted)
+			}
+		}
+	}
+}
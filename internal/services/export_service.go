@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"io"
+	"zadanie-6105/internal/export"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+)
+
+var tenderExportColumns = []string{
+	"ID", "Name", "Service type", "Status", "Visibility", "Version",
+	"Creator", "Created at", "Publish at", "Submission deadline", "Decision deadline",
+	"Budget", "Currency", "Bids", "Approved bids",
+}
+
+var bidExportColumns = []string{
+	"ID", "Name", "Status", "Version", "Author type", "Author ID", "Author",
+	"Amount", "Currency", "Delivery days", "Valid until", "Created at",
+	"Approvals", "Rejections", "Decision", "Decided by", "Decided at", "Feedback",
+}
+
+// ExportService writes an organization's tenders and a tender's bids as
+// spreadsheets, streaming the rows from the database to the writer.
+type ExportService struct {
+	tenderRepo repositories.TenderRepository
+	bidRepo    repositories.BidRepository
+}
+
+func NewExportService(tenderRepo repositories.TenderRepository, bidRepo repositories.BidRepository) *ExportService {
+	return &ExportService{tenderRepo: tenderRepo, bidRepo: bidRepo}
+}
+
+func (s *ExportService) IsUserAuthorizedToExportTenders(username, organizationID string) (bool, error) {
+	return s.tenderRepo.IsUserResponsibleForOrganization(username, organizationID)
+}
+
+func (s *ExportService) IsUserAuthorizedToExportBids(ctx context.Context, username, tenderID string) (bool, error) {
+	return s.bidRepo.IsUserResponsibleForTender(ctx, username, tenderID)
+}
+
+func (s *ExportService) ExportTenders(ctx context.Context, organizationID string, format export.Format, w io.Writer) error {
+	writer, err := export.NewWriter(format, w, "Tenders", tenderExportColumns)
+	if err != nil {
+		return err
+	}
+
+	err = s.tenderRepo.ExportOrganizationTenders(ctx, organizationID, func(row *models.TenderExportRow) error {
+		return writer.WriteRow(
+			row.ID, row.Name, string(row.ServiceType), string(row.Status), string(row.Visibility), row.Version,
+			row.CreatorUsername, row.CreatedAt, row.PublishAt, row.SubmissionDeadline, row.DecisionDeadline,
+			row.Budget, row.Currency, row.BidCount, row.ApprovedBidCount,
+		)
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+func (s *ExportService) ExportBids(ctx context.Context, tenderID string, format export.Format, w io.Writer) error {
+	writer, err := export.NewWriter(format, w, "Bids", bidExportColumns)
+	if err != nil {
+		return err
+	}
+
+	err = s.bidRepo.ExportTenderBids(ctx, tenderID, func(row *models.BidExportRow) error {
+		return writer.WriteRow(
+			row.ID, row.Name, string(row.Status), row.Version, string(row.AuthorType), row.AuthorID, row.AuthorName,
+			row.Amount, row.Currency, row.DeliveryDays, row.ValidUntil, row.CreatedAt,
+			row.Approvals, row.Rejections, string(row.Decision), row.DecidedBy, row.DecidedAt, row.Feedback,
+		)
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"zadanie-6105/internal/repositories"
+)
+
+// IdempotencySweeper periodically removes expired idempotency keys.
+type IdempotencySweeper struct {
+	repo     repositories.IdempotencyRepository
+	interval time.Duration
+}
+
+func NewIdempotencySweeper(repo repositories.IdempotencyRepository, interval time.Duration) *IdempotencySweeper {
+	return &IdempotencySweeper{repo: repo, interval: interval}
+}
+
+// Run sweeps until ctx is canceled.
+func (s *IdempotencySweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.repo.DeleteExpiredKeys(ctx, time.Now())
+			if err != nil {
+				log.Printf("Failed to sweep idempotency keys: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Swept %d expired idempotency keys", deleted)
+			}
+		}
+	}
+}
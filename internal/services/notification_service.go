@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+)
+
+type NotificationService struct {
+	notificationRepo repositories.NotificationRepository
+	tenderRepo       repositories.TenderRepository
+	employeeRepo     repositories.EmployeeRepository
+	uow              repositories.UnitOfWork
+}
+
+func NewNotificationService(
+	notificationRepo repositories.NotificationRepository,
+	tenderRepo repositories.TenderRepository,
+	employeeRepo repositories.EmployeeRepository,
+	uow repositories.UnitOfWork,
+) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		tenderRepo:       tenderRepo,
+		employeeRepo:     employeeRepo,
+		uow:              uow,
+	}
+}
+
+// HandleEvent is an EventHandler filling the inboxes: publishing an
+// invite-only tender notifies the invited organizations, a new bid notifies
+// the employees responsible for the tender, a decision or feedback notifies
+// the bid author and closing a tender notifies the authors of its active
+// bids.
+func (s *NotificationService) HandleEvent(ctx context.Context, event *models.OutboxEvent) error {
+	switch event.Type {
+	case models.EventTenderPublished:
+		var tender models.Tender
+		if err := json.Unmarshal(event.Data, &tender); err != nil {
+			return err
+		}
+		if tender.Visibility != models.TenderVisibilityInviteOnly {
+			return nil
+		}
+		recipients, err := s.notificationRepo.GetInvitedEmployees(ctx, tender.ID)
+		if err != nil {
+			return err
+		}
+		message := fmt.Sprintf("Tender %q you were invited to is published", tender.Name)
+		return s.notify(ctx, event, recipients, tender.ID, nil, message)
+
+	case models.EventBidCreated:
+		var bid models.Bid
+		if err := json.Unmarshal(event.Data, &bid); err != nil {
+			return err
+		}
+		tender, err := s.tenderRepo.GetTenderByID(ctx, bid.TenderID)
+		if err != nil {
+			return err
+		}
+		recipients, err := s.notificationRepo.GetOrganizationResponsibles(ctx, tender.OrganizationID)
+		if err != nil {
+			return err
+		}
+		message := fmt.Sprintf("New bid %q on tender %q", bid.Name, tender.Name)
+		return s.notify(ctx, event, recipients, tender.ID, &bid.ID, message)
+
+	case models.EventBidDecided:
+		var data models.BidDecidedEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return err
+		}
+		if data.Bid == nil || data.Decision == nil {
+			return fmt.Errorf("malformed %s event %s", event.Type, event.ID)
+		}
+		tender, err := s.tenderRepo.GetTenderByID(ctx, data.Bid.TenderID)
+		if err != nil {
+			return err
+		}
+		recipients, err := bidAuthorRecipients(ctx, s.notificationRepo, data.Bid)
+		if err != nil {
+			return err
+		}
+		message := fmt.Sprintf("Your bid %q on tender %q was %s", data.Bid.Name, tender.Name, strings.ToLower(string(data.Decision.Decision)))
+		return s.notify(ctx, event, recipients, tender.ID, &data.Bid.ID, message)
+
+	case models.EventBidFeedbackSubmitted:
+		var bid models.Bid
+		if err := json.Unmarshal(event.Data, &bid); err != nil {
+			return err
+		}
+		tender, err := s.tenderRepo.GetTenderByID(ctx, bid.TenderID)
+		if err != nil {
+			return err
+		}
+		recipients, err := bidAuthorRecipients(ctx, s.notificationRepo, &bid)
+		if err != nil {
+			return err
+		}
+		message := fmt.Sprintf("Feedback was left on your bid %q on tender %q", bid.Name, tender.Name)
+		return s.notify(ctx, event, recipients, tender.ID, &bid.ID, message)
+
+	case models.EventTenderClosed:
+		var tender models.Tender
+		if err := json.Unmarshal(event.Data, &tender); err != nil {
+			return err
+		}
+		bids, err := s.notificationRepo.GetActiveBidAuthors(ctx, tender.ID)
+		if err != nil {
+			return err
+		}
+		var recipients []string
+		for _, bid := range bids {
+			authors, err := bidAuthorRecipients(ctx, s.notificationRepo, bid)
+			if err != nil {
+				return err
+			}
+			recipients = append(recipients, authors...)
+		}
+		message := fmt.Sprintf("Tender %q you bid on is closed", tender.Name)
+		return s.notify(ctx, event, recipients, tender.ID, nil, message)
+	}
+	return nil
+}
+
+// bidAuthorRecipients returns the author of a user bid, or the employees
+// responsible for the organization that submitted an organization bid.
+func bidAuthorRecipients(ctx context.Context, notificationRepo repositories.NotificationRepository, bid *models.Bid) ([]string, error) {
+	if bid.AuthorType == models.AuthorTypeOrganization {
+		return notificationRepo.GetOrganizationResponsibles(ctx, bid.AuthorID)
+	}
+	return []string{bid.AuthorID}, nil
+}
+
+// notify creates one notification per recipient, skipping duplicates and
+// employees who turned the event type off.
+func (s *NotificationService) notify(ctx context.Context, event *models.OutboxEvent, recipients []string, tenderID string, bidID *string, message string) error {
+	optedOut, err := s.notificationRepo.GetOptedOutEmployees(ctx, event.Type, recipients)
+	if err != nil {
+		return err
+	}
+
+	skip := make(map[string]bool, len(optedOut)+len(recipients))
+	for _, id := range optedOut {
+		skip[id] = true
+	}
+
+	var notifications []*models.Notification
+	for _, recipientID := range recipients {
+		if skip[recipientID] {
+			continue
+		}
+		skip[recipientID] = true
+
+		notifications = append(notifications, &models.Notification{
+			RecipientID: recipientID,
+			EventID:     event.ID,
+			Type:        event.Type,
+			TenderID:    tenderID,
+			BidID:       bidID,
+			Message:     message,
+		})
+	}
+
+	return s.notificationRepo.CreateNotifications(ctx, notifications)
+}
+
+func (s *NotificationService) GetInbox(ctx context.Context, username string, unreadOnly bool, limit, offset int) (*models.NotificationInbox, error) {
+	employeeID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, err := s.notificationRepo.GetNotifications(ctx, employeeID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := s.notificationRepo.CountUnread(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NotificationInbox{UnreadCount: unread, Notifications: notifications}, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, username, notificationID string) error {
+	employeeID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	return s.notificationRepo.MarkRead(ctx, employeeID, notificationID, time.Now())
+}
+
+// MarkAllRead marks every unread notification of the user as read and
+// returns how many there were.
+func (s *NotificationService) MarkAllRead(ctx context.Context, username string) (int64, error) {
+	employeeID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+	return s.notificationRepo.MarkAllRead(ctx, employeeID, time.Now())
+}
+
+// GetPreferences lists every event type with the user's setting for it.
+func (s *NotificationService) GetPreferences(ctx context.Context, username string) ([]*models.NotificationPreference, error) {
+	employeeID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.notificationRepo.GetPreferences(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := make(map[models.DomainEventType]bool, len(stored))
+	for _, preference := range stored {
+		enabled[preference.EventType] = preference.Enabled
+	}
+
+	preferences := make([]*models.NotificationPreference, 0, len(models.DomainEventTypes))
+	for _, eventType := range models.DomainEventTypes {
+		preference := &models.NotificationPreference{EmployeeID: employeeID, EventType: eventType, Enabled: true}
+		if value, ok := enabled[eventType]; ok {
+			preference.Enabled = value
+		}
+		preferences = append(preferences, preference)
+	}
+	return preferences, nil
+}
+
+func (s *NotificationService) UpdatePreferences(ctx context.Context, username string, preferences []*models.NotificationPreference) ([]*models.NotificationPreference, error) {
+	for _, preference := range preferences {
+		if !preference.EventType.IsValid() {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidEventType, preference.EventType)
+		}
+	}
+
+	employeeID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	for _, preference := range preferences {
+		preference.EmployeeID = employeeID
+	}
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		return s.notificationRepo.SavePreferences(ctx, preferences)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPreferences(ctx, username)
+}
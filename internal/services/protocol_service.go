@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/pdf"
+	"zadanie-6105/internal/repositories"
+
+	"github.com/shopspring/decimal"
+)
+
+// Times in the protocol are given in UTC
+const protocolTimeLayout = "02.01.2006 15:04:05 UTC"
+
+var protocolTenderStatuses = map[models.TenderStatus]string{
+	models.TenderStatusCreated:   "Создан",
+	models.TenderStatusPublished: "Опубликован",
+	models.TenderStatusClosed:    "Закрыт",
+}
+
+var protocolServiceTypes = map[models.TenderServiceType]string{
+	models.ServiceTypeConstruction: "Строительство",
+	models.ServiceTypeDelivery:     "Доставка",
+	models.ServiceTypeManufacture:  "Производство",
+}
+
+var protocolBidStatuses = map[models.BidStatus]string{
+	models.BidStatusCreated:   "Создано",
+	models.BidStatusPublished: "Опубликовано",
+	models.BidStatusCanceled:  "Отменено",
+	models.BidStatusWithdrawn: "Отозвано",
+}
+
+var protocolDecisions = map[models.BidDecisionType]string{
+	models.BidDecisionApproved: "Одобрено",
+	models.BidDecisionRejected: "Отклонено",
+}
+
+// ProtocolService draws up the award protocol of a closed tender as a PDF
+// document in Russian, for the organization's legal records.
+type ProtocolService struct {
+	tenderRepo       repositories.TenderRepository
+	bidRepo          repositories.BidRepository
+	organizationRepo repositories.OrganizationRepository
+}
+
+func NewProtocolService(tenderRepo repositories.TenderRepository, bidRepo repositories.BidRepository, organizationRepo repositories.OrganizationRepository) *ProtocolService {
+	return &ProtocolService{tenderRepo: tenderRepo, bidRepo: bidRepo, organizationRepo: organizationRepo}
+}
+
+func (s *ProtocolService) IsUserAuthorizedToViewProtocol(ctx context.Context, username, tenderID string) (bool, error) {
+	return s.bidRepo.IsUserResponsibleForTender(ctx, username, tenderID)
+}
+
+// GetAwardProtocol gathers the protocol data of a tender, failing with
+// ErrTenderNotClosed while the tender is still open.
+func (s *ProtocolService) GetAwardProtocol(ctx context.Context, tenderID string) (*models.AwardProtocol, error) {
+	tender, err := s.tenderRepo.GetTenderByID(ctx, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	if tender.Status != models.TenderStatusClosed {
+		return nil, ErrTenderNotClosed
+	}
+
+	protocol := &models.AwardProtocol{Tender: tender}
+	if protocol.OrganizationName, err = s.organizationRepo.GetOrganizationName(ctx, tender.OrganizationID); err != nil {
+		return nil, err
+	}
+	err = s.bidRepo.ExportTenderBids(ctx, tenderID, func(row *models.BidExportRow) error {
+		protocol.Bids = append(protocol.Bids, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if protocol.Decisions, err = s.bidRepo.GetTenderDecisions(ctx, tenderID); err != nil {
+		return nil, err
+	}
+	if protocol.Reviews, err = s.bidRepo.GetTenderReviews(ctx, tenderID); err != nil {
+		return nil, err
+	}
+	return protocol, nil
+}
+
+// WriteAwardProtocol renders the protocol as a PDF file. Bids whose latest
+// decision approved them are named as the winners.
+func (s *ProtocolService) WriteAwardProtocol(protocol *models.AwardProtocol, generatedAt time.Time, w io.Writer) error {
+	tender := protocol.Tender
+
+	doc, err := pdf.New("Протокол подведения итогов тендера «"+tender.Name+"»", generatedAt)
+	if err != nil {
+		return err
+	}
+	doc.Footer = func(page, pages int) string {
+		return fmt.Sprintf("Тендер %s · сформировано %s · страница %d из %d", tender.ID, protocolTime(generatedAt), page, pages)
+	}
+
+	doc.Title("Протокол подведения итогов тендера")
+	doc.Title("«" + tender.Name + "»")
+
+	doc.Heading("1. Сведения о тендере")
+	doc.Field("Идентификатор", tender.ID)
+	doc.Field("Организация", protocolText(protocol.OrganizationName, tender.OrganizationID))
+	doc.Field("Наименование", tender.Name)
+	doc.Field("Описание", tender.Description)
+	doc.Field("Вид услуг", protocolText(protocolServiceTypes[tender.ServiceType], string(tender.ServiceType)))
+	doc.Field("Статус", protocolText(protocolTenderStatuses[tender.Status], string(tender.Status)))
+	doc.Field("Версия", strconv.Itoa(tender.Version))
+	doc.Field("Создан", tender.CreatorUsername+", "+protocolTime(tender.CreatedAt))
+	doc.Field("Срок подачи предложений", protocolOptionalTime(tender.SubmissionDeadline))
+	doc.Field("Срок принятия решения", protocolOptionalTime(tender.DecisionDeadline))
+	doc.Field("Бюджет", protocolAmount(tender.Budget, tender.Currency))
+
+	doc.Heading("2. Итоги")
+	var winners int
+	for _, bid := range protocol.Bids {
+		if bid.Decision == models.BidDecisionApproved {
+			winners++
+			doc.Field("Победитель", fmt.Sprintf("%s (%s), %s, одобрено %s %s",
+				bid.Name, bid.AuthorName, protocolAmount(bid.Amount, bid.Currency), bid.DecidedBy, protocolOptionalTime(bid.DecidedAt)))
+		}
+	}
+	if winners == 0 {
+		doc.Paragraph("Ни одно предложение не одобрено.")
+	}
+	doc.Paragraph(fmt.Sprintf("Поступило предложений: %d, принято решений: %d, оставлено отзывов: %d.",
+		len(protocol.Bids), len(protocol.Decisions), len(protocol.Reviews)))
+
+	doc.Heading("3. Поступившие предложения")
+	if len(protocol.Bids) == 0 {
+		doc.Paragraph("Предложения не поступали.")
+	} else {
+		rows := make([][]string, len(protocol.Bids))
+		for i, bid := range protocol.Bids {
+			rows[i] = []string{
+				strconv.Itoa(i + 1),
+				bid.Name + "\n" + bid.ID,
+				bid.AuthorName,
+				protocolTime(bid.CreatedAt),
+				protocolAmount(bid.Amount, bid.Currency),
+				strconv.Itoa(bid.DeliveryDays),
+				protocolText(protocolBidStatuses[bid.Status], string(bid.Status)),
+				protocolText(protocolDecisions[bid.Decision], "—"),
+			}
+		}
+		doc.Table([]pdf.Column{
+			{Title: "№", Width: 0.05},
+			{Title: "Предложение", Width: 0.25},
+			{Title: "Автор", Width: 0.13},
+			{Title: "Подано", Width: 0.12},
+			{Title: "Сумма", Width: 0.13},
+			{Title: "Срок поставки, дн.", Width: 0.1},
+			{Title: "Статус", Width: 0.11},
+			{Title: "Решение", Width: 0.11},
+		}, rows)
+	}
+
+	doc.Heading("4. Решения")
+	if len(protocol.Decisions) == 0 {
+		doc.Paragraph("Решения не принимались.")
+	} else {
+		rows := make([][]string, len(protocol.Decisions))
+		for i, decision := range protocol.Decisions {
+			rows[i] = []string{
+				protocolTime(decision.CreatedAt),
+				decision.BidName,
+				protocolText(protocolDecisions[decision.Decision], string(decision.Decision)),
+				decision.Approver,
+			}
+		}
+		doc.Table([]pdf.Column{
+			{Title: "Дата и время", Width: 0.2},
+			{Title: "Предложение", Width: 0.4},
+			{Title: "Решение", Width: 0.15},
+			{Title: "Утвердил", Width: 0.25},
+		}, rows)
+	}
+
+	doc.Heading("5. Обратная связь")
+	var feedback int
+	for _, bid := range protocol.Bids {
+		if bid.Feedback != "" {
+			feedback++
+			doc.Field(bid.Name, bid.Feedback)
+		}
+	}
+	if feedback > 0 {
+		doc.Space(6)
+	}
+	if len(protocol.Reviews) > 0 {
+		rows := make([][]string, len(protocol.Reviews))
+		for i, review := range protocol.Reviews {
+			rows[i] = []string{
+				protocolTime(review.CreatedAt),
+				review.BidName,
+				review.Reviewer,
+				fmt.Sprintf("%d из 5", review.Rating),
+				string(review.Category),
+				review.Review,
+			}
+		}
+		doc.Table([]pdf.Column{
+			{Title: "Дата и время", Width: 0.15},
+			{Title: "Предложение", Width: 0.18},
+			{Title: "Автор отзыва", Width: 0.13},
+			{Title: "Оценка", Width: 0.08},
+			{Title: "Категория", Width: 0.11},
+			{Title: "Отзыв", Width: 0.35},
+		}, rows)
+	}
+	if feedback == 0 && len(protocol.Reviews) == 0 {
+		doc.Paragraph("Обратная связь не предоставлялась.")
+	}
+
+	_, err = doc.WriteTo(w)
+	return err
+}
+
+// protocolText returns fallback for an empty text.
+func protocolText(text, fallback string) string {
+	if text == "" {
+		return fallback
+	}
+	return text
+}
+
+func protocolTime(t time.Time) string {
+	return t.UTC().Format(protocolTimeLayout)
+}
+
+func protocolOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "—"
+	}
+	return protocolTime(*t)
+}
+
+func protocolAmount(amount decimal.NullDecimal, currency string) string {
+	if !amount.Valid {
+		return "—"
+	}
+	if currency == "" {
+		return amount.Decimal.StringFixed(2)
+	}
+	return amount.Decimal.StringFixed(2) + " " + currency
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// Messages buffered per participant before it is dropped as too slow
+const reviewSessionBuffer = 16
+
+// ReviewSession is one participant's connection to a tender's review room.
+type ReviewSession struct {
+	rooms       *ReviewRooms
+	participant *models.ReviewParticipant
+	stream      *StreamSubscription
+	messages    chan *models.ReviewRoomMessage
+
+	// Presence and editing conflict last sent to the participant, guarded by
+	// rooms.mu
+	presence string
+	conflict string
+}
+
+func (s *ReviewSession) Participant() *models.ReviewParticipant {
+	return s.participant
+}
+
+// Messages delivers presence changes and editing conflicts. It is closed
+// when the participant falls too far behind or the server shuts down.
+func (s *ReviewSession) Messages() <-chan *models.ReviewRoomMessage {
+	return s.messages
+}
+
+// Events delivers the tender's events as they happen, see EventStream.
+func (s *ReviewSession) Events() <-chan *models.StreamEvent {
+	return s.stream.Events()
+}
+
+// StartEditing marks the participant as editing a bid of the room's tender.
+func (s *ReviewSession) StartEditing(ctx context.Context, bidID string) error {
+	bid, err := s.rooms.bidRepo.GetBidByID(ctx, bidID)
+	if err != nil {
+		return err
+	}
+	if bid.TenderID != s.participant.TenderID {
+		return gorm.ErrRecordNotFound
+	}
+	return s.setEditing(ctx, &bidID)
+}
+
+func (s *ReviewSession) StopEditing(ctx context.Context) error {
+	return s.setEditing(ctx, nil)
+}
+
+func (s *ReviewSession) setEditing(ctx context.Context, bidID *string) error {
+	participant := *s.participant
+	participant.EditingBidID = bidID
+	return s.rooms.roomRepo.UpdateParticipantEditing(ctx, &participant)
+}
+
+// ReviewRooms lets the responsible employees of a tender's organization
+// review its bids together: participants see each other, the tender's
+// events, and a warning when someone else edits the bid they edit.
+// Participants are stored so that every replica sees the whole room, and
+// changes are announced with NOTIFY like the events of EventStream.
+type ReviewRooms struct {
+	roomRepo    repositories.ReviewRoomRepository
+	bidRepo     repositories.BidRepository
+	eventStream *EventStream
+	// How long a participant stays in the room without being extended by
+	// its replica
+	presenceTTL time.Duration
+
+	mu    sync.Mutex
+	rooms map[string]map[*ReviewSession]bool
+	// Serializes refreshes so that a room never sees an older snapshot
+	// after a newer one
+	refreshMu sync.Mutex
+}
+
+func NewReviewRooms(roomRepo repositories.ReviewRoomRepository, bidRepo repositories.BidRepository, eventStream *EventStream, presenceTTL time.Duration) *ReviewRooms {
+	return &ReviewRooms{
+		roomRepo:    roomRepo,
+		bidRepo:     bidRepo,
+		eventStream: eventStream,
+		presenceTTL: presenceTTL,
+		rooms:       make(map[string]map[*ReviewSession]bool),
+	}
+}
+
+// IsUserAuthorizedToJoin allows the employees responsible for the tender's
+// organization, who may also see the reviews of its bids.
+func (r *ReviewRooms) IsUserAuthorizedToJoin(ctx context.Context, username, tenderID string) (bool, error) {
+	return r.bidRepo.IsUserResponsibleForTender(ctx, username, tenderID)
+}
+
+// Join adds the user to the tender's room. The session receives the current
+// participants right away.
+func (r *ReviewRooms) Join(ctx context.Context, username, tenderID string) (*ReviewSession, error) {
+	participant := &models.ReviewParticipant{
+		TenderID:  tenderID,
+		Username:  username,
+		ExpiresAt: time.Now().Add(r.presenceTTL),
+	}
+	if err := r.roomRepo.AddParticipant(ctx, participant); err != nil {
+		return nil, err
+	}
+
+	session := &ReviewSession{
+		rooms:       r,
+		participant: participant,
+		stream:      r.eventStream.Subscribe(tenderID),
+		messages:    make(chan *models.ReviewRoomMessage, reviewSessionBuffer),
+	}
+
+	r.mu.Lock()
+	if r.rooms[tenderID] == nil {
+		r.rooms[tenderID] = make(map[*ReviewSession]bool)
+	}
+	r.rooms[tenderID][session] = true
+	r.mu.Unlock()
+
+	r.refresh(ctx, tenderID)
+	return session, nil
+}
+
+// Leave removes the session from its room.
+func (r *ReviewRooms) Leave(ctx context.Context, session *ReviewSession) {
+	r.mu.Lock()
+	r.remove(session)
+	r.mu.Unlock()
+	r.eventStream.Unsubscribe(session.stream)
+
+	if err := r.roomRepo.RemoveParticipant(ctx, session.participant); err != nil {
+		log.Printf("Failed to remove review room participant %s: %v", session.participant.ID, err)
+	}
+}
+
+// remove must be called with r.mu held.
+func (r *ReviewRooms) remove(session *ReviewSession) {
+	tenderID := session.participant.TenderID
+	sessions := r.rooms[tenderID]
+	if !sessions[session] {
+		return
+	}
+	delete(sessions, session)
+	if len(sessions) == 0 {
+		delete(r.rooms, tenderID)
+	}
+	close(session.messages)
+}
+
+// Run keeps the local participants in their rooms and delivers the changes
+// made on any replica until ctx is canceled, then closes every session.
+func (r *ReviewRooms) Run(ctx context.Context) {
+	go r.keepAlive(ctx)
+
+	keepListening(ctx, "Review room", func(connected func()) error {
+		return r.roomRepo.Listen(ctx, func() error {
+			connected()
+			// Changes made while the listener was down
+			r.refreshAll(ctx)
+			return nil
+		}, func(tenderID string) {
+			r.refresh(ctx, tenderID)
+		})
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, sessions := range r.rooms {
+		for session := range sessions {
+			r.remove(session)
+		}
+	}
+}
+
+// keepAlive extends the local participants well before they expire, drops
+// the expired ones of other replicas and refreshes the local rooms, whose
+// participants may have expired without a notification.
+func (r *ReviewRooms) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(r.presenceTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+
+			r.mu.Lock()
+			var ids []string
+			for _, sessions := range r.rooms {
+				for session := range sessions {
+					ids = append(ids, session.participant.ID)
+				}
+			}
+			r.mu.Unlock()
+
+			if err := r.roomRepo.ExtendParticipants(ctx, ids, now.Add(r.presenceTTL)); err != nil {
+				log.Printf("Failed to extend review room participants: %v", err)
+			}
+			if _, err := r.roomRepo.DeleteExpiredParticipants(ctx, now); err != nil {
+				log.Printf("Failed to delete expired review room participants: %v", err)
+			}
+			r.refreshAll(ctx)
+		}
+	}
+}
+
+func (r *ReviewRooms) refreshAll(ctx context.Context) {
+	r.mu.Lock()
+	tenderIDs := make([]string, 0, len(r.rooms))
+	for tenderID := range r.rooms {
+		tenderIDs = append(tenderIDs, tenderID)
+	}
+	r.mu.Unlock()
+
+	for _, tenderID := range tenderIDs {
+		r.refresh(ctx, tenderID)
+	}
+}
+
+// refresh loads the room's participants and sends every local session the
+// presence and editing conflict that changed since it was last told.
+func (r *ReviewRooms) refresh(ctx context.Context, tenderID string) {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+
+	r.mu.Lock()
+	_, local := r.rooms[tenderID]
+	r.mu.Unlock()
+	if !local {
+		return
+	}
+
+	participants, err := r.roomRepo.GetParticipants(ctx, tenderID, time.Now())
+	if err != nil {
+		log.Printf("Failed to load review room of tender %s: %v", tenderID, err)
+		return
+	}
+	presence, err := json.Marshal(participants)
+	if err != nil {
+		log.Printf("Failed to encode review room of tender %s: %v", tenderID, err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for session := range r.rooms[tenderID] {
+		var messages []*models.ReviewRoomMessage
+
+		if session.presence != string(presence) {
+			session.presence = string(presence)
+			messages = append(messages, &models.ReviewRoomMessage{
+				Type:         models.ReviewRoomPresence,
+				Participants: participants,
+			})
+		}
+
+		bidID, usernames := editingConflict(session.participant, participants)
+		conflict := ""
+		if len(usernames) > 0 {
+			conflict = bidID + "\n" + strings.Join(usernames, "\n")
+		}
+		if session.conflict != conflict {
+			session.conflict = conflict
+			if conflict != "" {
+				messages = append(messages, &models.ReviewRoomMessage{
+					Type:      models.ReviewRoomEditingConflict,
+					BidID:     bidID,
+					Usernames: usernames,
+				})
+			}
+		}
+
+		for _, message := range messages {
+			select {
+			case session.messages <- message:
+			default:
+				r.remove(session)
+			}
+			if !r.rooms[tenderID][session] {
+				break
+			}
+		}
+	}
+}
+
+// editingConflict returns the bid the participant edits according to
+// participants, and the other users editing it.
+func editingConflict(participant *models.ReviewParticipant, participants []*models.ReviewParticipant) (string, []string) {
+	var bidID *string
+	for _, p := range participants {
+		if p.ID == participant.ID {
+			bidID = p.EditingBidID
+		}
+	}
+	if bidID == nil {
+		return "", nil
+	}
+
+	seen := make(map[string]bool)
+	var usernames []string
+	for _, p := range participants {
+		if p.EditingBidID == nil || *p.EditingBidID != *bidID || p.Username == participant.Username || seen[p.Username] {
+			continue
+		}
+		seen[p.Username] = true
+		usernames = append(usernames, p.Username)
+	}
+	sort.Strings(usernames)
+	return *bidID, usernames
+}
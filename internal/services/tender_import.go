@@ -0,0 +1,317 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Longest line of a JSON Lines import, in bytes
+const tenderImportMaxLine = 1 << 20
+
+// Columns of a CSV import, named like the fields of POST /tenders/new. There
+// is no status: imported tenders are created like any new tender and go
+// through the usual status changes and their events afterwards.
+var tenderImportColumns = map[string]bool{
+	"name":               true,
+	"description":        true,
+	"serviceType":        true,
+	"organizationId":     true,
+	"creatorUsername":    true,
+	"visibility":         true,
+	"submissionDeadline": true,
+	"decisionDeadline":   true,
+	"publishAt":          true,
+	"budget":             true,
+	"currency":           true,
+}
+
+// tenderImportRecord is a row of an import file. Err is set when the row
+// could not be decoded into a tender.
+type tenderImportRecord struct {
+	Line   int
+	Tender *models.Tender
+	Err    error
+}
+
+// ImportTenders reads tenders from a CSV or JSON Lines file, validates
+// every row with the rules of CreateTender, the importing user standing in
+// for a missing creatorUsername, and creates the tenders unless dryRun is
+// set. Problems with single rows are reported in the result; an error is
+// returned when the file cannot be read or the rows could not be checked.
+func (s *TenderService) ImportTenders(ctx context.Context, username string, format models.TenderImportFormat, r io.Reader, mode models.TenderImportMode, dryRun bool) (*models.TenderImportResult, error) {
+	if !mode.IsValid() {
+		return nil, fmt.Errorf("%w: unsupported mode %q", ErrInvalidTenderImport, mode)
+	}
+
+	records, err := parseTenderImport(format, r, s.importMaxRows)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: no rows", ErrInvalidTenderImport)
+	}
+	return s.importTenders(ctx, username, records, mode, dryRun)
+}
+
+// parseTenderImport reads the rows of an import file. It fails for a file
+// that cannot be read as a whole or has more than maxRows rows; rows that
+// cannot be decoded are returned with their error.
+func parseTenderImport(format models.TenderImportFormat, r io.Reader, maxRows int) ([]*tenderImportRecord, error) {
+	switch format {
+	case models.TenderImportCSV:
+		return parseTenderCSV(r, maxRows)
+	case models.TenderImportJSONL:
+		return parseTenderJSONL(r, maxRows)
+	}
+	return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidTenderImport, format)
+}
+
+func parseTenderCSV(r io.Reader, maxRows int) ([]*tenderImportRecord, error) {
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTenderImport, err)
+	}
+	// Spreadsheet applications start UTF-8 files with a byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	seen := make(map[string]bool)
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if !tenderImportColumns[column] {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidTenderImport, column)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidTenderImport, column)
+		}
+		seen[column] = true
+		header[i] = column
+	}
+
+	var records []*tenderImportRecord
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		// A row with the wrong number of fields is still returned
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTenderImport, err)
+		}
+		if len(records) == maxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrTenderImportTooLarge, maxRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		record := &tenderImportRecord{Line: line}
+		records = append(records, record)
+		if err != nil {
+			record.Err = fmt.Errorf("expected %d fields, got %d", len(header), len(values))
+			continue
+		}
+
+		// Decode the row like a JSON request body, leaving out empty cells
+		fields := make(map[string]string)
+		for i, value := range values {
+			if value = strings.TrimSpace(value); value != "" {
+				fields[header[i]] = value
+			}
+		}
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		record.Tender, record.Err = decodeImportedTender(data)
+	}
+}
+
+func parseTenderJSONL(r io.Reader, maxRows int) ([]*tenderImportRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), tenderImportMaxLine)
+
+	var records []*tenderImportRecord
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		if len(records) == maxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrTenderImportTooLarge, maxRows)
+		}
+
+		record := &tenderImportRecord{Line: line}
+		record.Tender, record.Err = decodeImportedTender(data)
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTenderImport, err)
+	}
+	return records, nil
+}
+
+func decodeImportedTender(data []byte) (*models.Tender, error) {
+	var tender models.Tender
+	if err := json.Unmarshal(data, &tender); err != nil {
+		return nil, fmt.Errorf("invalid tender: %v", err)
+	}
+	// Imports always create new tenders; a status given in JSON Lines is
+	// ignored like the id
+	tender.ID = ""
+	tender.Status = models.TenderStatusCreated
+	return &tender, nil
+}
+
+func (s *TenderService) importTenders(ctx context.Context, username string, records []*tenderImportRecord, mode models.TenderImportMode, dryRun bool) (*models.TenderImportResult, error) {
+	result := &models.TenderImportResult{
+		DryRun: dryRun,
+		Mode:   mode,
+		Total:  len(records),
+		Rows:   make([]*models.TenderImportRow, len(records)),
+	}
+
+	// Responsibility of a user for an organization, by user and organization
+	responsible := make(map[[2]string]bool)
+	var valid []int
+
+	for i, record := range records {
+		row := &models.TenderImportRow{Line: record.Line}
+		result.Rows[i] = row
+
+		if record.Err != nil {
+			row.Error = record.Err.Error()
+			result.Failed++
+			continue
+		}
+		problem, err := s.checkImportedTender(username, record.Tender, responsible)
+		if err != nil {
+			return nil, err
+		}
+		if problem != "" {
+			row.Error = problem
+			result.Failed++
+			continue
+		}
+
+		result.Valid++
+		valid = append(valid, i)
+	}
+
+	if dryRun || len(valid) == 0 {
+		return result, nil
+	}
+
+	if mode == models.TenderImportBestEffort {
+		for _, i := range valid {
+			tender := records[i].Tender
+			err := s.uow.Do(ctx, func(ctx context.Context) error {
+				return s.createTender(ctx, tender)
+			})
+			if err != nil {
+				result.Rows[i].Error = err.Error()
+				result.Failed++
+				continue
+			}
+			result.Rows[i].TenderID = tender.ID
+			result.Created++
+		}
+		return result, nil
+	}
+
+	if result.Failed > 0 {
+		return result, nil
+	}
+
+	failed := -1
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		for _, i := range valid {
+			if err := s.createTender(ctx, records[i].Tender); err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if failed < 0 {
+			return nil, err
+		}
+		result.Rows[failed].Error = err.Error()
+		result.Failed++
+		return result, nil
+	}
+
+	for _, i := range valid {
+		result.Rows[i].TenderID = records[i].Tender.ID
+	}
+	result.Created = len(valid)
+	return result, nil
+}
+
+// checkImportedTender returns what is wrong with the tender, if anything:
+// the struct validation and checks of CreateTender, and the responsibility
+// of both the importing user and the creator for the organization.
+func (s *TenderService) checkImportedTender(username string, tender *models.Tender, responsible map[[2]string]bool) (string, error) {
+	if tender.CreatorUsername == "" {
+		tender.CreatorUsername = username
+	}
+
+	if err := utils.ValidateStruct(tender); err != nil {
+		return describeTenderValidation(err), nil
+	}
+
+	for _, user := range []string{username, tender.CreatorUsername} {
+		key := [2]string{user, tender.OrganizationID}
+		ok, checked := responsible[key]
+		if !checked {
+			var err error
+			if ok, err = s.tenderRepo.IsUserResponsibleForOrganization(user, tender.OrganizationID); err != nil {
+				return "", err
+			}
+			responsible[key] = ok
+		}
+		if !ok {
+			return fmt.Sprintf("user %s is not responsible for organization %s", user, tender.OrganizationID), nil
+		}
+	}
+
+	if err := prepareNewTender(tender); err != nil {
+		return err.Error(), nil
+	}
+	return "", nil
+}
+
+// describeTenderValidation names the failed fields as in the JSON body.
+func describeTenderValidation(err error) string {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err.Error()
+	}
+
+	tenderType := reflect.TypeOf(models.Tender{})
+	problems := make([]string, len(fieldErrors))
+	for i, fieldError := range fieldErrors {
+		name := fieldError.Field()
+		if field, ok := tenderType.FieldByName(fieldError.StructField()); ok {
+			if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" {
+				name = tag
+			}
+		}
+		problems[i] = fmt.Sprintf("%s failed %s validation", name, fieldError.Tag())
+	}
+	return "invalid tender: " + strings.Join(problems, ", ")
+}
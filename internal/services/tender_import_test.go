@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"zadanie-6105/internal/models"
+)
+
+func TestParseTenderCSV(t *testing.T) {
+	input := "\ufeffname, organizationId ,budget,visibility\n" +
+		"Tender 1,0e6a5b2d-3c4f-4a1b-9e8d-7c6b5a4f3e2d,1500.50,\n" +
+		"Tender 2,0e6a5b2d-3c4f-4a1b-9e8d-7c6b5a4f3e2d\n" +
+		"\"Tender, \"\"3\"\"\",,not a number,InviteOnly\n"
+
+	records, err := parseTenderCSV(strings.NewReader(input), 10)
+	if err != nil {
+		t.Fatalf("parseTenderCSV() error = %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("parsed %d records, want 3", len(records))
+	}
+
+	first := records[0]
+	if first.Line != 2 || first.Err != nil {
+		t.Fatalf("record 1 = line %d, error %v, want line 2 without an error", first.Line, first.Err)
+	}
+	if first.Tender.Name != "Tender 1" || first.Tender.OrganizationID != "0e6a5b2d-3c4f-4a1b-9e8d-7c6b5a4f3e2d" ||
+		first.Tender.Budget.Decimal.String() != "1500.5" || first.Tender.Visibility != "" {
+		t.Errorf("record 1 tender = %+v", first.Tender)
+	}
+	if first.Tender.Status != models.TenderStatusCreated {
+		t.Errorf("record 1 status = %q, want %q", first.Tender.Status, models.TenderStatusCreated)
+	}
+
+	if records[1].Line != 3 || records[1].Err == nil || !strings.Contains(records[1].Err.Error(), "expected 4 fields, got 2") {
+		t.Errorf("record 2 = line %d, error %v, want a field count error at line 3", records[1].Line, records[1].Err)
+	}
+	if records[2].Line != 4 || records[2].Err == nil || !strings.HasPrefix(records[2].Err.Error(), "invalid tender") {
+		t.Errorf("record 3 = line %d, error %v, want an invalid tender at line 4", records[2].Line, records[2].Err)
+	}
+}
+
+func TestParseTenderCSVErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		maxRows int
+		wantErr error
+		wantMsg string
+	}{
+		{name: "unknown column", input: "name,owner\n", wantErr: ErrInvalidTenderImport, wantMsg: `unknown column "owner"`},
+		{name: "status column", input: "name,status\nTender,Published\n", wantErr: ErrInvalidTenderImport, wantMsg: `unknown column "status"`},
+		{name: "duplicate column", input: "name, name\n", wantErr: ErrInvalidTenderImport, wantMsg: `duplicate column "name"`},
+		{name: "malformed quotes", input: "name\n\"Tender\n", wantErr: ErrInvalidTenderImport},
+		{name: "too many rows", input: "name\nA\nB\nC\n", maxRows: 2, wantErr: ErrTenderImportTooLarge, wantMsg: "more than 2 rows"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxRows := tt.maxRows
+			if maxRows == 0 {
+				maxRows = 10
+			}
+			_, err := parseTenderCSV(strings.NewReader(tt.input), maxRows)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseTenderCSV() error = %v, want %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("parseTenderCSV() error = %v, want it to contain %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestParseTenderCSVEmpty(t *testing.T) {
+	records, err := parseTenderCSV(strings.NewReader(""), 10)
+	if err != nil || len(records) != 0 {
+		t.Errorf("parseTenderCSV(\"\") = %d records, error %v, want none", len(records), err)
+	}
+}
+
+func TestParseTenderJSONL(t *testing.T) {
+	input := `{"id":"7b0e8c44-1b7e-4c55-8a3e-5d2f1c0b9a11","name":"Tender 1","status":"Published"}` + "\n" +
+		"\n" +
+		"   \r\n" +
+		`{"name": "Tender 2", "budget": "12.30"}` + "\r\n" +
+		`{"name": ` + "\n" +
+		`["not", "a", "tender"]`
+
+	records, err := parseTenderJSONL(strings.NewReader(input), 4)
+	if err != nil {
+		t.Fatalf("parseTenderJSONL() error = %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("parsed %d records, want 4", len(records))
+	}
+
+	first := records[0]
+	if first.Line != 1 || first.Err != nil {
+		t.Fatalf("record 1 = line %d, error %v, want line 1 without an error", first.Line, first.Err)
+	}
+	if first.Tender.ID != "" || first.Tender.Name != "Tender 1" || first.Tender.Status != models.TenderStatusCreated {
+		t.Errorf("record 1 tender = %+v, want no id and status %q", first.Tender, models.TenderStatusCreated)
+	}
+
+	second := records[1]
+	if second.Line != 4 || second.Err != nil || second.Tender.Name != "Tender 2" || second.Tender.Budget.Decimal.String() != "12.3" {
+		t.Errorf("record 2 = line %d, error %v, tender %+v", second.Line, second.Err, second.Tender)
+	}
+	for i, line := range []int{5, 6} {
+		record := records[i+2]
+		if record.Line != line || record.Err == nil || !strings.HasPrefix(record.Err.Error(), "invalid tender") {
+			t.Errorf("record %d = line %d, error %v, want an invalid tender at line %d", i+3, record.Line, record.Err, line)
+		}
+	}
+
+	// Empty lines do not count as rows
+	if _, err := parseTenderJSONL(strings.NewReader(input), 3); !errors.Is(err, ErrTenderImportTooLarge) {
+		t.Errorf("parseTenderJSONL() with 3 rows allowed error = %v, want %v", err, ErrTenderImportTooLarge)
+	}
+}
+
+func TestParseTenderJSONLLongLine(t *testing.T) {
+	input := `{"name":"` + strings.Repeat("a", tenderImportMaxLine) + `"}`
+	if _, err := parseTenderJSONL(strings.NewReader(input), 10); !errors.Is(err, ErrInvalidTenderImport) {
+		t.Errorf("parseTenderJSONL() error = %v, want %v", err, ErrInvalidTenderImport)
+	}
+}
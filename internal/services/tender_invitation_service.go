@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+)
+
+type TenderInvitationService struct {
+	invitationRepo   repositories.TenderInvitationRepository
+	tenderRepo       repositories.TenderRepository
+	organizationRepo repositories.OrganizationRepository
+	employeeRepo     repositories.EmployeeRepository
+	uow              repositories.UnitOfWork
+}
+
+func NewTenderInvitationService(
+	invitationRepo repositories.TenderInvitationRepository,
+	tenderRepo repositories.TenderRepository,
+	organizationRepo repositories.OrganizationRepository,
+	employeeRepo repositories.EmployeeRepository,
+	uow repositories.UnitOfWork,
+) *TenderInvitationService {
+	return &TenderInvitationService{
+		invitationRepo:   invitationRepo,
+		tenderRepo:       tenderRepo,
+		organizationRepo: organizationRepo,
+		employeeRepo:     employeeRepo,
+		uow:              uow,
+	}
+}
+
+func (s *TenderInvitationService) IsUserAuthorizedToManageInvitations(username, tenderId string) (bool, error) {
+	return s.tenderRepo.IsUserResponsibleForTender(username, tenderId)
+}
+
+func (s *TenderInvitationService) IsOrganizationExists(ctx context.Context, organizationID string) (bool, error) {
+	return s.organizationRepo.IsOrganizationExists(ctx, organizationID)
+}
+
+// InviteOrganization invites a supplier organization to the tender. Inviting
+// an organization that declined earlier re-opens its invitation.
+func (s *TenderInvitationService) InviteOrganization(ctx context.Context, tenderId, organizationID, username string) (*models.TenderInvitation, error) {
+	inviterID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	tender, err := s.tenderRepo.GetTenderByID(ctx, tenderId)
+	if err != nil {
+		return nil, err
+	}
+	if tender.OrganizationID == organizationID {
+		return nil, ErrInvalidInvitation
+	}
+
+	invitation := &models.TenderInvitation{
+		TenderID:       tenderId,
+		OrganizationID: organizationID,
+		InvitedBy:      inviterID,
+	}
+	if err := s.invitationRepo.CreateInvitation(ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (s *TenderInvitationService) GetInvitations(ctx context.Context, tenderId string) ([]*models.TenderInvitation, error) {
+	return s.invitationRepo.GetInvitations(ctx, tenderId)
+}
+
+func (s *TenderInvitationService) GetInvitation(ctx context.Context, invitationID string) (*models.TenderInvitation, error) {
+	return s.invitationRepo.GetInvitationByID(ctx, invitationID)
+}
+
+func (s *TenderInvitationService) RevokeInvitation(ctx context.Context, invitationID string) error {
+	return s.invitationRepo.DeleteInvitation(ctx, invitationID)
+}
+
+// GetUserInvitations lists the invitations of every organization the user
+// is responsible for.
+func (s *TenderInvitationService) GetUserInvitations(ctx context.Context, username string, limit, offset int) ([]*models.TenderInvitation, error) {
+	return s.invitationRepo.GetInvitationsForUser(ctx, username, limit, offset)
+}
+
+func (s *TenderInvitationService) IsUserAuthorizedToRespond(username string, invitation *models.TenderInvitation) (bool, error) {
+	return s.tenderRepo.IsUserResponsibleForOrganization(username, invitation.OrganizationID)
+}
+
+// RespondToInvitation accepts or declines a pending invitation. An accepted
+// invitation can still be declined later, which withdraws access to the
+// tender; a declined one has to be re-issued by the tender owner.
+func (s *TenderInvitationService) RespondToInvitation(ctx context.Context, invitationID, username string, status models.InvitationStatus) (*models.TenderInvitation, error) {
+	responderID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	var invitation *models.TenderInvitation
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		invitation, err = s.invitationRepo.GetInvitationByID(ctx, invitationID)
+		if err != nil {
+			return err
+		}
+
+		if invitation.Status == models.InvitationStatusDeclined ||
+			(invitation.Status == models.InvitationStatusAccepted && status == models.InvitationStatusAccepted) {
+			return ErrInvitationAlreadyAnswered
+		}
+
+		if err := s.invitationRepo.UpdateInvitationStatus(ctx, invitationID, status, responderID); err != nil {
+			return err
+		}
+
+		invitation, err = s.invitationRepo.GetInvitationByID(ctx, invitationID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"time"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+type TenderQuestionService struct {
+	questionRepo repositories.TenderQuestionRepository
+	tenderRepo   repositories.TenderRepository
+	employeeRepo repositories.EmployeeRepository
+	uow          repositories.UnitOfWork
+}
+
+func NewTenderQuestionService(
+	questionRepo repositories.TenderQuestionRepository,
+	tenderRepo repositories.TenderRepository,
+	employeeRepo repositories.EmployeeRepository,
+	uow repositories.UnitOfWork,
+) *TenderQuestionService {
+	return &TenderQuestionService{
+		questionRepo: questionRepo,
+		tenderRepo:   tenderRepo,
+		employeeRepo: employeeRepo,
+		uow:          uow,
+	}
+}
+
+// AskQuestion records a question from a potential bidder. Questions are
+// only accepted while the tender is published.
+func (s *TenderQuestionService) AskQuestion(ctx context.Context, tenderID, username, text string) (*models.TenderQuestion, error) {
+	authorID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	question := &models.TenderQuestion{
+		TenderID: tenderID,
+		AuthorID: authorID,
+		Question: text,
+	}
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		tender, err := s.tenderRepo.GetTenderByID(ctx, tenderID)
+		if err != nil {
+			return err
+		}
+		if tender.Status != models.TenderStatusPublished {
+			return ErrTenderNotOpenForQuestions
+		}
+
+		visible, err := s.tenderRepo.IsTenderVisibleTo(ctx, tenderID, username, "")
+		if err != nil {
+			return err
+		}
+		if !visible {
+			return gorm.ErrRecordNotFound
+		}
+
+		return s.questionRepo.CreateQuestion(ctx, question)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+func (s *TenderQuestionService) IsUserAuthorizedToAnswer(ctx context.Context, username, questionID string) (bool, error) {
+	question, err := s.questionRepo.GetQuestionByID(ctx, questionID)
+	if err != nil {
+		return false, err
+	}
+	return s.tenderRepo.IsUserResponsibleForTender(username, question.TenderID)
+}
+
+// AnswerQuestion sets or replaces the answer. A public answer becomes
+// visible to every bidder with the asker's identity removed; otherwise only
+// the asker sees it.
+func (s *TenderQuestionService) AnswerQuestion(ctx context.Context, questionID, username, answer string, public bool) (*models.TenderQuestion, error) {
+	answererID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	var question *models.TenderQuestion
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		question, err = s.questionRepo.GetQuestionByID(ctx, questionID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		question.Answer = answer
+		question.AnsweredBy = &answererID
+		question.AnsweredAt = &now
+		question.IsPublic = public
+
+		return s.questionRepo.AnswerQuestion(ctx, question)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+// IsUserAuthorizedToViewQuestions mirrors tender visibility: questions of a
+// tender that has not been published yet are visible only to its
+// responsible employees, those of an invite-only tender only to invitees.
+func (s *TenderQuestionService) IsUserAuthorizedToViewQuestions(ctx context.Context, username, tenderID string) (bool, error) {
+	tender, err := s.tenderRepo.GetTenderByID(ctx, tenderID)
+	if err != nil {
+		return false, err
+	}
+	if tender.Status == models.TenderStatusPublished || tender.Status == models.TenderStatusClosed {
+		return s.tenderRepo.IsTenderVisibleTo(ctx, tenderID, username, "")
+	}
+	return s.tenderRepo.IsUserResponsibleForTender(username, tenderID)
+}
+
+// GetQuestions returns every question to the tender's responsible
+// employees. Other users see their own questions and the publicly answered
+// ones, without the identity of other askers or of the answering employee.
+func (s *TenderQuestionService) GetQuestions(ctx context.Context, tenderID, username string, limit, offset int) ([]*models.TenderQuestion, error) {
+	responsible, err := s.tenderRepo.IsUserResponsibleForTender(username, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	if responsible {
+		return s.questionRepo.GetQuestions(ctx, tenderID, "", limit, offset)
+	}
+
+	viewerID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := s.questionRepo.GetQuestions(ctx, tenderID, viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, question := range questions {
+		if question.AuthorID != viewerID {
+			question.AuthorID = ""
+		}
+		question.AnsweredBy = nil
+	}
+
+	return questions, nil
+}
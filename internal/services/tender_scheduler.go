@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"zadanie-6105/internal/audit"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+)
+
+// TenderScheduler applies time-based tender transitions: closing tenders
+// after their submission deadline and publishing scheduled ones. Every replica runs
+// it, but on each tick only the one holding the Postgres advisory lock does
+// the work.
+type TenderScheduler struct {
+	tenderRepo repositories.TenderRepository
+	locker     repositories.JobLocker
+	audit      *AuditService
+	events     *EventPublisher
+	interval   time.Duration
+}
+
+func NewTenderScheduler(tenderRepo repositories.TenderRepository, locker repositories.JobLocker, audit *AuditService, events *EventPublisher, interval time.Duration) *TenderScheduler {
+	return &TenderScheduler{tenderRepo: tenderRepo, locker: locker, audit: audit, events: events, interval: interval}
+}
+
+// Run ticks until ctx is canceled.
+func (s *TenderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.tick(ctx); err != nil {
+				log.Printf("Tender scheduler failed: %v", err)
+			}
+		}
+	}
+}
+
+func (s *TenderScheduler) tick(ctx context.Context) error {
+	ctx = audit.WithActor(ctx, audit.SystemActor)
+
+	_, err := s.locker.RunExclusive(ctx, repositories.TenderSchedulerLockID, func(ctx context.Context) error {
+		closed, err := s.tenderRepo.CloseExpiredTenders(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, id := range closed {
+			log.Printf("Tender %s closed: submission deadline passed", id)
+			if err := s.recordTransition(ctx, id, models.AuditTenderClosedByDeadline, models.TenderStatusPublished); err != nil {
+				return err
+			}
+		}
+
+		published, err := s.tenderRepo.PublishDueTenders(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, id := range published {
+			log.Printf("Tender %s published on schedule", id)
+			if err := s.recordTransition(ctx, id, models.AuditTenderPublishedBySchedule, models.TenderStatusCreated); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// recordTransition saves a status change made by the scheduler as a new
+// version, like UpdateTenderStatus does, then audits and publishes it. It
+// runs in the transaction holding the lock, so the events are committed
+// together with the transitions.
+func (s *TenderScheduler) recordTransition(ctx context.Context, tenderID string, action models.AuditAction, from models.TenderStatus) error {
+	tender, err := s.tenderRepo.GetTenderByID(ctx, tenderID)
+	if err != nil {
+		return err
+	}
+
+	before := *tender
+	before.Status = from
+	if err := s.tenderRepo.UpdateTender(ctx, tender); err != nil {
+		return err
+	}
+	if err := s.audit.Record(ctx, action, models.AuditEntityTender, tenderID, tender.OrganizationID, &before, tender); err != nil {
+		return err
+	}
+	return publishTenderStatus(ctx, s.events, tender)
+}
@@ -2,16 +2,25 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"time"
 	"zadanie-6105/internal/models"
 	"zadanie-6105/internal/repositories"
+	"zadanie-6105/pkg/utils"
 )
 
 type TenderService struct {
 	tenderRepo repositories.TenderRepository
+	uow        repositories.UnitOfWork
+	audit      *AuditService
+	events     *EventPublisher
+
+	// Most rows an import file may have
+	importMaxRows int
 }
 
-func NewTenderService(tenderRepo repositories.TenderRepository) *TenderService {
-	return &TenderService{tenderRepo: tenderRepo}
+func NewTenderService(tenderRepo repositories.TenderRepository, uow repositories.UnitOfWork, audit *AuditService, events *EventPublisher, importMaxRows int) *TenderService {
+	return &TenderService{tenderRepo: tenderRepo, uow: uow, audit: audit, events: events, importMaxRows: importMaxRows}
 }
 
 func (s *TenderService) IsUserAuthorizedToCreateTender(username, organizationID string) (bool, error) {
@@ -19,7 +28,60 @@ func (s *TenderService) IsUserAuthorizedToCreateTender(username, organizationID
 }
 
 func (s *TenderService) CreateTender(ctx context.Context, tender *models.Tender) error {
-	return s.tenderRepo.CreateTender(ctx, tender)
+	if err := prepareNewTender(tender); err != nil {
+		return err
+	}
+
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		return s.createTender(ctx, tender)
+	})
+}
+
+// prepareNewTender applies the checks of CreateTender that go beyond the
+// struct validation and fills in the defaults.
+func prepareNewTender(tender *models.Tender) error {
+	if err := validateTenderDeadlines(tender); err != nil {
+		return err
+	}
+	if err := validateTenderBudget(tender); err != nil {
+		return err
+	}
+	if tender.Visibility == "" {
+		tender.Visibility = models.TenderVisibilityPublic
+	}
+	return nil
+}
+
+// createTender must be called within a unit of work.
+func (s *TenderService) createTender(ctx context.Context, tender *models.Tender) error {
+	if err := s.tenderRepo.CreateTender(ctx, tender); err != nil {
+		return err
+	}
+	return s.audit.Record(ctx, models.AuditTenderCreated, models.AuditEntityTender, tender.ID, tender.OrganizationID, nil, tender)
+}
+
+func validateTenderBudget(tender *models.Tender) error {
+	if tender.Currency != "" && utils.ValidateVar(tender.Currency, "iso4217") != nil {
+		return fmt.Errorf("%w: currency must be an ISO 4217 code", ErrInvalidTenderBudget)
+	}
+	if !tender.Budget.Valid {
+		return nil
+	}
+	if tender.Budget.Decimal.IsNegative() {
+		return fmt.Errorf("%w: budget must not be negative", ErrInvalidTenderBudget)
+	}
+	if tender.Currency == "" {
+		return fmt.Errorf("%w: currency is required with budget", ErrInvalidTenderBudget)
+	}
+	return nil
+}
+
+func validateTenderDeadlines(tender *models.Tender) error {
+	if tender.SubmissionDeadline != nil && tender.DecisionDeadline != nil &&
+		tender.DecisionDeadline.Before(*tender.SubmissionDeadline) {
+		return ErrInvalidTenderDeadlines
+	}
+	return nil
 }
 
 func (s *TenderService) GetTenderByID(ctx context.Context, id string) (*models.Tender, error) {
@@ -30,35 +92,109 @@ func (s *TenderService) GetTendersByUser(ctx context.Context, username string, l
 	return s.tenderRepo.GetTendersByUser(ctx, username, limit, offset)
 }
 
-func (s *TenderService) GetTenders(ctx context.Context, serviceTypes []models.TenderServiceType, limit, offset int) ([]*models.Tender, error) {
-	return s.tenderRepo.GetTenders(ctx, serviceTypes, limit, offset)
+// GetTenders lists the tenders visible to the viewer, an employee or an
+// organization; invite-only tenders are hidden from outsiders.
+func (s *TenderService) GetTenders(ctx context.Context, serviceTypes []models.TenderServiceType, username, organizationID string, limit, offset int) ([]*models.Tender, error) {
+	return s.tenderRepo.GetTenders(ctx, serviceTypes, username, organizationID, limit, offset)
 }
 
-func (s *TenderService) UpdateTenderStatus(ctx context.Context, tenderId string, status models.TenderStatus) error {
-	return s.tenderRepo.UpdateTenderStatus(ctx, tenderId, status)
+func (s *TenderService) IsTenderVisible(ctx context.Context, tenderId, username, organizationID string) (bool, error) {
+	return s.tenderRepo.IsTenderVisibleTo(ctx, tenderId, username, organizationID)
+}
+
+func (s *TenderService) UpdateTenderStatus(ctx context.Context, tenderId string, status models.TenderStatus, expectedVersion int) error {
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		tender, err := s.tenderRepo.GetTenderByID(ctx, tenderId)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(tender.Version, expectedVersion); err != nil {
+			return err
+		}
+
+		if err := s.tenderRepo.UpdateTenderStatus(ctx, tenderId, status); err != nil {
+			return err
+		}
+
+		after := *tender
+		after.Status = status
+		// A status change is a new version, so that the ETag changes with it
+		if tender.Status != status {
+			if err := s.tenderRepo.UpdateTender(ctx, &after); err != nil {
+				return err
+			}
+		}
+		if err := s.audit.Record(ctx, models.AuditTenderStatusChanged, models.AuditEntityTender, tenderId, tender.OrganizationID, tender, &after); err != nil {
+			return err
+		}
+
+		if tender.Status == status {
+			return nil
+		}
+		return publishTenderStatus(ctx, s.events, &after)
+	})
 }
 
 func (s *TenderService) IsUserAuthorizedToUpdateStatus(username, tenderId string) (bool, error) {
 	return s.tenderRepo.IsUserResponsibleForTender(username, tenderId)
 }
 
-func (s *TenderService) UpdateTender(ctx context.Context, tenderId string, updates *models.Tender) (*models.Tender, error) {
-	existingTender, err := s.tenderRepo.GetTenderByID(ctx, tenderId)
-	if err != nil {
-		return nil, err
-	}
+func (s *TenderService) UpdateTender(ctx context.Context, tenderId string, updates *models.Tender, expectedVersion int) (*models.Tender, error) {
+	var existingTender *models.Tender
 
-	if updates.Name != "" {
-		existingTender.Name = updates.Name
-	}
-	if updates.Description != "" {
-		existingTender.Description = updates.Description
-	}
-	if updates.ServiceType != "" {
-		existingTender.ServiceType = updates.ServiceType
-	}
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		existingTender, err = s.tenderRepo.GetTenderByID(ctx, tenderId)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion(existingTender.Version, expectedVersion); err != nil {
+			return err
+		}
+
+		before := *existingTender
+
+		if updates.Name != "" {
+			existingTender.Name = updates.Name
+		}
+		if updates.Description != "" {
+			existingTender.Description = updates.Description
+		}
+		if updates.ServiceType != "" {
+			existingTender.ServiceType = updates.ServiceType
+		}
+		if updates.SubmissionDeadline != nil {
+			existingTender.SubmissionDeadline = updates.SubmissionDeadline
+		}
+		if updates.DecisionDeadline != nil {
+			existingTender.DecisionDeadline = updates.DecisionDeadline
+		}
+
+		if updates.Budget.Valid {
+			existingTender.Budget = updates.Budget
+		}
+		if updates.Currency != "" {
+			existingTender.Currency = updates.Currency
+		}
+		if updates.Visibility != "" {
+			existingTender.Visibility = updates.Visibility
+		}
+
+		if err := validateTenderDeadlines(existingTender); err != nil {
+			return err
+		}
+		if err := validateTenderBudget(existingTender); err != nil {
+			return err
+		}
 
-	if err := s.tenderRepo.UpdateTender(ctx, existingTender); err != nil {
+		if err := s.tenderRepo.UpdateTender(ctx, existingTender); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, models.AuditTenderUpdated, models.AuditEntityTender, tenderId, existingTender.OrganizationID, &before, existingTender)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -70,20 +206,58 @@ func (s *TenderService) IsUserAuthorizedToEditTender(username, tenderId string)
 }
 
 func (s *TenderService) DeleteTender(ctx context.Context, id string) error {
-	return s.tenderRepo.DeleteTender(ctx, id)
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		tender, err := s.tenderRepo.GetTenderByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.tenderRepo.DeleteTender(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, models.AuditTenderDeleted, models.AuditEntityTender, id, tender.OrganizationID, tender, nil)
+	})
 }
 
 func (s *TenderService) GetTenderVersions(ctx context.Context, id string) ([]*models.Tender, error) {
 	return s.tenderRepo.GetTenderVersions(ctx, id)
 }
 
-func (s *TenderService) RollbackTenderVersion(ctx context.Context, tenderId string, version int) (*models.Tender, error) {
-	if err := s.tenderRepo.RollbackTenderVersion(ctx, tenderId, version); err != nil {
+func (s *TenderService) RollbackTenderVersion(ctx context.Context, tenderId string, version int, expectedVersion int) (*models.Tender, error) {
+	var tender *models.Tender
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		current, err := s.tenderRepo.GetTenderByID(ctx, tenderId)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(current.Version, expectedVersion); err != nil {
+			return err
+		}
+
+		if err := s.tenderRepo.RollbackTenderVersion(ctx, tenderId, version); err != nil {
+			return err
+		}
+
+		// Получаем последнюю версию тендера
+		tender, err = s.tenderRepo.GetTenderByID(ctx, tenderId)
+		if err != nil {
+			return err
+		}
+
+		if err := s.audit.Record(ctx, models.AuditTenderRolledBack, models.AuditEntityTender, tenderId, tender.OrganizationID, current, tender); err != nil {
+			return err
+		}
+
+		if current.Status == tender.Status {
+			return nil
+		}
+		return publishTenderStatus(ctx, s.events, tender)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Получаем последнюю версию тендера
-	return s.tenderRepo.GetTenderByID(ctx, tenderId)
+	return tender, nil
 }
 
 func (s *TenderService) CheckUserExists(ctx context.Context, username string) (bool, error) {
@@ -93,3 +267,82 @@ func (s *TenderService) CheckUserExists(ctx context.Context, username string) (b
 func (s *TenderService) IsUserAuthorizedToViewStatus(username, tenderId string) (bool, error) {
 	return s.tenderRepo.IsUserResponsibleForTender(username, tenderId)
 }
+
+func (s *TenderService) ScheduleTenderPublication(ctx context.Context, tenderId string, publishAt time.Time) (*models.Tender, error) {
+	if !publishAt.After(time.Now()) {
+		return nil, ErrPublishAtInPast
+	}
+
+	var tender *models.Tender
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		tender, err = s.tenderRepo.GetTenderByID(ctx, tenderId)
+		if err != nil {
+			return err
+		}
+
+		if tender.Status != models.TenderStatusCreated {
+			return ErrTenderNotSchedulable
+		}
+
+		if err := s.tenderRepo.SetTenderPublishAt(ctx, tenderId, &publishAt); err != nil {
+			return err
+		}
+
+		before := *tender
+		tender.PublishAt = &publishAt
+		return s.audit.Record(ctx, models.AuditTenderPublicationScheduled, models.AuditEntityTender, tenderId, tender.OrganizationID, &before, tender)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tender, nil
+}
+
+func (s *TenderService) UnscheduleTenderPublication(ctx context.Context, tenderId string) (*models.Tender, error) {
+	var tender *models.Tender
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		tender, err = s.tenderRepo.GetTenderByID(ctx, tenderId)
+		if err != nil {
+			return err
+		}
+
+		if err := s.tenderRepo.SetTenderPublishAt(ctx, tenderId, nil); err != nil {
+			return err
+		}
+
+		before := *tender
+		tender.PublishAt = nil
+		return s.audit.Record(ctx, models.AuditTenderPublicationCanceled, models.AuditEntityTender, tenderId, tender.OrganizationID, &before, tender)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tender, nil
+}
+
+func (s *TenderService) GetScheduledTenders(ctx context.Context, organizationID string, limit, offset int) ([]*models.Tender, error) {
+	return s.tenderRepo.GetScheduledTenders(ctx, organizationID, limit, offset)
+}
+
+// publishTenderStatus emits tender.status_changed for a tender whose status
+// has just changed, followed by tender.published or tender.closed when it
+// entered that status.
+func publishTenderStatus(ctx context.Context, events *EventPublisher, tender *models.Tender) error {
+	if err := events.Publish(ctx, models.EventTenderStatusChanged, tender.OrganizationID, tender.ID, tender); err != nil {
+		return err
+	}
+
+	switch tender.Status {
+	case models.TenderStatusPublished:
+		return events.Publish(ctx, models.EventTenderPublished, tender.OrganizationID, tender.ID, tender)
+	case models.TenderStatusClosed:
+		return events.Publish(ctx, models.EventTenderClosed, tender.OrganizationID, tender.ID, tender)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// Headers of a webhook request. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the subscription secret.
+const (
+	WebhookIDHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookDispatchOptions tune delivery retries.
+type WebhookDispatchOptions struct {
+	// Events and deliveries processed per tick
+	BatchSize int
+	// Attempts before a delivery is dead-lettered
+	MaxAttempts int
+	// Delay before the first retry, doubled on every further attempt up to
+	// MaxRetryBackoff
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// How long a claimed delivery is reserved for one dispatcher
+	Lease time.Duration
+}
+
+// webhookPayload is the body POSTed to subscribers.
+type webhookPayload struct {
+	ID             string                 `json:"id"`
+	Type           models.DomainEventType `json:"type"`
+	OccurredAt     time.Time              `json:"occurredAt"`
+	OrganizationID string                 `json:"organizationId"`
+	Data           models.AuditSnapshot   `json:"data"`
+}
+
+// WebhookDispatcher fans outbox events out into deliveries for the matching
+// subscriptions and sends the due deliveries. Any number of replicas may run
+// it: events and deliveries are claimed with SKIP LOCKED, and deliveries are
+// sent outside of a transaction under a lease. The client should come from
+// NewWebhookClient, which refuses non-public addresses.
+type WebhookDispatcher struct {
+	outboxRepo  repositories.OutboxRepository
+	webhookRepo repositories.WebhookRepository
+	uow         repositories.UnitOfWork
+	client      *http.Client
+	options     WebhookDispatchOptions
+	interval    time.Duration
+}
+
+func NewWebhookDispatcher(
+	outboxRepo repositories.OutboxRepository,
+	webhookRepo repositories.WebhookRepository,
+	uow repositories.UnitOfWork,
+	client *http.Client,
+	options WebhookDispatchOptions,
+	interval time.Duration,
+) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		outboxRepo:  outboxRepo,
+		webhookRepo: webhookRepo,
+		uow:         uow,
+		client:      client,
+		options:     options,
+		interval:    interval,
+	}
+}
+
+// Run ticks until ctx is canceled.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.DispatchOnce(ctx); err != nil {
+				log.Printf("Webhook dispatcher failed: %v", err)
+			}
+		}
+	}
+}
+
+// DispatchOnce runs a single fan-out and delivery pass.
+func (d *WebhookDispatcher) DispatchOnce(ctx context.Context) error {
+	if err := d.fanOut(ctx); err != nil {
+		return err
+	}
+	return d.deliverDue(ctx, time.Now())
+}
+
+func (d *WebhookDispatcher) fanOut(ctx context.Context) error {
+	return d.uow.Do(ctx, func(ctx context.Context) error {
+		events, err := d.outboxRepo.ClaimUndispatchedEvents(ctx, d.options.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		var deliveries []*models.WebhookDelivery
+		for _, event := range events {
+			subscriptions, err := d.webhookRepo.GetMatchingSubscriptions(ctx, event.OrganizationID, event.Type)
+			if err != nil {
+				return err
+			}
+			for _, subscription := range subscriptions {
+				deliveries = append(deliveries, &models.WebhookDelivery{
+					SubscriptionID: subscription.ID,
+					EventID:        event.ID,
+					EventType:      event.Type,
+					Status:         models.WebhookDeliveryPending,
+					NextAttemptAt:  event.CreatedAt,
+				})
+			}
+		}
+
+		return d.webhookRepo.CreateDeliveries(ctx, deliveries)
+	})
+}
+
+func (d *WebhookDispatcher) deliverDue(ctx context.Context, now time.Time) error {
+	deliveries, err := d.webhookRepo.ClaimDueDeliveries(ctx, now, d.options.Lease, d.options.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		statusCode, err := d.send(ctx, delivery)
+		d.recordAttempt(delivery, statusCode, err, time.Now())
+
+		// The lease runs out on its own, so the delivery is retried later
+		if err := d.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			log.Printf("Failed to record webhook delivery %s: %v", delivery.ID, err)
+		}
+	}
+	return nil
+}
+
+// recordAttempt applies the outcome of a delivery attempt: success,
+// a retry with exponential backoff, or the dead letter state.
+func (d *WebhookDispatcher) recordAttempt(delivery *models.WebhookDelivery, statusCode int, err error, now time.Time) {
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.LockedUntil = nil
+
+	if err == nil {
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.options.MaxAttempts {
+		delivery.Status = models.WebhookDeliveryDeadLetter
+		log.Printf("Webhook delivery %s dead-lettered after %d attempts: %v", delivery.ID, delivery.Attempts, err)
+		return
+	}
+
+	delivery.NextAttemptAt = now.Add(retryBackoff(delivery.Attempts, d.options.RetryBackoff, d.options.MaxRetryBackoff))
+}
+
+// retryBackoff returns the delay after the given number of failed attempts:
+// initial, doubled on every further attempt up to max.
+func retryBackoff(attempts int, initial, max time.Duration) time.Duration {
+	backoff := initial
+	for i := 1; i < attempts && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff
+}
+
+// send POSTs the event to the subscription URL. Any 2xx response counts as
+// delivered.
+func (d *WebhookDispatcher) send(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	subscription, err := d.webhookRepo.GetSubscriptionByID(ctx, delivery.SubscriptionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("subscription no longer exists")
+		}
+		return 0, err
+	}
+	event, err := d.outboxRepo.GetEventByID(ctx, delivery.EventID)
+	if err != nil {
+		return 0, err
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		ID:             event.ID,
+		Type:           event.Type,
+		OccurredAt:     event.CreatedAt,
+		OrganizationID: event.OrganizationID,
+		Data:           event.Data,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIDHeader, delivery.ID)
+	req.Header.Set(WebhookEventHeader, string(event.Type))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(subscription.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the hex HMAC-SHA256 signature receivers
+// compare with the X-Webhook-Signature header.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// memoryOutboxRepository holds outbox events in memory; the methods the
+// dispatcher does not use are left to the embedded nil interface.
+type memoryOutboxRepository struct {
+	repositories.OutboxRepository
+	events []*models.OutboxEvent
+}
+
+func (r *memoryOutboxRepository) ClaimUndispatchedEvents(ctx context.Context, limit int) ([]*models.OutboxEvent, error) {
+	var events []*models.OutboxEvent
+	for _, event := range r.events {
+		if event.DispatchedAt == nil && len(events) < limit {
+			now := time.Now()
+			event.DispatchedAt = &now
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *memoryOutboxRepository) GetEventByID(ctx context.Context, id string) (*models.OutboxEvent, error) {
+	for _, event := range r.events {
+		if event.ID == id {
+			return event, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// memoryWebhookRepository holds subscriptions and deliveries in memory.
+type memoryWebhookRepository struct {
+	repositories.WebhookRepository
+	subscriptions []*models.WebhookSubscription
+	deliveries    []*models.WebhookDelivery
+	// Deliveries whose update fails
+	failUpdates map[string]bool
+}
+
+func (r *memoryWebhookRepository) GetSubscriptionByID(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	for _, subscription := range r.subscriptions {
+		if subscription.ID == id {
+			copied := *subscription
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryWebhookRepository) GetMatchingSubscriptions(ctx context.Context, organizationID string, eventType models.DomainEventType) ([]*models.WebhookSubscription, error) {
+	var subscriptions []*models.WebhookSubscription
+	for _, subscription := range r.subscriptions {
+		if subscription.OrganizationID == organizationID {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *memoryWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	for _, delivery := range deliveries {
+		delivery.ID = "delivery-" + strconv.Itoa(len(r.deliveries)+1)
+		copied := *delivery
+		r.deliveries = append(r.deliveries, &copied)
+	}
+	return nil
+}
+
+func (r *memoryWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status != models.WebhookDeliveryPending || delivery.NextAttemptAt.After(now) || len(deliveries) == limit {
+			continue
+		}
+		if delivery.LockedUntil != nil && delivery.LockedUntil.After(now) {
+			continue
+		}
+		lockedUntil := now.Add(lease)
+		delivery.LockedUntil = &lockedUntil
+		copied := *delivery
+		deliveries = append(deliveries, &copied)
+	}
+	return deliveries, nil
+}
+
+func (r *memoryWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if r.failUpdates[delivery.ID] {
+		return errors.New("connection reset")
+	}
+	for i, stored := range r.deliveries {
+		if stored.ID == delivery.ID {
+			copied := *delivery
+			r.deliveries[i] = &copied
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// directUnitOfWork runs the function without a transaction.
+type directUnitOfWork struct{}
+
+func (directUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// webhookReceiver records the requests of an httptest server, which answers
+// with the given status codes in turn.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	status := http.StatusOK
+	if len(rcv.requests) < len(rcv.statuses) {
+		status = rcv.statuses[len(rcv.requests)]
+	}
+	rcv.requests = append(rcv.requests, r)
+	rcv.bodies = append(rcv.bodies, body)
+	w.WriteHeader(status)
+}
+
+func newTestDispatcher(t *testing.T, statuses ...int) (*WebhookDispatcher, *memoryWebhookRepository, *webhookReceiver) {
+	t.Helper()
+
+	receiver := &webhookReceiver{statuses: statuses}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	outboxRepo := &memoryOutboxRepository{events: []*models.OutboxEvent{{
+		ID:             "event-1",
+		Type:           models.EventTenderPublished,
+		OrganizationID: "org-1",
+		Data:           models.AuditSnapshot(`{"id":"tender-1"}`),
+		CreatedAt:      time.Now().Add(-time.Second),
+	}}}
+	webhookRepo := &memoryWebhookRepository{
+		subscriptions: []*models.WebhookSubscription{{
+			ID:             "subscription-1",
+			OrganizationID: "org-1",
+			URL:            server.URL + "/hooks",
+			Secret:         "whsec_test",
+		}},
+		failUpdates: make(map[string]bool),
+	}
+
+	// The test server listens on loopback, so the client of httptest is
+	// used instead of one from NewWebhookClient
+	dispatcher := NewWebhookDispatcher(outboxRepo, webhookRepo, directUnitOfWork{}, server.Client(), WebhookDispatchOptions{
+		BatchSize:       10,
+		MaxAttempts:     2,
+		RetryBackoff:    time.Millisecond,
+		MaxRetryBackoff: time.Millisecond,
+		Lease:           time.Minute,
+	}, time.Second)
+	return dispatcher, webhookRepo, receiver
+}
+
+func TestDispatchOnceDelivers(t *testing.T) {
+	dispatcher, webhookRepo, receiver := newTestDispatcher(t)
+
+	if err := dispatcher.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce() error = %v", err)
+	}
+
+	if len(receiver.requests) != 1 {
+		t.Fatalf("requests = %d, want 1", len(receiver.requests))
+	}
+	r, body := receiver.requests[0], receiver.bodies[0]
+	if r.Method != http.MethodPost || r.URL.Path != "/hooks" {
+		t.Errorf("request = %s %s, want POST /hooks", r.Method, r.URL.Path)
+	}
+	if got := r.Header.Get(WebhookIDHeader); got != "delivery-1" {
+		t.Errorf("%s = %q, want delivery-1", WebhookIDHeader, got)
+	}
+	if got := r.Header.Get(WebhookEventHeader); got != string(models.EventTenderPublished) {
+		t.Errorf("%s = %q, want %s", WebhookEventHeader, got, models.EventTenderPublished)
+	}
+
+	timestamp, err := strconv.ParseInt(r.Header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("%s is not a Unix time: %v", WebhookTimestampHeader, err)
+	}
+	if want := "sha256=" + SignWebhookPayload("whsec_test", timestamp, body); r.Header.Get(WebhookSignatureHeader) != want {
+		t.Errorf("%s = %q, want %q", WebhookSignatureHeader, r.Header.Get(WebhookSignatureHeader), want)
+	}
+
+	var payload struct {
+		ID   string          `json:"id"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+	if payload.ID != "event-1" || string(payload.Data) != `{"id":"tender-1"}` {
+		t.Errorf("payload = %+v, want event-1 with its data", payload)
+	}
+
+	delivery := webhookRepo.deliveries[0]
+	if delivery.Status != models.WebhookDeliveryDelivered || delivery.Attempts != 1 || delivery.LastStatusCode != http.StatusOK {
+		t.Errorf("delivery = %+v, want delivered after one attempt", delivery)
+	}
+}
+
+func TestDispatchOnceRetriesAndDeadLetters(t *testing.T) {
+	dispatcher, webhookRepo, receiver := newTestDispatcher(t, http.StatusInternalServerError, http.StatusBadGateway)
+
+	if err := dispatcher.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce() error = %v", err)
+	}
+	delivery := webhookRepo.deliveries[0]
+	if delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != 1 || delivery.LastStatusCode != http.StatusInternalServerError {
+		t.Fatalf("delivery after the first attempt = %+v, want pending with one attempt", delivery)
+	}
+	if delivery.LockedUntil != nil || !strings.Contains(delivery.LastError, "500") {
+		t.Errorf("delivery after the first attempt = %+v, want unlocked with the error", delivery)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if err := dispatcher.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce() error = %v", err)
+	}
+	delivery = webhookRepo.deliveries[0]
+	if delivery.Status != models.WebhookDeliveryDeadLetter || delivery.Attempts != 2 || delivery.LastStatusCode != http.StatusBadGateway {
+		t.Errorf("delivery after the last attempt = %+v, want dead-lettered after two attempts", delivery)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if err := dispatcher.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce() error = %v", err)
+	}
+	if len(receiver.requests) != 2 {
+		t.Errorf("requests = %d, want no more after the dead letter", len(receiver.requests))
+	}
+}
+
+func TestDispatchOnceContinuesAfterFailedUpdate(t *testing.T) {
+	dispatcher, webhookRepo, receiver := newTestDispatcher(t)
+	second := *webhookRepo.subscriptions[0]
+	second.ID = "subscription-2"
+	webhookRepo.subscriptions = append(webhookRepo.subscriptions, &second)
+	webhookRepo.failUpdates["delivery-1"] = true
+
+	if err := dispatcher.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce() error = %v", err)
+	}
+	if len(receiver.requests) != 2 {
+		t.Fatalf("requests = %d, want both deliveries sent", len(receiver.requests))
+	}
+	if webhookRepo.deliveries[1].Status != models.WebhookDeliveryDelivered {
+		t.Errorf("second delivery = %+v, want delivered", webhookRepo.deliveries[1])
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 5, want: 8 * time.Minute},
+		{attempts: 10, want: time.Hour},
+		{attempts: 1000, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := retryBackoff(tt.attempts, 30*time.Second, time.Hour); got != tt.want {
+			t.Errorf("retryBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestIsPublicWebhookIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{ip: "127.0.0.1"},
+		{ip: "::1"},
+		{ip: "10.1.2.3"},
+		{ip: "172.16.0.1"},
+		{ip: "192.168.1.1"},
+		{ip: "169.254.169.254"},
+		{ip: "100.100.100.200"},
+		{ip: "0.0.0.0"},
+		{ip: "fd00::1"},
+		{ip: "fe80::1"},
+		{ip: "::ffff:127.0.0.1"},
+		{ip: "224.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicWebhookIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isPublicWebhookIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestNewWebhookClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	if _, err := NewWebhookClient(time.Second, false).Get(server.URL); err == nil || !strings.Contains(err.Error(), "not a public address") {
+		t.Errorf("Get() of a loopback server error = %v, want the address refused", err)
+	}
+
+	resp, err := NewWebhookClient(time.Second, true).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() with private targets allowed error = %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestCreateSubscriptionRejectsPrivateTargets(t *testing.T) {
+	service := NewWebhookService(nil, nil, nil, nil, false)
+
+	for _, url := range []string{
+		"http://169.254.169.254/latest/meta-data",
+		"http://127.0.0.1:8080/hooks",
+		"http://localhost/hooks",
+		"https://10.0.0.5/hooks",
+		"http://[::1]/hooks",
+		"ftp://example.com/hooks",
+	} {
+		err := service.CreateSubscription(context.Background(), "user1", &models.WebhookSubscription{URL: url})
+		if !errors.Is(err, ErrInvalidWebhook) {
+			t.Errorf("CreateSubscription(%s) error = %v, want ErrInvalidWebhook", url, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+	"zadanie-6105/internal/models"
+	"zadanie-6105/internal/repositories"
+)
+
+type WebhookService struct {
+	webhookRepo  repositories.WebhookRepository
+	tenderRepo   repositories.TenderRepository
+	employeeRepo repositories.EmployeeRepository
+	uow          repositories.UnitOfWork
+	// Accept subscriptions to private and local addresses, for development
+	allowPrivateTargets bool
+}
+
+func NewWebhookService(
+	webhookRepo repositories.WebhookRepository,
+	tenderRepo repositories.TenderRepository,
+	employeeRepo repositories.EmployeeRepository,
+	uow repositories.UnitOfWork,
+	allowPrivateTargets bool,
+) *WebhookService {
+	return &WebhookService{
+		webhookRepo:         webhookRepo,
+		tenderRepo:          tenderRepo,
+		employeeRepo:        employeeRepo,
+		uow:                 uow,
+		allowPrivateTargets: allowPrivateTargets,
+	}
+}
+
+// IsUserAuthorizedToManageWebhooks allows the employees responsible for the
+// organization.
+func (s *WebhookService) IsUserAuthorizedToManageWebhooks(username, organizationID string) (bool, error) {
+	return s.tenderRepo.IsUserResponsibleForOrganization(username, organizationID)
+}
+
+// CreateSubscription registers the webhook and generates its signing
+// secret, which is returned only in the created subscription. URLs of
+// private and local addresses are rejected unless allowed in the config.
+func (s *WebhookService) CreateSubscription(ctx context.Context, username string, subscription *models.WebhookSubscription) error {
+	target, err := url.Parse(subscription.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if !s.allowPrivateTargets {
+		if err := checkWebhookHost(ctx, target.Hostname()); err != nil {
+			return err
+		}
+	}
+	for _, eventType := range subscription.EventTypes {
+		if !eventType.IsValid() {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, eventType)
+		}
+	}
+
+	creatorID, err := s.employeeRepo.GetEmployeeIDByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+
+	subscription.CreatedBy = creatorID
+	subscription.Secret = "whsec_" + hex.EncodeToString(secret)
+	return s.webhookRepo.CreateSubscription(ctx, subscription)
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	subscription, err := s.webhookRepo.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+func (s *WebhookService) GetSubscriptions(ctx context.Context, organizationID string) ([]*models.WebhookSubscription, error) {
+	subscriptions, err := s.webhookRepo.GetSubscriptions(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+	return subscriptions, nil
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id string) error {
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		return s.webhookRepo.DeleteSubscription(ctx, id)
+	})
+}
+
+func (s *WebhookService) GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	return s.webhookRepo.GetDeliveryByID(ctx, id)
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, subscriptionID string, status models.WebhookDeliveryStatus, limit, offset int) ([]*models.WebhookDelivery, error) {
+	return s.webhookRepo.GetDeliveries(ctx, subscriptionID, status, limit, offset)
+}
+
+// Redeliver queues a delivered or dead-lettered delivery again with a fresh
+// attempt budget.
+func (s *WebhookService) Redeliver(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error) {
+	var delivery *models.WebhookDelivery
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		delivery, err = s.webhookRepo.GetDeliveryByID(ctx, deliveryID)
+		if err != nil {
+			return err
+		}
+		if delivery.Status == models.WebhookDeliveryPending {
+			return ErrWebhookDeliveryPending
+		}
+
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = time.Now()
+		delivery.LockedUntil = nil
+		delivery.LastError = ""
+		return s.webhookRepo.UpdateDelivery(ctx, delivery)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
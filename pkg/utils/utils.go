@@ -2,9 +2,11 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -52,6 +54,16 @@ func ValidateStruct(s interface{}) error {
 	return validate.Struct(s)
 }
 
+// ValidateStructPartial validates only the named fields of a struct, such as
+// the fields a partial update may change.
+func ValidateStructPartial(s interface{}, fields ...string) error {
+	return validate.StructPartial(s, fields...)
+}
+
+func ValidateVar(field interface{}, tag string) error {
+	return validate.Var(field, tag)
+}
+
 func GetPaginationParams(r *http.Request) (limit, offset int, err error) {
 	limit, err = ParseQueryParamInt(r, "limit", 5)
 	if err != nil {
@@ -60,3 +72,29 @@ func GetPaginationParams(r *http.Request) (limit, offset int, err error) {
 	offset, err = ParseQueryParamInt(r, "offset", 0)
 	return limit, offset, err
 }
+
+// SetETag exposes the entity version as a strong ETag.
+func SetETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// ParseIfMatch returns the version from an If-Match header produced by
+// SetETag. Zero means the header is absent or "*", i.e. no precondition.
+func ParseIfMatch(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, err
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil {
+		return 0, err
+	}
+	if version < 1 {
+		return 0, fmt.Errorf("invalid version %d", version)
+	}
+	return version, nil
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetETag(t *testing.T) {
+	w := httptest.NewRecorder()
+	SetETag(w, 7)
+	if got := w.Header().Get("ETag"); got != `"7"` {
+		t.Errorf("ETag = %s, want \"7\"", got)
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    int
+		wantErr bool
+	}{
+		{header: "", want: 0},
+		{header: "*", want: 0},
+		{header: `"3"`, want: 3},
+		{header: ` "12" `, want: 12},
+		{header: "3", wantErr: true},
+		{header: `W/"3"`, wantErr: true},
+		{header: `"abc"`, wantErr: true},
+		{header: `"0"`, wantErr: true},
+		{header: `"-1"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/api/tenders/1/status", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			got, err := ParseIfMatch(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIfMatch(%q) error = %v, wantErr %v", tt.header, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseIfMatch(%q) = %d, want %d", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+// An ETag sent back as If-Match yields the version it was set from.
+func TestETagRoundTrip(t *testing.T) {
+	for _, version := range []int{1, 2, 100} {
+		w := httptest.NewRecorder()
+		SetETag(w, version)
+
+		r := httptest.NewRequest("PATCH", "/api/bids/1/edit", nil)
+		r.Header.Set("If-Match", w.Header().Get("ETag"))
+		got, err := ParseIfMatch(r)
+		if err != nil || got != version {
+			t.Errorf("round trip of version %d = %d, %v", version, got, err)
+		}
+	}
+}
+
+func TestValidateStructPartial(t *testing.T) {
+	type item struct {
+		Name       string `validate:"required"`
+		Visibility string `validate:"omitempty,oneof=Public InviteOnly"`
+	}
+
+	tests := []struct {
+		visibility string
+		wantErr    bool
+	}{
+		{visibility: ""},
+		{visibility: "Public"},
+		{visibility: "InviteOnly"},
+		{visibility: "Foo", wantErr: true},
+	}
+	for _, tt := range tests {
+		// Name is required but not among the validated fields
+		err := ValidateStructPartial(&item{Visibility: tt.visibility}, "Visibility")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateStructPartial(%q) error = %v, wantErr %v", tt.visibility, err, tt.wantErr)
+		}
+	}
+}